@@ -0,0 +1,204 @@
+// Package adaptor implements BIP340 adaptor signatures. An adaptor
+// signature over an adaptor point T = t*G can be checked by anyone but
+// only becomes a valid schnorr signature once completed with the secret
+// t. Publishing the completed signature in turn reveals t to whoever
+// holds the adaptor signature, which is what makes atomic swap style
+// conditions work.
+//
+// With nonce k, R = k*G + T and challenge e = H(R || P || m), the
+// adaptor signature is s' = k + e*d, or s' = -k + e*d if R has an odd
+// Y. The final signature is (R, s' + t), or (-R, s' - t) respectively,
+// so s' is off from the final s by exactly t.
+package adaptor
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+var (
+	ErrMessageSize       = errors.New("message must be a 32-byte digest")
+	ErrInvalidSecret     = errors.New("adaptor secret is zero or not below the curve order")
+	ErrSignatureMismatch = errors.New("signature was not completed from this adaptor signature")
+)
+
+var (
+	nonceTag     = []byte("Unchained/adaptor-nonce")
+	challengeTag = []byte("BIP0340/challenge")
+)
+
+// Signature is an adaptor signature. R includes the adaptor point and
+// keeps its Y parity, T is the adaptor point itself.
+type Signature struct {
+	R *btcec.PublicKey
+	T *btcec.PublicKey
+	S btcec.ModNScalar
+}
+
+// Sign creates an adaptor signature over the 32-byte digest msg that
+// can be completed with the discrete log of adaptorPoint.
+func Sign(priv *btcec.PrivateKey, msg []byte, adaptorPoint *btcec.PublicKey) (*Signature, error) {
+	if len(msg) != 32 {
+		return nil, ErrMessageSize
+	}
+
+	d := priv.Key
+	defer d.Zero()
+	if isOdd(priv.PubKey()) {
+		d.Negate()
+	}
+
+	// The nonce mixes the key, message and adaptor point with fresh
+	// randomness, like BIP340 auxiliary randomness.
+	var aux [32]byte
+	if _, err := rand.Read(aux[:]); err != nil {
+		return nil, err
+	}
+	dBytes := d.Bytes()
+	defer clear(dBytes[:])
+	hash := chainhash.TaggedHash(nonceTag, dBytes[:], aux[:], adaptorPoint.SerializeCompressed(), msg)
+
+	var k btcec.ModNScalar
+	defer k.Zero()
+	k.SetByteSlice(hash[:])
+	if k.IsZero() {
+		return nil, errors.New("adaptor nonce is zero")
+	}
+
+	var kG, t, r btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(&k, &kG)
+	adaptorPoint.AsJacobian(&t)
+	btcec.AddNonConst(&kG, &t, &r)
+	if r == (btcec.JacobianPoint{}) || r.Z.IsZero() {
+		return nil, errors.New("adaptor nonce is the point at infinity")
+	}
+	r.ToAffine()
+	nonce := btcec.NewPublicKey(&r.X, &r.Y)
+
+	if r.Y.IsOdd() {
+		k.Negate()
+	}
+
+	e := challenge(nonce, priv.PubKey(), msg)
+
+	sig := &Signature{R: nonce, T: adaptorPoint}
+	sig.S.Mul2(e, &d).Add(&k)
+	return sig, nil
+}
+
+// Verify reports whether sig is a valid adaptor signature of msg by
+// pub, that is whether completing it with the secret of sig.T yields a
+// valid BIP340 signature.
+func Verify(pub *btcec.PublicKey, msg []byte, sig *Signature) bool {
+	if len(msg) != 32 || sig == nil || sig.R == nil || sig.T == nil {
+		return false
+	}
+
+	// Check s'*G == ±(R - T) + e*P, with P taken as its even Y point.
+	even, err := schnorr.ParsePubKey(schnorr.SerializePubKey(pub))
+	if err != nil {
+		return false
+	}
+
+	var p, r, t, eP, sG, rhs btcec.JacobianPoint
+	even.AsJacobian(&p)
+	sig.R.AsJacobian(&r)
+	sig.T.AsJacobian(&t)
+
+	t.Y.Negate(1)
+	t.Y.Normalize()
+	btcec.AddNonConst(&r, &t, &rhs)
+	if isOdd(sig.R) {
+		rhs.ToAffine()
+		rhs.Y.Negate(1)
+		rhs.Y.Normalize()
+	}
+
+	btcec.ScalarMultNonConst(challenge(sig.R, pub, msg), &p, &eP)
+	btcec.AddNonConst(&rhs, &eP, &rhs)
+	btcec.ScalarBaseMultNonConst(&sig.S, &sG)
+
+	sG.ToAffine()
+	rhs.ToAffine()
+	return sG == rhs
+}
+
+// Complete turns sig into a regular BIP340 signature using the 32-byte
+// adaptor secret.
+func Complete(sig *Signature, secret []byte) (*schnorr.Signature, error) {
+	if len(secret) != 32 {
+		return nil, ErrInvalidSecret
+	}
+
+	var t btcec.ModNScalar
+	defer t.Zero()
+	if overflow := t.SetByteSlice(secret); overflow || t.IsZero() {
+		return nil, ErrInvalidSecret
+	}
+
+	if isOdd(sig.R) {
+		t.Negate()
+	}
+
+	var s btcec.ModNScalar
+	s.Add2(&sig.S, &t)
+
+	var r btcec.FieldVal
+	r.SetByteSlice(schnorr.SerializePubKey(sig.R))
+	return schnorr.NewSignature(&r, &s), nil
+}
+
+// Extract recovers the adaptor secret from sig and the completed
+// signature final. It fails if final was not completed from sig.
+func Extract(sig *Signature, final *schnorr.Signature) ([]byte, error) {
+	serialized := final.Serialize()
+	if len(serialized) != schnorr.SignatureSize {
+		return nil, ErrSignatureMismatch
+	}
+
+	if !bytes.Equal(serialized[:32], schnorr.SerializePubKey(sig.R)) {
+		return nil, ErrSignatureMismatch
+	}
+
+	var s btcec.ModNScalar
+	if overflow := s.SetByteSlice(serialized[32:]); overflow {
+		return nil, ErrSignatureMismatch
+	}
+
+	// t = s - s', or s' - s if R had to be negated.
+	var t btcec.ModNScalar
+	t.NegateVal(&sig.S).Add(&s)
+	if isOdd(sig.R) {
+		t.Negate()
+	}
+
+	var tG btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(&t, &tG)
+	tG.ToAffine()
+	if !btcec.NewPublicKey(&tG.X, &tG.Y).IsEqual(sig.T) {
+		return nil, ErrSignatureMismatch
+	}
+
+	secret := t.Bytes()
+	return secret[:], nil
+}
+
+// challenge computes the BIP340 challenge e = H(R || P || m).
+func challenge(nonce, pub *btcec.PublicKey, msg []byte) *btcec.ModNScalar {
+	hash := chainhash.TaggedHash(
+		challengeTag, schnorr.SerializePubKey(nonce), schnorr.SerializePubKey(pub), msg,
+	)
+
+	e := new(btcec.ModNScalar)
+	e.SetByteSlice(hash[:])
+	return e
+}
+
+func isOdd(pub *btcec.PublicKey) bool {
+	return pub.SerializeCompressed()[0] == 0x03
+}
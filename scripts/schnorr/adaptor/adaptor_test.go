@@ -0,0 +1,137 @@
+package adaptor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// newKey returns a fresh private key.
+func newKey(t *testing.T) *btcec.PrivateKey {
+	t.Helper()
+	priv, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+// asSignature reads an adaptor signature as a plain BIP340 signature,
+// as someone trying to use it without the secret would.
+func asSignature(sig *Signature) *schnorr.Signature {
+	var r btcec.FieldVal
+	r.SetByteSlice(schnorr.SerializePubKey(sig.R))
+	return schnorr.NewSignature(&r, &sig.S)
+}
+
+// Fresh keys, secrets and nonces each time, so both Y parities of the
+// signing key and of R are covered.
+func TestCompleteAndExtract(t *testing.T) {
+	for i := 0; i < 32; i++ {
+		priv, secret := newKey(t), newKey(t)
+		msg := sha256.Sum256([]byte{byte(i)})
+
+		sig, err := Sign(priv, msg[:], secret.PubKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Verify(priv.PubKey(), msg[:], sig) {
+			t.Fatalf("round %d: adaptor signature does not verify", i)
+		}
+		if asSignature(sig).Verify(msg[:], priv.PubKey()) {
+			t.Fatalf("round %d: adaptor signature verifies as a regular signature", i)
+		}
+
+		final, err := Complete(sig, secret.Serialize())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !final.Verify(msg[:], priv.PubKey()) {
+			t.Fatalf("round %d: completed signature does not verify", i)
+		}
+
+		extracted, err := Extract(sig, final)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(extracted, secret.Serialize()) {
+			t.Fatalf("round %d: extracted %x, want %x", i, extracted, secret.Serialize())
+		}
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	priv, secret := newKey(t), newKey(t)
+	msg := sha256.Sum256([]byte("conditional"))
+	sig, err := Sign(priv, msg[:], secret.PubKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := sha256.Sum256([]byte("other"))
+
+	if Verify(priv.PubKey(), other[:], sig) {
+		t.Error("verified over another message")
+	}
+	if Verify(newKey(t).PubKey(), msg[:], sig) {
+		t.Error("verified under another key")
+	}
+	if Verify(priv.PubKey(), msg[:], &Signature{R: sig.R, T: newKey(t).PubKey(), S: sig.S}) {
+		t.Error("verified with another adaptor point")
+	}
+	if Verify(priv.PubKey(), msg[:5], sig) || Verify(priv.PubKey(), msg[:], nil) {
+		t.Error("verified a malformed input")
+	}
+}
+
+func TestCompleteWithWrongSecret(t *testing.T) {
+	priv, secret := newKey(t), newKey(t)
+	msg := sha256.Sum256([]byte("conditional"))
+	sig, err := Sign(priv, msg[:], secret.PubKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	final, err := Complete(sig, newKey(t).Serialize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if final.Verify(msg[:], priv.PubKey()) {
+		t.Error("signature completed with the wrong secret verifies")
+	}
+	if _, err := Extract(sig, final); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("Extract: got %v, want ErrSignatureMismatch", err)
+	}
+
+	for _, bad := range [][]byte{make([]byte, 32), bytes.Repeat([]byte{0xff}, 32), secret.Serialize()[:31]} {
+		if _, err := Complete(sig, bad); !errors.Is(err, ErrInvalidSecret) {
+			t.Errorf("Complete(%x): got %v, want ErrInvalidSecret", bad, err)
+		}
+	}
+}
+
+func TestExtractFromUnrelatedSignature(t *testing.T) {
+	priv, secret := newKey(t), newKey(t)
+	msg := sha256.Sum256([]byte("conditional"))
+	sig, err := Sign(priv, msg[:], secret.PubKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unrelated, err := schnorr.Sign(priv, msg[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Extract(sig, unrelated); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("got %v, want ErrSignatureMismatch", err)
+	}
+}
+
+func TestSignRejectsLongMessage(t *testing.T) {
+	if _, err := Sign(newKey(t), make([]byte, 33), newKey(t).PubKey()); !errors.Is(err, ErrMessageSize) {
+		t.Errorf("got %v, want ErrMessageSize", err)
+	}
+}
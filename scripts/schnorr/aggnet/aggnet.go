@@ -0,0 +1,246 @@
+// Package aggnet carries aggsig signing rounds over TCP. A coordinator
+// dials every participant, sends the message and signer set, collects
+// the public nonces, sends back the aggregated nonce and collects the
+// partial signatures. Each of the two rounds has a deadline; a round
+// that runs past it aborts with an error naming the participants that
+// did not answer.
+package aggnet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TimeleapLabs/go-schnorr/aggsig"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+)
+
+// DefaultRoundTimeout is used when no round timeout is set.
+const DefaultRoundTimeout = 10 * time.Second
+
+var (
+	ErrTimeout     = errors.New("participants did not respond before the round deadline")
+	ErrPeer        = errors.New("peer reported an error")
+	ErrBadPartial  = errors.New("participant sent an invalid partial signature")
+	ErrNoPeers     = errors.New("no participants")
+	ErrRequestSize = errors.New("request has the wrong size")
+	ErrNotApproved = errors.New("message was not approved")
+)
+
+// Peer is a participant as seen by the coordinator.
+type Peer struct {
+	Addr      string
+	PublicKey *btcec.PublicKey
+}
+
+func (p Peer) String() string {
+	return fmt.Sprintf("%s (0x%x)", p.Addr, signer.XOnlyPubKey(p.PublicKey))
+}
+
+// Coordinator runs signing rounds with a fixed set of participants.
+type Coordinator struct {
+	Peers        []Peer
+	RoundTimeout time.Duration
+	Dialer       net.Dialer
+}
+
+// Sign runs both rounds with every peer and returns the combined
+// signature over the 32-byte digest msg.
+func (c *Coordinator) Sign(ctx context.Context, msg []byte) (*schnorr.Signature, error) {
+	if len(c.Peers) == 0 {
+		return nil, ErrNoPeers
+	}
+	if len(msg) != 32 {
+		return nil, aggsig.ErrMessageSize
+	}
+
+	keys := make([]*btcec.PublicKey, len(c.Peers))
+	for i, peer := range c.Peers {
+		keys[i] = peer.PublicKey
+	}
+
+	cache, err := aggsig.NewKeyAggCache(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	conns := make([]net.Conn, len(c.Peers))
+	defer func() {
+		for _, conn := range conns {
+			if conn != nil {
+				conn.Close()
+			}
+		}
+	}()
+
+	timeout := c.RoundTimeout
+	if timeout <= 0 {
+		timeout = DefaultRoundTimeout
+	}
+
+	request := encodeRequest(msg, cache.Keys())
+	pubNonces := make([][musig2.PubNonceSize]byte, len(c.Peers))
+
+	// Dialing counts against the first round's deadline.
+	err = c.round(ctx, timeout, func(ctx context.Context, i int) error {
+		conn, err := c.Dialer.DialContext(ctx, "tcp", c.Peers[i].Addr)
+		if err != nil {
+			return err
+		}
+		conns[i] = conn
+
+		return exchange(ctx, conn, typeRequest, request, typeNonce, pubNonces[i][:])
+	})
+	if err != nil {
+		return nil, fmt.Errorf("nonce round: %w", err)
+	}
+
+	aggNonce, err := aggsig.AggregateNonces(pubNonces)
+	if err != nil {
+		return nil, err
+	}
+
+	partialSigs := make([]*aggsig.PartialSignature, len(c.Peers))
+	err = c.round(ctx, timeout, func(ctx context.Context, i int) error {
+		var sBytes [32]byte
+		err := exchange(ctx, conns[i], typeAggNonce, aggNonce[:], typePartialSig, sBytes[:])
+		if err != nil {
+			return err
+		}
+
+		s := new(btcec.ModNScalar)
+		partialSig := &aggsig.PartialSignature{S: s}
+		overflow := s.SetBytes(&sBytes) != 0
+		if overflow || !cache.VerifyPartialSig(partialSig, pubNonces[i], aggNonce, c.Peers[i].PublicKey, msg) {
+			return ErrBadPartial
+		}
+		partialSigs[i] = partialSig
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("signing round: %w", err)
+	}
+
+	return cache.Combine(aggNonce, msg, partialSigs)
+}
+
+// round runs step for every peer in parallel under one deadline. Peers
+// that are still outstanding at the deadline are named in the error. A
+// peer failing any other way aborts the round for everyone. If the
+// caller's ctx is canceled first, the error wraps ctx.Err() instead.
+func (c *Coordinator) round(parent context.Context, timeout time.Duration, step func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	errs := make([]error, len(c.Peers))
+	var wg sync.WaitGroup
+	for i := range c.Peers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = step(ctx, i)
+			if errs[i] != nil && !isTimeout(errs[i]) {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var missing []string
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if isTimeout(err) {
+			missing = append(missing, c.Peers[i].String())
+			continue
+		}
+		return fmt.Errorf("participant %s: %w", c.Peers[i], err)
+	}
+
+	if len(missing) > 0 {
+		if err := parent.Err(); err != nil {
+			return fmt.Errorf("%w: waiting for %s", err, strings.Join(missing, ", "))
+		}
+		return fmt.Errorf("%w: %s", ErrTimeout, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// exchange sends one frame and reads the reply into reply, with conn's
+// deadline bound to ctx.
+func exchange(ctx context.Context, conn net.Conn, sendType byte, payload []byte, replyType byte, reply []byte) error {
+	stop := bindDeadline(ctx, conn)
+	defer stop()
+
+	if err := writeFrame(conn, sendType, payload); err != nil {
+		return err
+	}
+
+	got, err := expectFrame(conn, replyType, len(reply))
+	if err != nil {
+		return err
+	}
+
+	copy(reply, got)
+	return nil
+}
+
+// bindDeadline sets conn's deadline to ctx's and cuts it short if ctx
+// is canceled early. The returned function undoes the binding.
+func bindDeadline(ctx context.Context, conn net.Conn) func() {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+
+	return func() {
+		stop()
+		conn.SetDeadline(time.Time{})
+	}
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func encodeRequest(msg []byte, keys []*btcec.PublicKey) []byte {
+	var buf bytes.Buffer
+	buf.Write(msg)
+	for _, key := range keys {
+		buf.Write(key.SerializeCompressed())
+	}
+	return buf.Bytes()
+}
+
+func decodeRequest(payload []byte) ([]byte, []*btcec.PublicKey, error) {
+	if len(payload) <= 32 || (len(payload)-32)%btcec.PubKeyBytesLenCompressed != 0 {
+		return nil, nil, fmt.Errorf("%w: %d bytes", ErrRequestSize, len(payload))
+	}
+
+	msg, rest := payload[:32], payload[32:]
+	keys := make([]*btcec.PublicKey, 0, len(rest)/btcec.PubKeyBytesLenCompressed)
+	for len(rest) > 0 {
+		key, err := btcec.ParsePubKey(rest[:btcec.PubKeyBytesLenCompressed])
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+		rest = rest[btcec.PubKeyBytesLenCompressed:]
+	}
+
+	return msg, keys, nil
+}
@@ -0,0 +1,277 @@
+package aggnet
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TimeleapLabs/go-schnorr/aggsig"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+)
+
+// approveAll is an Approve callback that signs every request.
+func approveAll([]byte, []*btcec.PublicKey) error { return nil }
+
+// listen returns a loopback listener closed when the test ends.
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	return ln
+}
+
+// startParticipant serves p on a loopback port until the test ends and
+// returns it as a Peer.
+func startParticipant(t *testing.T, p *Participant) Peer {
+	t.Helper()
+
+	ln := listen(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Serve(ctx, ln)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	return Peer{Addr: ln.Addr().String(), PublicKey: p.Key.PubKey()}
+}
+
+func newKey(t *testing.T) *btcec.PrivateKey {
+	t.Helper()
+
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// startStalling serves a participant that answers the nonce round with
+// a valid nonce, signals on gotAggNonce when the aggregated nonce
+// arrives and then never replies.
+func startStalling(t *testing.T, key *btcec.PrivateKey, gotAggNonce chan<- struct{}) Peer {
+	t.Helper()
+
+	ln := listen(t)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		if _, err := expectFrame(conn, typeRequest, -1); err != nil {
+			return
+		}
+		nonces, err := aggsig.GenerateNonces(key)
+		if err != nil {
+			return
+		}
+		if err := writeFrame(conn, typeNonce, nonces.PubNonce[:]); err != nil {
+			return
+		}
+		if _, err := expectFrame(conn, typeAggNonce, musig2.PubNonceSize); err != nil {
+			return
+		}
+		close(gotAggNonce)
+
+		// Hold the connection open until the coordinator hangs up.
+		readFrame(conn)
+	}()
+
+	return Peer{Addr: ln.Addr().String(), PublicKey: key.PubKey()}
+}
+
+func TestSignCancelledWhileWaitingForPartialSigs(t *testing.T) {
+	gotAggNonce := make(chan struct{})
+	peers := []Peer{
+		startParticipant(t, &Participant{Key: newKey(t), Approve: approveAll}),
+		startParticipant(t, &Participant{Key: newKey(t), Approve: approveAll}),
+		startStalling(t, newKey(t), gotAggNonce),
+	}
+
+	// The round deadline is far away, so only the cancellation can end
+	// the round.
+	coordinator := &Coordinator{Peers: peers, RoundTimeout: time.Minute}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-gotAggNonce
+		cancel()
+	}()
+
+	msg := sha256.Sum256([]byte("cancelled"))
+	done := make(chan error, 1)
+	go func() {
+		_, err := coordinator.Sign(ctx, msg[:])
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+		if errors.Is(err, ErrTimeout) {
+			t.Errorf("cancellation reported as a round timeout: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Sign did not return after the context was cancelled")
+	}
+}
+
+func TestSignThreeParticipants(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		approved int
+	)
+	msg := sha256.Sum256([]byte("three participants"))
+	approve := func(got []byte, keys []*btcec.PublicKey) error {
+		if !bytes.Equal(got, msg[:]) || len(keys) != 3 {
+			return errors.New("unexpected request")
+		}
+		mu.Lock()
+		approved++
+		mu.Unlock()
+		return nil
+	}
+
+	peers := make([]Peer, 3)
+	keys := make([]*btcec.PublicKey, 3)
+	for i := range peers {
+		peers[i] = startParticipant(t, &Participant{Key: newKey(t), Approve: approve})
+		keys[i] = peers[i].PublicKey
+	}
+
+	coordinator := &Coordinator{Peers: peers, RoundTimeout: 5 * time.Second}
+	sig, err := coordinator.Sign(context.Background(), msg[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aggKey, err := aggsig.AggregatePublicKeys(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sig.Verify(msg[:], aggKey) {
+		t.Error("signature does not verify against the aggregated key")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if approved != 3 {
+		t.Errorf("%d participants approved, want 3", approved)
+	}
+}
+
+func TestParticipantRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		approve func([]byte, []*btcec.PublicKey) error
+	}{
+		{"no approve callback", nil},
+		{"approve rejects", func([]byte, []*btcec.PublicKey) error { return errors.New("unknown feed") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peers := []Peer{
+				startParticipant(t, &Participant{Key: newKey(t), Approve: approveAll}),
+				startParticipant(t, &Participant{Key: newKey(t), Approve: tt.approve}),
+			}
+			coordinator := &Coordinator{Peers: peers, RoundTimeout: 5 * time.Second}
+
+			msg := sha256.Sum256([]byte("rejected"))
+			_, err := coordinator.Sign(context.Background(), msg[:])
+			if !errors.Is(err, ErrPeer) || !strings.Contains(err.Error(), ErrNotApproved.Error()) {
+				t.Errorf("got %v, want the participant's rejection", err)
+			}
+			if !strings.Contains(err.Error(), peers[1].Addr) {
+				t.Errorf("error does not name the rejecting participant: %v", err)
+			}
+		})
+	}
+}
+
+func TestSignNamesMissingParticipant(t *testing.T) {
+	// Accepts the connection but never answers.
+	silent := listen(t)
+	go func() {
+		conn, err := silent.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		readFrame(conn)
+		readFrame(conn)
+	}()
+
+	peers := []Peer{
+		startParticipant(t, &Participant{Key: newKey(t), Approve: approveAll}),
+		{Addr: silent.Addr().String(), PublicKey: newKey(t).PubKey()},
+	}
+	coordinator := &Coordinator{Peers: peers, RoundTimeout: 200 * time.Millisecond}
+
+	msg := sha256.Sum256([]byte("missing"))
+	_, err := coordinator.Sign(context.Background(), msg[:])
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("got %v, want ErrTimeout", err)
+	}
+	if !strings.Contains(err.Error(), peers[1].Addr) || strings.Contains(err.Error(), peers[0].Addr) {
+		t.Errorf("error should name only the silent participant: %v", err)
+	}
+}
+
+func TestSignRejectsDuplicatePeerKeys(t *testing.T) {
+	key := newKey(t)
+	peers := []Peer{
+		startParticipant(t, &Participant{Key: key, Approve: approveAll}),
+		startParticipant(t, &Participant{Key: newKey(t), Approve: approveAll}),
+		startParticipant(t, &Participant{Key: key, Approve: approveAll}),
+	}
+	coordinator := &Coordinator{Peers: peers, RoundTimeout: 5 * time.Second}
+
+	msg := sha256.Sum256([]byte("duplicate"))
+	if _, err := coordinator.Sign(context.Background(), msg[:]); !errors.Is(err, aggsig.ErrDuplicateKey) {
+		t.Errorf("got %v, want ErrDuplicateKey", err)
+	}
+}
+
+// A coordinator that sends a request and then stops reading must not
+// hold the participant's session open past its round timeout.
+func TestParticipantWriteDeadline(t *testing.T) {
+	key := newKey(t)
+	coordinatorConn, participantConn := net.Pipe()
+	defer coordinatorConn.Close()
+	defer participantConn.Close()
+
+	go writeFrame(coordinatorConn, typeRequest, encodeRequest(make([]byte, 32), []*btcec.PublicKey{key.PubKey()}))
+
+	p := &Participant{Key: key, Approve: approveAll, RoundTimeout: 100 * time.Millisecond}
+	done := make(chan error, 1)
+	go func() { done <- p.run(context.Background(), participantConn) }()
+
+	select {
+	case err := <-done:
+		if !isTimeout(err) {
+			t.Errorf("got %v, want a timeout writing the nonce", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("participant blocked writing its nonce")
+	}
+}
@@ -0,0 +1,81 @@
+package aggnet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Every message is one frame: a 4-byte big-endian length followed by
+// that many bytes, the first of which is the message type.
+const (
+	typeRequest    byte = iota + 1 // message (32) || signer keys (33 each)
+	typeNonce                      // public nonce (66)
+	typeAggNonce                   // aggregated nonce (66)
+	typePartialSig                 // partial signature s (32)
+	typeError                      // error text
+)
+
+// maxFrameSize bounds a single frame, enough for a request naming a few
+// thousand signers.
+const maxFrameSize = 1 << 20
+
+var ErrFrameSize = errors.New("frame is too large")
+
+func writeFrame(w io.Writer, typ byte, payload []byte) error {
+	if len(payload)+1 > maxFrameSize {
+		return ErrFrameSize
+	}
+
+	frame := make([]byte, 5, 5+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)+1))
+	frame[4] = typ
+	frame = append(frame, payload...)
+
+	_, err := w.Write(frame)
+	return err
+}
+
+func readFrame(r io.Reader) (byte, []byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if size == 0 {
+		return 0, nil, errors.New("empty frame")
+	}
+	if size > maxFrameSize {
+		return 0, nil, fmt.Errorf("%w: %d bytes", ErrFrameSize, size)
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		return 0, nil, err
+	}
+
+	return frame[0], frame[1:], nil
+}
+
+// expectFrame reads a frame of type typ with a payload of exactly size
+// bytes, or any size if size is negative. An error frame from the peer
+// is returned as an error.
+func expectFrame(r io.Reader, typ byte, size int) ([]byte, error) {
+	got, payload, err := readFrame(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case got == typeError:
+		return nil, fmt.Errorf("%w: %s", ErrPeer, payload)
+	case got != typ:
+		return nil, fmt.Errorf("unexpected frame type %d, want %d", got, typ)
+	case size >= 0 && len(payload) != size:
+		return nil, fmt.Errorf("frame type %d has %d bytes, want %d", typ, len(payload), size)
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,153 @@
+package aggnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/TimeleapLabs/go-schnorr/aggsig"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+)
+
+// Participant answers signing rounds from coordinators.
+type Participant struct {
+	Key *btcec.PrivateKey
+
+	// Approve is asked before signing a message and rejects it by
+	// returning an error. A participant without Approve rejects every
+	// request, so signing is always an explicit choice.
+	Approve func(msg []byte, keys []*btcec.PublicKey) error
+
+	// RoundTimeout bounds how long the participant waits for each
+	// message from the coordinator and for each of its replies to be
+	// written.
+	RoundTimeout time.Duration
+}
+
+// Serve answers one signing session per connection accepted on ln until
+// ctx is done, then closes ln and waits for open sessions to finish.
+func (p *Participant) Serve(ctx context.Context, ln net.Listener) error {
+	stop := context.AfterFunc(ctx, func() { ln.Close() })
+	defer stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			p.session(ctx, conn)
+		}()
+	}
+}
+
+// session runs both rounds of one signing session on conn. Failures are
+// reported to the coordinator as an error frame.
+func (p *Participant) session(ctx context.Context, conn net.Conn) {
+	err := p.run(ctx, conn)
+	if err != nil && !isTimeout(err) && !errors.Is(err, net.ErrClosed) {
+		send(ctx, conn, p.timeout(), typeError, []byte(err.Error()))
+	}
+}
+
+func (p *Participant) timeout() time.Duration {
+	if p.RoundTimeout <= 0 {
+		return DefaultRoundTimeout
+	}
+	return p.RoundTimeout
+}
+
+func (p *Participant) run(ctx context.Context, conn net.Conn) error {
+	timeout := p.timeout()
+
+	request, err := receive(ctx, conn, timeout, typeRequest, -1)
+	if err != nil {
+		return err
+	}
+
+	msg, keys, err := decodeRequest(request)
+	if err != nil {
+		return err
+	}
+
+	if !slices.ContainsFunc(keys, p.Key.PubKey().IsEqual) {
+		return musig2.ErrPubkeyNotIncluded
+	}
+
+	if p.Approve == nil {
+		return fmt.Errorf("%w: participant has no Approve callback", ErrNotApproved)
+	}
+	if err := p.Approve(msg, keys); err != nil {
+		return fmt.Errorf("%w: %w", ErrNotApproved, err)
+	}
+
+	cache, err := aggsig.NewKeyAggCache(keys)
+	if err != nil {
+		return err
+	}
+
+	nonces, err := aggsig.GenerateNonces(p.Key)
+	if err != nil {
+		return err
+	}
+	defer clear(nonces.SecNonce[:])
+
+	if err := send(ctx, conn, timeout, typeNonce, nonces.PubNonce[:]); err != nil {
+		return err
+	}
+
+	payload, err := receive(ctx, conn, timeout, typeAggNonce, musig2.PubNonceSize)
+	if err != nil {
+		return err
+	}
+
+	var aggNonce [musig2.PubNonceSize]byte
+	copy(aggNonce[:], payload)
+
+	partialSig, err := cache.PartialSign(p.Key, nonces, aggNonce, msg)
+	if err != nil {
+		return err
+	}
+
+	s := partialSig.S.Bytes()
+	return send(ctx, conn, timeout, typePartialSig, s[:])
+}
+
+// send writes a frame of type typ, giving up after timeout so a
+// coordinator that stops reading cannot hold the session open.
+func send(ctx context.Context, conn net.Conn, timeout time.Duration, typ byte, payload []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stop := bindDeadline(ctx, conn)
+	defer stop()
+
+	return writeFrame(conn, typ, payload)
+}
+
+// receive waits up to timeout for a frame of type typ with a payload of
+// size bytes, or any size if size is negative.
+func receive(ctx context.Context, conn net.Conn, timeout time.Duration, typ byte, size int) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stop := bindDeadline(ctx, conn)
+	defer stop()
+
+	return expectFrame(conn, typ, size)
+}
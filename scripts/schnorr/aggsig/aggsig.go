@@ -0,0 +1,197 @@
+// Package aggsig implements n-of-n schnorr signature aggregation on
+// top of MuSig2. A signing round has two steps: every signer shares a
+// public nonce, then every signer produces a partial signature over the
+// aggregated nonce. The partial signatures combine into a regular
+// BIP340 signature that verifies against the aggregated public key.
+package aggsig
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/TimeleapLabs/go-schnorr/internal/round"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+var (
+	ErrNoKeys        = errors.New("no public keys to aggregate")
+	ErrNoPartialSigs = errors.New("no partial signatures to combine")
+	ErrMessageSize   = errors.New("message must be a 32-byte digest")
+	ErrInvalidAggSig = errors.New("combined signature does not verify against the aggregated key")
+	ErrAggregateKeys = errors.New("could not aggregate public keys")
+	ErrDuplicateKey  = errors.New("public key appears more than once")
+)
+
+// Nonces holds the secret and public nonce of a signer for one round.
+// A Nonces value must never be used for more than one signature.
+type Nonces = musig2.Nonces
+
+// PartialSignature is a single signer's share of the final signature.
+type PartialSignature = musig2.PartialSignature
+
+// AggregatePublicKeys combines pubs into the MuSig2 aggregated key. Keys
+// are sorted first, so the order of pubs does not matter. A key listed
+// twice is rejected with ErrDuplicateKey, since it usually means a
+// signer set was assembled wrongly.
+func AggregatePublicKeys(pubs []*btcec.PublicKey) (*btcec.PublicKey, error) {
+	if err := checkDistinct(pubs); err != nil {
+		return nil, err
+	}
+	return AggregatePublicKeysWithDuplicates(pubs)
+}
+
+// AggregatePublicKeysWithDuplicates is AggregatePublicKeys for callers
+// that mean to aggregate a key with multiplicity. MuSig2 defines the
+// result, but every copy of the key must then sign.
+func AggregatePublicKeysWithDuplicates(pubs []*btcec.PublicKey) (*btcec.PublicKey, error) {
+	if len(pubs) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	aggKey, _, _, err := musig2.AggregateKeys(copyKeys(pubs), true)
+	if err != nil {
+		return nil, err
+	}
+
+	return aggKey.FinalKey, nil
+}
+
+// VerifyAggregate re-aggregates pubs and checks sig over msg against the
+// result, which catches a signature made for a different signer set.
+// It returns the aggregated key; errors wrap ErrAggregateKeys if the
+// keys could not be aggregated and ErrInvalidAggSig if sig is invalid.
+func VerifyAggregate(pubs []*btcec.PublicKey, msg []byte, sig *schnorr.Signature) (*btcec.PublicKey, error) {
+	aggKey, err := AggregatePublicKeys(pubs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAggregateKeys, err)
+	}
+
+	if len(msg) != 32 {
+		return aggKey, ErrMessageSize
+	}
+
+	if !sig.Verify(msg, aggKey) {
+		return aggKey, ErrInvalidAggSig
+	}
+
+	return aggKey, nil
+}
+
+// GenerateNonces creates fresh nonces for the first round. The public
+// part is sent to the other signers, the secret part stays local.
+func GenerateNonces(priv *btcec.PrivateKey) (*Nonces, error) {
+	return musig2.GenNonces(musig2.WithPublicKey(priv.PubKey()))
+}
+
+// AggregateNonces combines the public nonces of all signers into the
+// round's aggregated nonce.
+func AggregateNonces(pubNonces [][musig2.PubNonceSize]byte) ([musig2.PubNonceSize]byte, error) {
+	return musig2.AggregateNonces(pubNonces)
+}
+
+// PartialSign produces this signer's partial signature over msg for
+// the second round. Use KeyAggCache.PartialSign to skip key aggregation
+// when the same signer set signs many rounds.
+func PartialSign(
+	priv *btcec.PrivateKey,
+	nonces *Nonces,
+	aggNonce [musig2.PubNonceSize]byte,
+	pubs []*btcec.PublicKey,
+	msg []byte,
+) (*PartialSignature, error) {
+	cache, err := NewKeyAggCache(pubs)
+	if err != nil {
+		return nil, err
+	}
+
+	return cache.PartialSign(priv, nonces, aggNonce, msg)
+}
+
+// CombinePartialSigs combines the partial signatures of all signers into
+// a standard 64-byte schnorr signature and checks that it verifies
+// against the aggregated key.
+func CombinePartialSigs(
+	pubs []*btcec.PublicKey,
+	aggNonce [musig2.PubNonceSize]byte,
+	msg []byte,
+	partialSigs []*PartialSignature,
+) (*schnorr.Signature, error) {
+	cache, err := NewKeyAggCache(pubs)
+	if err != nil {
+		return nil, err
+	}
+
+	return cache.Combine(aggNonce, msg, partialSigs)
+}
+
+// checkDistinct returns ErrDuplicateKey if any key appears twice in
+// pubs.
+func checkDistinct(pubs []*btcec.PublicKey) error {
+	seen := make(map[[btcec.PubKeyBytesLenCompressed]byte]int, len(pubs))
+	for i, pub := range pubs {
+		var id [btcec.PubKeyBytesLenCompressed]byte
+		copy(id[:], pub.SerializeCompressed())
+		if j, ok := seen[id]; ok {
+			return fmt.Errorf("%w: keys %d and %d", ErrDuplicateKey, j, i)
+		}
+		seen[id] = i
+	}
+	return nil
+}
+
+// copyKeys returns a copy of pubs. musig2 sorts key slices in place,
+// which would otherwise reorder the caller's keys.
+func copyKeys(pubs []*btcec.PublicKey) []*btcec.PublicKey {
+	return append([]*btcec.PublicKey(nil), pubs...)
+}
+
+// signingNonce derives the final nonce R = R1 + b*R2 from the aggregated
+// nonce, as described in the MuSig2 spec, and returns it with b. Partial
+// signatures decoded from the wire only carry s, so the combiner has to
+// recompute R itself.
+func signingNonce(aggNonce [musig2.PubNonceSize]byte, aggKey *btcec.PublicKey, msg []byte) (*btcec.PublicKey, *btcec.ModNScalar, error) {
+	var buf bytes.Buffer
+	buf.Write(aggNonce[:])
+	buf.Write(schnorr.SerializePubKey(aggKey))
+	buf.Write(msg)
+
+	var b btcec.ModNScalar
+	b.SetByteSlice(chainhash.TaggedHash(musig2.NonceBlindTag, buf.Bytes())[:])
+
+	r1, err := btcec.ParseJacobian(aggNonce[:btcec.PubKeyBytesLenCompressed])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid aggregated nonce: %w", err)
+	}
+	r2, err := btcec.ParseJacobian(aggNonce[btcec.PubKeyBytesLenCompressed:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid aggregated nonce: %w", err)
+	}
+
+	var nonce btcec.JacobianPoint
+	btcec.ScalarMultNonConst(&b, &r2, &r2)
+	btcec.AddNonConst(&r1, &r2, &nonce)
+
+	if nonce == (btcec.JacobianPoint{}) {
+		return btcec.Generator(), &b, nil
+	}
+
+	nonce.ToAffine()
+	return btcec.NewPublicKey(&nonce.X, &nonce.Y), &b, nil
+}
+
+// CollectNonces waits for n public nonces on ch, giving up when ctx is
+// done.
+func CollectNonces(ctx context.Context, n int, ch <-chan [musig2.PubNonceSize]byte) ([][musig2.PubNonceSize]byte, error) {
+	return round.Collect(ctx, n, ch)
+}
+
+// CollectPartialSigs waits for n partial signatures on ch, giving up
+// when ctx is done.
+func CollectPartialSigs(ctx context.Context, n int, ch <-chan *PartialSignature) ([]*PartialSignature, error) {
+	return round.Collect(ctx, n, ch)
+}
@@ -0,0 +1,184 @@
+package aggsig
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+)
+
+// newSigners returns n fresh private keys and their public keys.
+func newSigners(t testing.TB, n int) ([]*btcec.PrivateKey, []*btcec.PublicKey) {
+	t.Helper()
+
+	privs := make([]*btcec.PrivateKey, n)
+	pubs := make([]*btcec.PublicKey, n)
+	for i := range privs {
+		priv, err := btcec.NewPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs[i] = priv
+		pubs[i] = priv.PubKey()
+	}
+	return privs, pubs
+}
+
+// signRound runs both MuSig2 rounds for privs over msg and returns the
+// combined signature.
+func signRound(t testing.TB, privs []*btcec.PrivateKey, pubs []*btcec.PublicKey, msg []byte) *schnorr.Signature {
+	t.Helper()
+
+	nonces := make([]*Nonces, len(privs))
+	pubNonces := make([][musig2.PubNonceSize]byte, len(privs))
+	for i, priv := range privs {
+		n, err := GenerateNonces(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonces[i] = n
+		pubNonces[i] = n.PubNonce
+	}
+
+	aggNonce, err := AggregateNonces(pubNonces)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partials := make([]*PartialSignature, len(privs))
+	for i, priv := range privs {
+		partials[i], err = PartialSign(priv, nonces[i], aggNonce, pubs, msg)
+		if err != nil {
+			t.Fatalf("signer %d: %v", i, err)
+		}
+	}
+
+	sig, err := CombinePartialSigs(pubs, aggNonce, msg, partials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func TestThreeSignerRoundTrip(t *testing.T) {
+	privs, pubs := newSigners(t, 3)
+	msg := sha256.Sum256([]byte("three signers"))
+
+	sig := signRound(t, privs, pubs, msg[:])
+
+	aggKey, err := AggregatePublicKeys(pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sig.Verify(msg[:], aggKey) {
+		t.Fatal("combined signature does not verify against the aggregated key")
+	}
+
+	// The order the keys are listed in does not change the aggregate.
+	reordered, err := AggregatePublicKeys([]*btcec.PublicKey{pubs[2], pubs[0], pubs[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !aggKey.IsEqual(reordered) {
+		t.Error("aggregated key depends on key order")
+	}
+
+	if _, err := VerifyAggregate(pubs, msg[:], sig); err != nil {
+		t.Errorf("VerifyAggregate: %v", err)
+	}
+
+	// A signature for another signer set must not verify.
+	_, others := newSigners(t, 3)
+	if _, err := VerifyAggregate(others, msg[:], sig); err == nil {
+		t.Error("signature verified against another signer set")
+	}
+}
+
+func TestCombineRejectsMissingSigner(t *testing.T) {
+	privs, pubs := newSigners(t, 3)
+	msg := sha256.Sum256([]byte("missing signer"))
+
+	nonces := make([]*Nonces, len(privs))
+	pubNonces := make([][musig2.PubNonceSize]byte, len(privs))
+	for i, priv := range privs {
+		n, err := GenerateNonces(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonces[i] = n
+		pubNonces[i] = n.PubNonce
+	}
+	aggNonce, err := AggregateNonces(pubNonces)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var partials []*PartialSignature
+	for i, priv := range privs[:2] {
+		partial, err := PartialSign(priv, nonces[i], aggNonce, pubs, msg[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		partials = append(partials, partial)
+	}
+
+	if _, err := CombinePartialSigs(pubs, aggNonce, msg[:], partials); err == nil {
+		t.Error("combined a signature with one signer missing")
+	}
+}
+
+func TestDuplicateKeysRejected(t *testing.T) {
+	privs, pubs := newSigners(t, 2)
+	keys := []*btcec.PublicKey{pubs[0], pubs[1], pubs[0]}
+	signers := []*btcec.PrivateKey{privs[0], privs[1], privs[0]}
+	msg := sha256.Sum256([]byte("duplicate key"))
+	nonces, aggNonce := roundNonces(t, signers)
+
+	if _, err := AggregatePublicKeys(keys); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("AggregatePublicKeys: got %v, want ErrDuplicateKey", err)
+	}
+	if _, err := NewKeyAggCache(keys); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("NewKeyAggCache: got %v, want ErrDuplicateKey", err)
+	}
+	if _, err := PartialSign(privs[0], nonces[0], aggNonce, keys, msg[:]); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("PartialSign: got %v, want ErrDuplicateKey", err)
+	}
+	if _, err := CombinePartialSigs(keys, aggNonce, msg[:], nil); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("CombinePartialSigs: got %v, want ErrDuplicateKey", err)
+	}
+	if _, err := VerifyAggregate(keys, msg[:], nil); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("VerifyAggregate: got %v, want ErrDuplicateKey", err)
+	}
+
+	// The explicit APIs aggregate the key with multiplicity, and every
+	// copy signs.
+	aggKey, err := AggregatePublicKeysWithDuplicates(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := NewKeyAggCacheWithDuplicates(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cache.PublicKey().IsEqual(aggKey) {
+		t.Fatal("NewKeyAggCacheWithDuplicates and AggregatePublicKeysWithDuplicates disagree")
+	}
+
+	partials := make([]*PartialSignature, len(signers))
+	for i, priv := range signers {
+		partials[i], err = cache.PartialSign(priv, nonces[i], aggNonce, msg[:])
+		if err != nil {
+			t.Fatalf("signer %d: %v", i, err)
+		}
+	}
+	sig, err := cache.Combine(aggNonce, msg[:], partials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sig.Verify(msg[:], aggKey) {
+		t.Error("signature does not verify against the aggregate with multiplicity")
+	}
+}
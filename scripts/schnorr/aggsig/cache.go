@@ -0,0 +1,286 @@
+package aggsig
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// KeyAggCache holds the key aggregation of a fixed signer set: the
+// sorted keys, every key's aggregation coefficient and the aggregated
+// key. In a stable validator set these are the same every round, so a
+// cache built once can sign and combine any number of rounds.
+type KeyAggCache struct {
+	keys   []*btcec.PublicKey
+	coeffs map[[btcec.PubKeyBytesLenCompressed]byte]*btcec.ModNScalar
+	aggKey *btcec.PublicKey
+	oddY   bool
+}
+
+// NewKeyAggCache aggregates pubs. Keys are sorted first, so the order of
+// pubs does not matter. Like AggregatePublicKeys it rejects a key
+// listed twice with ErrDuplicateKey.
+func NewKeyAggCache(pubs []*btcec.PublicKey) (*KeyAggCache, error) {
+	if err := checkDistinct(pubs); err != nil {
+		return nil, err
+	}
+	return NewKeyAggCacheWithDuplicates(pubs)
+}
+
+// NewKeyAggCacheWithDuplicates is NewKeyAggCache for callers that mean
+// to aggregate a key with multiplicity, as
+// AggregatePublicKeysWithDuplicates does.
+func NewKeyAggCacheWithDuplicates(pubs []*btcec.PublicKey) (*KeyAggCache, error) {
+	if len(pubs) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	keys := copyKeys(pubs)
+	serialized := make([][]byte, len(keys))
+	for i, key := range keys {
+		serialized[i] = key.SerializeCompressed()
+	}
+	sort.Sort(sortedKeys{keys, serialized})
+
+	keysHash := chainhash.TaggedHash(musig2.KeyAggTagList, bytes.Join(serialized, nil))
+
+	secondKey := -1
+	for i := range serialized {
+		if !bytes.Equal(serialized[i], serialized[0]) {
+			secondKey = i
+			break
+		}
+	}
+
+	cache := &KeyAggCache{
+		keys:   keys,
+		coeffs: make(map[[btcec.PubKeyBytesLenCompressed]byte]*btcec.ModNScalar, len(keys)),
+	}
+
+	for i := range keys {
+		var id [btcec.PubKeyBytesLenCompressed]byte
+		copy(id[:], serialized[i])
+
+		coeff := new(btcec.ModNScalar)
+		if secondKey != -1 && bytes.Equal(serialized[i], serialized[secondKey]) {
+			coeff.SetInt(1)
+		} else {
+			hash := chainhash.TaggedHash(musig2.KeyAggTagCoeff, keysHash[:], serialized[i])
+			coeff.SetByteSlice(hash[:])
+		}
+		cache.coeffs[id] = coeff
+	}
+
+	aggKey, _, _, err := musig2.AggregateKeys(
+		keys, false, musig2.WithKeysHash(keysHash[:]), musig2.WithUniqueKeyIndex(secondKey),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.aggKey = aggKey.FinalKey
+	cache.oddY = aggKey.FinalKey.SerializeCompressed()[0] == 0x03
+
+	return cache, nil
+}
+
+// PublicKey returns the aggregated public key.
+func (c *KeyAggCache) PublicKey() *btcec.PublicKey {
+	return c.aggKey
+}
+
+// Keys returns a copy of the sorted signer keys.
+func (c *KeyAggCache) Keys() []*btcec.PublicKey {
+	return copyKeys(c.keys)
+}
+
+// PartialSign is PartialSign for the cached signer set.
+func (c *KeyAggCache) PartialSign(
+	priv *btcec.PrivateKey,
+	nonces *Nonces,
+	aggNonce [musig2.PubNonceSize]byte,
+	msg []byte,
+) (*PartialSignature, error) {
+	if len(msg) != 32 {
+		return nil, ErrMessageSize
+	}
+
+	pub := priv.PubKey()
+	if !bytes.Equal(nonces.SecNonce[2*btcec.PrivKeyBytesLen:], pub.SerializeCompressed()) {
+		return nil, musig2.ErrSecNoncePubkey
+	}
+
+	coeff := c.coefficient(pub)
+	if coeff == nil {
+		return nil, musig2.ErrPubkeyNotIncluded
+	}
+
+	nonce, b, err := signingNonce(aggNonce, c.aggKey, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var k1, k2 btcec.ModNScalar
+	defer k1.Zero()
+	defer k2.Zero()
+	k1.SetByteSlice(nonces.SecNonce[:btcec.PrivKeyBytesLen])
+	k2.SetByteSlice(nonces.SecNonce[btcec.PrivKeyBytesLen : 2*btcec.PrivKeyBytesLen])
+	if k1.IsZero() || k2.IsZero() {
+		return nil, musig2.ErrSecretNonceZero
+	}
+	if nonce.SerializeCompressed()[0] == 0x03 {
+		k1.Negate()
+		k2.Negate()
+	}
+
+	d := priv.Key
+	if d.IsZero() {
+		return nil, musig2.ErrPrivKeyZero
+	}
+	if c.oddY {
+		d.Negate()
+	}
+
+	// s = k1 + b*k2 + e*a*d
+	e := c.challenge(nonce, msg)
+	s := new(btcec.ModNScalar)
+	s.Add(&k1).Add(k2.Mul(b)).Add(e.Mul(coeff).Mul(&d))
+	d.Zero()
+
+	signature := musig2.NewPartialSignature(s, nonce)
+	if !c.VerifyPartialSig(&signature, nonces.PubNonce, aggNonce, pub, msg) {
+		return nil, musig2.ErrPartialSigInvalid
+	}
+
+	return &signature, nil
+}
+
+// VerifyPartialSig reports whether partialSig is the partial signature
+// of pub, which used pubNonce, over msg.
+func (c *KeyAggCache) VerifyPartialSig(
+	partialSig *PartialSignature,
+	pubNonce, aggNonce [musig2.PubNonceSize]byte,
+	pub *btcec.PublicKey,
+	msg []byte,
+) bool {
+	coeff := c.coefficient(pub)
+	if coeff == nil || len(msg) != 32 {
+		return false
+	}
+
+	nonce, b, err := signingNonce(aggNonce, c.aggKey, msg)
+	if err != nil {
+		return false
+	}
+
+	r1, err := btcec.ParseJacobian(pubNonce[:btcec.PubKeyBytesLenCompressed])
+	if err != nil {
+		return false
+	}
+	r2, err := btcec.ParseJacobian(pubNonce[btcec.PubKeyBytesLenCompressed:])
+	if err != nil {
+		return false
+	}
+
+	// R' = R1 + b*R2, negated along with R
+	var signerNonce btcec.JacobianPoint
+	btcec.ScalarMultNonConst(b, &r2, &r2)
+	btcec.AddNonConst(&r1, &r2, &signerNonce)
+	signerNonce.ToAffine()
+	if nonce.SerializeCompressed()[0] == 0x03 {
+		signerNonce.Y.Negate(1)
+		signerNonce.Y.Normalize()
+	}
+
+	// s*G == R' + e*a*g*P
+	factor := c.challenge(nonce, msg).Mul(coeff)
+	if c.oddY {
+		factor.Negate()
+	}
+
+	var point, sG, rhs btcec.JacobianPoint
+	pub.AsJacobian(&point)
+	btcec.ScalarMultNonConst(factor, &point, &rhs)
+	btcec.AddNonConst(&rhs, &signerNonce, &rhs)
+	btcec.ScalarBaseMultNonConst(partialSig.S, &sG)
+
+	sG.ToAffine()
+	rhs.ToAffine()
+	return sG == rhs
+}
+
+// Combine is CombinePartialSigs for the cached signer set.
+func (c *KeyAggCache) Combine(
+	aggNonce [musig2.PubNonceSize]byte,
+	msg []byte,
+	partialSigs []*PartialSignature,
+) (*schnorr.Signature, error) {
+	if len(partialSigs) == 0 {
+		return nil, ErrNoPartialSigs
+	}
+
+	if len(msg) != 32 {
+		return nil, ErrMessageSize
+	}
+
+	nonce, _, err := signingNonce(aggNonce, c.aggKey, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	signature := musig2.CombineSigs(nonce, partialSigs)
+	if !signature.Verify(msg, c.aggKey) {
+		return nil, ErrInvalidAggSig
+	}
+
+	return signature, nil
+}
+
+// coefficient returns the aggregation coefficient of pub, or nil if pub
+// is not in the signer set.
+func (c *KeyAggCache) coefficient(pub *btcec.PublicKey) *btcec.ModNScalar {
+	var id [btcec.PubKeyBytesLenCompressed]byte
+	copy(id[:], pub.SerializeCompressed())
+
+	coeff, ok := c.coeffs[id]
+	if !ok {
+		return nil
+	}
+
+	var copied btcec.ModNScalar
+	copied.Set(coeff)
+	return &copied
+}
+
+// challenge returns e = H(tag=BIP0340/challenge, R || Q || m).
+func (c *KeyAggCache) challenge(nonce *btcec.PublicKey, msg []byte) *btcec.ModNScalar {
+	hash := chainhash.TaggedHash(
+		musig2.ChallengeHashTag, schnorr.SerializePubKey(nonce), schnorr.SerializePubKey(c.aggKey), msg,
+	)
+
+	var e btcec.ModNScalar
+	e.SetByteSlice(hash[:])
+	return &e
+}
+
+// sortedKeys sorts keys by their compressed encoding, the order MuSig2
+// key aggregation expects.
+type sortedKeys struct {
+	keys       []*btcec.PublicKey
+	serialized [][]byte
+}
+
+func (s sortedKeys) Len() int { return len(s.keys) }
+
+func (s sortedKeys) Less(i, j int) bool {
+	return bytes.Compare(s.serialized[i], s.serialized[j]) < 0
+}
+
+func (s sortedKeys) Swap(i, j int) {
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	s.serialized[i], s.serialized[j] = s.serialized[j], s.serialized[i]
+}
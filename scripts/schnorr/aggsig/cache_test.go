@@ -0,0 +1,236 @@
+package aggsig
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+)
+
+func TestKeyAggCacheMatchesUncached(t *testing.T) {
+	privs, pubs := newSigners(t, 5)
+	msg := sha256.Sum256([]byte("cached"))
+
+	cache, err := NewKeyAggCache(pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aggKey, err := AggregatePublicKeys(pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cache.PublicKey().IsEqual(aggKey) {
+		t.Fatal("cached aggregated key differs from AggregatePublicKeys")
+	}
+
+	// PartialSign and CombinePartialSigs wrap the cache, so mix its
+	// partial signatures with upstream musig2 ones, which aggregate the
+	// keys on every call.
+	nonces, aggNonce := roundNonces(t, privs)
+	partials := make([]*PartialSignature, len(privs))
+	for i, priv := range privs {
+		if i%2 == 0 {
+			partials[i], err = cache.PartialSign(priv, nonces[i], aggNonce, msg[:])
+		} else {
+			partials[i], err = musig2.Sign(nonces[i].SecNonce, priv, aggNonce, pubs, msg, musig2.WithSortedKeys())
+		}
+		if err != nil {
+			t.Fatalf("signer %d: %v", i, err)
+		}
+	}
+
+	cached, err := cache.Combine(aggNonce, msg[:], partials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uncached := musig2.CombineSigs(partials[0].R, partials)
+	if !cached.IsEqual(uncached) {
+		t.Error("cached and upstream combine disagree")
+	}
+	if !cached.Verify(msg[:], aggKey) {
+		t.Error("signature does not verify against the aggregated key")
+	}
+}
+
+// TestKeyAggCacheMatchesMusig2 checks the cache against the upstream
+// musig2 implementation for both parities of the aggregated key and of
+// the round's final nonce, the two places the cache negates scalars.
+func TestKeyAggCacheMatchesMusig2(t *testing.T) {
+	msg := sha256.Sum256([]byte("upstream"))
+
+	for _, oddY := range []bool{false, true} {
+		for _, oddR := range []bool{false, true} {
+			t.Run(fmt.Sprintf("oddY=%v/oddR=%v", oddY, oddR), func(t *testing.T) {
+				privs, pubs, cache := signerSetWithParity(t, 3, oddY)
+				nonces, aggNonce := roundWithParity(t, privs, cache, msg[:], oddR)
+
+				for i, priv := range privs {
+					want, err := musig2.Sign(nonces[i].SecNonce, priv, aggNonce, pubs, msg, musig2.WithSortedKeys())
+					if err != nil {
+						t.Fatalf("signer %d: upstream: %v", i, err)
+					}
+					got, err := cache.PartialSign(priv, nonces[i], aggNonce, msg[:])
+					if err != nil {
+						t.Fatalf("signer %d: %v", i, err)
+					}
+					if got.S.Bytes() != want.S.Bytes() {
+						t.Errorf("signer %d: s is %x, upstream %x", i, got.S.Bytes(), want.S.Bytes())
+					}
+					if !got.R.IsEqual(want.R) {
+						t.Errorf("signer %d: final nonce differs from upstream", i)
+					}
+
+					if !cache.VerifyPartialSig(want, nonces[i].PubNonce, aggNonce, priv.PubKey(), msg[:]) {
+						t.Errorf("signer %d: upstream partial signature rejected", i)
+					}
+					if !got.Verify(nonces[i].PubNonce, aggNonce, pubs, priv.PubKey(), msg, musig2.WithSortedKeys()) {
+						t.Errorf("signer %d: upstream rejects the cached partial signature", i)
+					}
+
+					var one btcec.ModNScalar
+					one.SetInt(1)
+					tampered := musig2.NewPartialSignature(new(btcec.ModNScalar).Add2(want.S, &one), want.R)
+					if cache.VerifyPartialSig(&tampered, nonces[i].PubNonce, aggNonce, priv.PubKey(), msg[:]) {
+						t.Errorf("signer %d: tampered s accepted", i)
+					}
+					if tampered.Verify(nonces[i].PubNonce, aggNonce, pubs, priv.PubKey(), msg, musig2.WithSortedKeys()) {
+						t.Errorf("signer %d: upstream accepts tampered s", i)
+					}
+				}
+			})
+		}
+	}
+}
+
+// signerSetWithParity returns n fresh signers whose aggregated key has
+// an odd Y coordinate if oddY is set and an even one otherwise.
+func signerSetWithParity(t *testing.T, n int, oddY bool) ([]*btcec.PrivateKey, []*btcec.PublicKey, *KeyAggCache) {
+	t.Helper()
+
+	for attempt := 0; attempt < 128; attempt++ {
+		privs, pubs := newSigners(t, n)
+		cache, err := NewKeyAggCache(pubs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cache.oddY == oddY {
+			return privs, pubs, cache
+		}
+	}
+	t.Fatalf("no signer set with oddY=%v", oddY)
+	return nil, nil, nil
+}
+
+// roundWithParity returns nonces for privs whose final nonce over msg
+// has an odd Y coordinate if oddR is set and an even one otherwise.
+func roundWithParity(t *testing.T, privs []*btcec.PrivateKey, cache *KeyAggCache, msg []byte, oddR bool) ([]*Nonces, [musig2.PubNonceSize]byte) {
+	t.Helper()
+
+	for attempt := 0; attempt < 128; attempt++ {
+		nonces, aggNonce := roundNonces(t, privs)
+		nonce, _, err := signingNonce(aggNonce, cache.PublicKey(), msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (nonce.SerializeCompressed()[0] == 0x03) == oddR {
+			return nonces, aggNonce
+		}
+	}
+	t.Fatalf("no round with oddR=%v", oddR)
+	return nil, [musig2.PubNonceSize]byte{}
+}
+
+// roundNonces generates fresh nonces for privs and aggregates them.
+func roundNonces(t testing.TB, privs []*btcec.PrivateKey) ([]*Nonces, [musig2.PubNonceSize]byte) {
+	t.Helper()
+
+	nonces := make([]*Nonces, len(privs))
+	pubNonces := make([][musig2.PubNonceSize]byte, len(privs))
+	for i, priv := range privs {
+		n, err := GenerateNonces(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonces[i] = n
+		pubNonces[i] = n.PubNonce
+	}
+
+	aggNonce, err := AggregateNonces(pubNonces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nonces, aggNonce
+}
+
+// The signer set is the size of a validator set, signing 100 rounds per
+// iteration. Nonce generation is left out of the timing since it costs
+// the same with and without the cache.
+const (
+	benchSigners = 21
+	benchRounds  = 100
+)
+
+func BenchmarkRoundsUncached(b *testing.B) {
+	privs, pubs := newSigners(b, benchSigners)
+	benchmarkRounds(b, privs, func(i int, nonces *Nonces, aggNonce [musig2.PubNonceSize]byte, msg []byte) (*PartialSignature, error) {
+		return PartialSign(privs[i], nonces, aggNonce, pubs, msg)
+	}, func(aggNonce [musig2.PubNonceSize]byte, msg []byte, partials []*PartialSignature) error {
+		_, err := CombinePartialSigs(pubs, aggNonce, msg, partials)
+		return err
+	})
+}
+
+func BenchmarkRoundsCached(b *testing.B) {
+	privs, pubs := newSigners(b, benchSigners)
+	cache, err := NewKeyAggCache(pubs)
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkRounds(b, privs, func(i int, nonces *Nonces, aggNonce [musig2.PubNonceSize]byte, msg []byte) (*PartialSignature, error) {
+		return cache.PartialSign(privs[i], nonces, aggNonce, msg)
+	}, func(aggNonce [musig2.PubNonceSize]byte, msg []byte, partials []*PartialSignature) error {
+		_, err := cache.Combine(aggNonce, msg, partials)
+		return err
+	})
+}
+
+func benchmarkRounds(
+	b *testing.B,
+	privs []*btcec.PrivateKey,
+	partialSign func(i int, nonces *Nonces, aggNonce [musig2.PubNonceSize]byte, msg []byte) (*PartialSignature, error),
+	combine func(aggNonce [musig2.PubNonceSize]byte, msg []byte, partials []*PartialSignature) error,
+) {
+	type round struct {
+		nonces   []*Nonces
+		aggNonce [musig2.PubNonceSize]byte
+		msg      [32]byte
+	}
+
+	rounds := make([]round, benchRounds)
+	partials := make([]*PartialSignature, len(privs))
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		for r := range rounds {
+			rounds[r].nonces, rounds[r].aggNonce = roundNonces(b, privs)
+			rounds[r].msg = sha256.Sum256([]byte{byte(r), byte(n)})
+		}
+		b.StartTimer()
+
+		for _, r := range rounds {
+			for i := range privs {
+				partial, err := partialSign(i, r.nonces[i], r.aggNonce, r.msg[:])
+				if err != nil {
+					b.Fatal(err)
+				}
+				partials[i] = partial
+			}
+			if err := combine(r.aggNonce, r.msg[:], partials); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package aggsig
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+)
+
+var (
+	ErrMessageMismatch  = errors.New("partial signatures cover different messages")
+	ErrAggNonceMismatch = errors.New("partial signatures use different aggregate nonces")
+)
+
+// PartialSigRecord is what a signer publishes after round two so that a
+// coordinator can combine the signatures offline. All byte fields are
+// 0x-prefixed hex; publicKey is the 33-byte compressed key.
+type PartialSigRecord struct {
+	PublicKey  string `json:"publicKey"`
+	PubNonce   string `json:"pubNonce"`
+	AggNonce   string `json:"aggNonce"`
+	Message    string `json:"message"`
+	PartialSig string `json:"partialSig"`
+}
+
+// CombineFile is the input of the combine step, one record per signer.
+type CombineFile struct {
+	Signers []PartialSigRecord `json:"signers"`
+}
+
+// ParseCombineFile decodes a JSON combine file.
+func ParseCombineFile(data []byte) (*CombineFile, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var file CombineFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, err
+	}
+
+	return &file, nil
+}
+
+// Combine checks that every record covers the same message and aggregate
+// nonce, that the aggregate nonce really is the sum of the signers'
+// public nonces and that every partial signature is valid, then returns
+// the combined signature along with the aggregated key.
+func (f *CombineFile) Combine() (*schnorr.Signature, *btcec.PublicKey, error) {
+	if len(f.Signers) == 0 {
+		return nil, nil, ErrNoPartialSigs
+	}
+
+	var (
+		msg         []byte
+		aggNonce    [musig2.PubNonceSize]byte
+		pubs        = make([]*btcec.PublicKey, len(f.Signers))
+		pubNonces   = make([][musig2.PubNonceSize]byte, len(f.Signers))
+		partialSigs = make([]*PartialSignature, len(f.Signers))
+	)
+
+	for i, record := range f.Signers {
+		recordMsg, err := decodeFixed(record.Message, 32)
+		if err != nil {
+			return nil, nil, fmt.Errorf("signer %d: message: %w", i, err)
+		}
+		recordAggNonce, err := decodeFixed(record.AggNonce, musig2.PubNonceSize)
+		if err != nil {
+			return nil, nil, fmt.Errorf("signer %d: aggNonce: %w", i, err)
+		}
+
+		if i == 0 {
+			msg = recordMsg
+			copy(aggNonce[:], recordAggNonce)
+		} else if !bytes.Equal(msg, recordMsg) {
+			return nil, nil, fmt.Errorf("signer %d: %w", i, ErrMessageMismatch)
+		} else if !bytes.Equal(aggNonce[:], recordAggNonce) {
+			return nil, nil, fmt.Errorf("signer %d: %w", i, ErrAggNonceMismatch)
+		}
+
+		pubKeyBytes, err := decodeFixed(record.PublicKey, btcec.PubKeyBytesLenCompressed)
+		if err != nil {
+			return nil, nil, fmt.Errorf("signer %d: publicKey: %w", i, err)
+		}
+		pubs[i], err = btcec.ParsePubKey(pubKeyBytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("signer %d: publicKey: %w", i, err)
+		}
+
+		pubNonce, err := decodeFixed(record.PubNonce, musig2.PubNonceSize)
+		if err != nil {
+			return nil, nil, fmt.Errorf("signer %d: pubNonce: %w", i, err)
+		}
+		copy(pubNonces[i][:], pubNonce)
+
+		sBytes, err := decodeFixed(record.PartialSig, 32)
+		if err != nil {
+			return nil, nil, fmt.Errorf("signer %d: partialSig: %w", i, err)
+		}
+		s := new(btcec.ModNScalar)
+		if overflow := s.SetByteSlice(sBytes); overflow {
+			return nil, nil, fmt.Errorf("signer %d: partialSig is not below the curve order", i)
+		}
+		partialSigs[i] = &PartialSignature{S: s}
+	}
+
+	expected, err := AggregateNonces(pubNonces)
+	if err != nil {
+		return nil, nil, err
+	}
+	if expected != aggNonce {
+		return nil, nil, fmt.Errorf("%w: aggNonce is not the sum of the signers' nonces", ErrAggNonceMismatch)
+	}
+
+	cache, err := NewKeyAggCache(pubs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, partialSig := range partialSigs {
+		if !cache.VerifyPartialSig(partialSig, pubNonces[i], aggNonce, pubs[i], msg) {
+			return nil, nil, fmt.Errorf("signer %d: %w", i, musig2.ErrPartialSigInvalid)
+		}
+	}
+
+	signature, err := cache.Combine(aggNonce, msg, partialSigs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return signature, cache.PublicKey(), nil
+}
+
+// NewPartialSigRecord builds the record a signer publishes for its
+// partial signature.
+func NewPartialSigRecord(
+	pub *btcec.PublicKey,
+	pubNonce, aggNonce [musig2.PubNonceSize]byte,
+	msg []byte,
+	partialSig *PartialSignature,
+) PartialSigRecord {
+	s := partialSig.S.Bytes()
+
+	return PartialSigRecord{
+		PublicKey:  fmt.Sprintf("0x%x", pub.SerializeCompressed()),
+		PubNonce:   fmt.Sprintf("0x%x", pubNonce),
+		AggNonce:   fmt.Sprintf("0x%x", aggNonce),
+		Message:    fmt.Sprintf("0x%x", msg),
+		PartialSig: fmt.Sprintf("0x%x", s[:]),
+	}
+}
+
+func decodeFixed(hexStr string, size int) ([]byte, error) {
+	decoded, err := hex.DecodeString(strings.TrimPrefix(hexStr, "0x"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(decoded) != size {
+		return nil, fmt.Errorf("must be %d bytes, got %d", size, len(decoded))
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,108 @@
+package aggsig
+
+import (
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+)
+
+// testdata/combine.json was written by three signer processes, holding
+// the keys 0x11..11, 0x22..22 and 0x33..33, that each ran its own
+// nonce generation and partial signing while a coordinator process
+// relayed the nonces. Combining is deterministic, so the result is
+// pinned.
+const (
+	fixtureAggKey    = "d7f88557dc5f43705444eac25b463e567608e9876d4d58b7fe38e69f5a06a303"
+	fixtureSignature = "c126ba24b1e4b90cd7ca8a942b6f844a86eda224a95045d7f025880a7e74f83a" +
+		"12861996a9d22d3ba134529e3298f50e85365eac2cd44409e0146655565486eb"
+)
+
+func loadCombineFixture(t *testing.T) *CombineFile {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/combine.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := ParseCombineFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestCombineFixture(t *testing.T) {
+	file := loadCombineFixture(t)
+
+	sig, aggKey, err := file.Combine()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := hex.EncodeToString(schnorr.SerializePubKey(aggKey)); got != fixtureAggKey {
+		t.Errorf("aggregated key %s, want %s", got, fixtureAggKey)
+	}
+	if got := hex.EncodeToString(sig.Serialize()); got != fixtureSignature {
+		t.Errorf("signature %s, want %s", got, fixtureSignature)
+	}
+
+	msg, err := hex.DecodeString(strings.TrimPrefix(file.Signers[0].Message, "0x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sig.Verify(msg, aggKey) {
+		t.Error("combined signature does not verify")
+	}
+}
+
+func TestCombineFixtureTampered(t *testing.T) {
+	// otherHex returns a hex string of the same length as s with its
+	// last digit changed.
+	otherHex := func(s string) string {
+		last := s[len(s)-1]
+		if last == '0' {
+			return s[:len(s)-1] + "1"
+		}
+		return s[:len(s)-1] + "0"
+	}
+
+	tests := []struct {
+		name string
+		edit func(*CombineFile)
+		want error
+	}{
+		{"message", func(f *CombineFile) { f.Signers[1].Message = otherHex(f.Signers[1].Message) }, ErrMessageMismatch},
+		{"aggregate nonce", func(f *CombineFile) { f.Signers[2].AggNonce = f.Signers[0].PubNonce }, ErrAggNonceMismatch},
+		{"missing signer", func(f *CombineFile) { f.Signers = f.Signers[:2] }, ErrAggNonceMismatch},
+		{"partial signature", func(f *CombineFile) { f.Signers[1].PartialSig = otherHex(f.Signers[1].PartialSig) }, musig2.ErrPartialSigInvalid},
+		{"swapped partial signatures", func(f *CombineFile) {
+			f.Signers[0].PartialSig, f.Signers[1].PartialSig = f.Signers[1].PartialSig, f.Signers[0].PartialSig
+		}, musig2.ErrPartialSigInvalid},
+		{"no signers", func(f *CombineFile) { f.Signers = nil }, ErrNoPartialSigs},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := loadCombineFixture(t)
+			tt.edit(file)
+
+			if _, _, err := file.Combine(); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCombineFileStrict(t *testing.T) {
+	if _, err := ParseCombineFile([]byte(`{"signers": [], "extra": 1}`)); err == nil {
+		t.Error("unknown field accepted")
+	}
+	if _, err := ParseCombineFile([]byte(`{"signers": [{"publicKey": 1}]}`)); err == nil {
+		t.Error("wrong field type accepted")
+	}
+}
@@ -0,0 +1,76 @@
+package aggsig
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+)
+
+func TestCollectPartialSigsCancelledMidRound(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Two of three signers answer, the third stalls until the round is
+	// cancelled.
+	ch := make(chan *PartialSignature)
+	go func() {
+		for i := 0; i < 2; i++ {
+			ch <- &PartialSignature{S: new(btcec.ModNScalar)}
+		}
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := CollectPartialSigs(ctx, 3, ch)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("CollectPartialSigs did not return after the context was cancelled")
+	}
+}
+
+func TestCollectNoncesDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	ch := make(chan [musig2.PubNonceSize]byte)
+	if _, err := CollectNonces(ctx, 1, ch); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestCollectNoncesAllArrive(t *testing.T) {
+	ch := make(chan [musig2.PubNonceSize]byte, 3)
+	for i := 0; i < 3; i++ {
+		ch <- [musig2.PubNonceSize]byte{byte(i)}
+	}
+
+	nonces, err := CollectNonces(context.Background(), 3, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, nonce := range nonces {
+		if nonce[0] != byte(i) {
+			t.Errorf("nonce %d is out of order", i)
+		}
+	}
+}
+
+func TestCollectClosedChannel(t *testing.T) {
+	ch := make(chan *PartialSignature)
+	close(ch)
+	if _, err := CollectPartialSigs(context.Background(), 1, ch); err == nil {
+		t.Error("no error for a closed channel")
+	}
+}
@@ -0,0 +1,95 @@
+package aggsig
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+var ErrWeights = errors.New("invalid key weights")
+
+// Weighted aggregation scales every key by its weight before MuSig2 key
+// aggregation: signer i with key P_i = x_i*G and weight w_i contributes
+// the weighted key w_i*P_i, and the aggregate is the MuSig2 aggregate of
+// the weighted keys, sum(a_i * w_i * P_i) with a_i the MuSig2 key
+// coefficient of w_i*P_i. Weights are voting power as integers in
+// [1, n), n the curve order.
+//
+// Signers take part in a normal aggsig round over WeightedKeys, each
+// signing with WeightedPrivateKey. Every signer must still take part:
+// the weights bind the aggregate key to the stake distribution, so any
+// change in voting power gives a different key, but they do not turn
+// n-of-n signing into a stake threshold.
+
+// AggregateWeightedPublicKeys returns the aggregate of keys scaled by
+// weights, as described above. Keys are sorted after weighting, so the
+// order of the pairs does not matter.
+func AggregateWeightedPublicKeys(keys []*btcec.PublicKey, weights []*big.Int) (*btcec.PublicKey, error) {
+	weighted, err := WeightedKeys(keys, weights)
+	if err != nil {
+		return nil, err
+	}
+
+	return AggregatePublicKeys(weighted)
+}
+
+// WeightedKeys returns w_i*P_i for every key and its weight. Its result
+// is the signer set to pass to NewKeyAggCache, PartialSign and
+// CombinePartialSigs for a weighted round. A key listed twice is
+// rejected with ErrDuplicateKey, whatever its weights; give it the sum
+// of them instead.
+func WeightedKeys(keys []*btcec.PublicKey, weights []*big.Int) ([]*btcec.PublicKey, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	if len(keys) != len(weights) {
+		return nil, fmt.Errorf("%w: %d keys but %d weights", ErrWeights, len(keys), len(weights))
+	}
+	if err := checkDistinct(keys); err != nil {
+		return nil, err
+	}
+
+	weighted := make([]*btcec.PublicKey, len(keys))
+	for i, key := range keys {
+		w, err := weightScalar(weights[i])
+		if err != nil {
+			return nil, fmt.Errorf("weight %d: %w", i, err)
+		}
+
+		var point, result btcec.JacobianPoint
+		key.AsJacobian(&point)
+		btcec.ScalarMultNonConst(w, &point, &result)
+		result.ToAffine()
+		weighted[i] = btcec.NewPublicKey(&result.X, &result.Y)
+	}
+
+	return weighted, nil
+}
+
+// WeightedPrivateKey returns w*x, the private key of the weighted key
+// w*P that a signer with key x and weight w signs with.
+func WeightedPrivateKey(priv *btcec.PrivateKey, weight *big.Int) (*btcec.PrivateKey, error) {
+	w, err := weightScalar(weight)
+	if err != nil {
+		return nil, err
+	}
+
+	var k btcec.ModNScalar
+	k.Mul2(&priv.Key, w)
+	return &btcec.PrivateKey{Key: k}, nil
+}
+
+func weightScalar(weight *big.Int) (*btcec.ModNScalar, error) {
+	if weight == nil || weight.Sign() <= 0 || weight.Cmp(btcec.S256().N) >= 0 {
+		return nil, fmt.Errorf("%w: weight must be in [1, n)", ErrWeights)
+	}
+
+	var buf [32]byte
+	weight.FillBytes(buf[:])
+
+	w := new(btcec.ModNScalar)
+	w.SetBytes(&buf)
+	return w, nil
+}
@@ -0,0 +1,152 @@
+package aggsig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// smallKeys returns the public keys of private keys 1 to n.
+func smallKeys(n int) []*btcec.PublicKey {
+	keys := make([]*btcec.PublicKey, n)
+	for i := range keys {
+		var k btcec.ModNScalar
+		k.SetInt(uint32(i + 1))
+		keys[i] = (&btcec.PrivateKey{Key: k}).PubKey()
+	}
+	return keys
+}
+
+func weights(ws ...int64) []*big.Int {
+	out := make([]*big.Int, len(ws))
+	for i, w := range ws {
+		out[i] = big.NewInt(w)
+	}
+	return out
+}
+
+// The aggregates were computed independently with the BIP327 KeyAgg
+// algorithm over the weighted keys w_i*P_i: 5G, 2G and 300G for the
+// weighted vector, G, 2G and 3G for unit weights.
+func TestAggregateWeightedPublicKeysKnown(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []*big.Int
+		want    string
+	}{
+		{"weights 5, 1, 100", weights(5, 1, 100), "034c3fc05f251c7d3f09e80d0ce5b77ad63f7be40579f91fe678020271949c64c7"},
+		{"unit weights", weights(1, 1, 1), "020a8111534296d6fef2b23ad86d0d982b7b2f0fe6a48f03b1827954da2026f8dc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agg, err := AggregateWeightedPublicKeys(smallKeys(3), tt.weights)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(agg.SerializeCompressed()); got != tt.want {
+				t.Errorf("aggregate is %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Unit weights are plain MuSig2 aggregation.
+	plain, err := AggregatePublicKeys(smallKeys(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unit, _ := AggregateWeightedPublicKeys(smallKeys(3), weights(1, 1, 1))
+	if !plain.IsEqual(unit) {
+		t.Error("unit weights differ from AggregatePublicKeys")
+	}
+}
+
+func TestAggregateWeightedPublicKeysOrder(t *testing.T) {
+	keys := smallKeys(3)
+	want, err := AggregateWeightedPublicKeys(keys, weights(5, 1, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := AggregateWeightedPublicKeys([]*btcec.PublicKey{keys[2], keys[0], keys[1]}, weights(100, 5, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsEqual(want) {
+		t.Error("reordering the key and weight pairs changed the aggregate")
+	}
+
+	moved, err := AggregateWeightedPublicKeys(keys, weights(100, 1, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.IsEqual(want) {
+		t.Error("moving a weight to another key kept the aggregate")
+	}
+}
+
+func TestWeightedRound(t *testing.T) {
+	privs, pubs := newSigners(t, 3)
+	ws := weights(7, 1, 30)
+
+	weightedPrivs := make([]*btcec.PrivateKey, len(privs))
+	for i, priv := range privs {
+		var err error
+		weightedPrivs[i], err = WeightedPrivateKey(priv, ws[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	weightedPubs, err := WeightedKeys(pubs, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := sha256.Sum256([]byte("weighted"))
+	sig := signRound(t, weightedPrivs, weightedPubs, msg[:])
+
+	agg, err := AggregateWeightedPublicKeys(pubs, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sig.Verify(msg[:], agg) {
+		t.Error("weighted round does not verify against the weighted aggregate")
+	}
+}
+
+func TestAggregateWeightedPublicKeysRejects(t *testing.T) {
+	keys := smallKeys(2)
+	n := btcec.S256().N
+
+	tests := []struct {
+		name    string
+		keys    []*btcec.PublicKey
+		weights []*big.Int
+		want    error
+	}{
+		{"no keys", nil, nil, ErrNoKeys},
+		{"fewer weights", keys, weights(1), ErrWeights},
+		{"zero weight", keys, weights(1, 0), ErrWeights},
+		{"negative weight", keys, weights(-1, 1), ErrWeights},
+		{"weight n", keys, []*big.Int{big.NewInt(1), new(big.Int).Set(n)}, ErrWeights},
+		{"nil weight", keys, []*big.Int{big.NewInt(1), nil}, ErrWeights},
+		{"duplicate key", []*btcec.PublicKey{keys[0], keys[0]}, weights(1, 2), ErrDuplicateKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := AggregateWeightedPublicKeys(tt.keys, tt.weights); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// n-1 is the largest weight.
+	if _, err := AggregateWeightedPublicKeys(keys, []*big.Int{big.NewInt(1), new(big.Int).Sub(n, big.NewInt(1))}); err != nil {
+		t.Errorf("weight n-1: %v", err)
+	}
+}
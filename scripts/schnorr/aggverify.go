@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/TimeleapLabs/go-schnorr/aggsig"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// aggVerify checks a combined MuSig2 signature against the key set it
+// claims to come from, by aggregating the keys again instead of trusting
+// the coordinator's aggregated key.
+func aggVerify(args []string) {
+	flags := flag.NewFlagSet("aggverify", flag.ExitOnError)
+	pubkeys := flags.String("pubkeys", "", "comma separated 0x-prefixed 33-byte compressed signer public keys")
+	pubkeysFile := flags.String("pubkeys-file", "", "read the signer public keys from a file, one per line")
+	input := addInputFlags(flags)
+	input.now = time.Now
+	signatureHex := flags.String("signature", "", "0x-prefixed 64-byte combined signature")
+	parseFlags(flags, args)
+
+	keys, err := readKeySet(*pubkeys, *pubkeysFile, parseCompressedKey)
+	if err != nil {
+		fatal(inputError("Error reading public keys: %w", err))
+	}
+
+	signature, err := signer.ParseSignature(*signatureHex)
+	if err != nil {
+		fatal(inputError("Error parsing signature: %w", err))
+	}
+
+	hash, err := input.digest()
+	if err != nil {
+		fatal(fmt.Errorf("Error reading message: %w", err))
+	}
+
+	aggKey, err := aggsig.VerifyAggregate(keys, hash, signature)
+	switch {
+	case errors.Is(err, aggsig.ErrAggregateKeys):
+		fatal(inputError("Could not aggregate public keys: %w", err))
+	case errors.Is(err, aggsig.ErrInvalidAggSig):
+		fatal(verifyError("Signature is invalid for aggregated key 0x%x", signer.XOnlyPubKey(aggKey)))
+	case err != nil:
+		fatal(fmt.Errorf("Error verifying signature: %w", err))
+	}
+
+	fmt.Printf("Aggregated public key: 0x%x\n", signer.XOnlyPubKey(aggKey))
+	infof("Signature is valid")
+}
+
+// parseCompressedKey decodes a hex encoded 33-byte compressed public
+// key. MuSig2 aggregation depends on each key's Y parity, so x-only keys
+// are not enough here.
+func parseCompressedKey(hexStr string) (*btcec.PublicKey, error) {
+	keyBytes, err := signer.DecodeHex(hexStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", signer.ErrInvalidKey, err)
+	}
+
+	if len(keyBytes) != btcec.PubKeyBytesLenCompressed {
+		return nil, fmt.Errorf("%w: public key must be %d bytes, got %d", signer.ErrInvalidKey, btcec.PubKeyBytesLenCompressed, len(keyBytes))
+	}
+
+	key, err := btcec.ParsePubKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", signer.ErrInvalidKey, err)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,367 @@
+// Package attestation defines the bundle a validator submits for a
+// signed message: the message hash, the signer's x-only public key, the
+// signature and optionally the replay-protection nonce, the signing
+// timestamp and a merkle proof, along with a fixed binary layout and a
+// JSON form.
+//
+// The binary layout, all integers big-endian:
+//
+//	offset  size  field
+//	0       1     version, currently 1
+//	1       1     flags: bit 0 nonce present, bit 1 proof present,
+//	              bit 2 timestamp present
+//	2       32    message hash
+//	34      32    x-only public key
+//	66      64    signature
+//	130     32    nonce, if flag bit 0 is set
+//	        8     timestamp in unix seconds, if flag bit 2 is set
+//	        4     proof leaf index, if flag bit 1 is set
+//	        1     proof length n, if flag bit 1 is set
+//	        32*n  proof siblings, bottom first, if flag bit 1 is set
+//
+// Nothing may follow the last field.
+package attestation
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// Version is the binary layout version written by MarshalBinary.
+const Version = 1
+
+const (
+	flagNonce = 1 << iota
+	flagProof
+	flagTimestamp
+)
+
+// headerSize is the size of the fields every attestation has.
+const headerSize = 2 + 32 + schnorr.PubKeyBytesLen + schnorr.SignatureSize
+
+// MaxProofLength bounds the number of proof siblings, which is stored in
+// a single byte.
+const MaxProofLength = 255
+
+var (
+	ErrVersion        = errors.New("unsupported attestation version")
+	ErrFlags          = errors.New("unknown attestation flags")
+	ErrLength         = errors.New("attestation has the wrong length")
+	ErrNonceRange     = errors.New("nonce must be a uint256")
+	ErrTimestampRange = errors.New("timestamp must not be before 1970")
+	ErrProofLength    = errors.New("proof is too long")
+)
+
+// Proof places the attested message as a leaf at Index in the tree
+// whose siblings are listed bottom first.
+type Proof struct {
+	Index    uint32
+	Siblings [][32]byte
+}
+
+// Attestation is a signed message hash and what is needed to verify it.
+// Nonce, Timestamp and Proof are optional. Timestamp is the time signed
+// with the message, see signer.EncodeWithTimestamp, and is kept in
+// whole seconds; a verifier needs it to re-derive the signed bytes.
+type Attestation struct {
+	MessageHash [32]byte
+	PublicKey   [schnorr.PubKeyBytesLen]byte
+	Signature   [schnorr.SignatureSize]byte
+	Nonce       *big.Int
+	Timestamp   *time.Time
+	Proof       *Proof
+}
+
+// New bundles a signature of hash by pub. The nonce, timestamp and
+// proof are left for the caller to set.
+func New(hash []byte, pub *btcec.PublicKey, sig *schnorr.Signature) (*Attestation, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("%w: message hash must be 32 bytes, got %d", ErrLength, len(hash))
+	}
+
+	a := &Attestation{}
+	copy(a.MessageHash[:], hash)
+	copy(a.PublicKey[:], signer.XOnlyPubKey(pub))
+	copy(a.Signature[:], sig.Serialize())
+	return a, nil
+}
+
+// Verify reports whether the signature is valid for the message hash
+// and public key.
+func (a *Attestation) Verify() bool {
+	pub, err := schnorr.ParsePubKey(a.PublicKey[:])
+	if err != nil {
+		return false
+	}
+
+	sig, err := schnorr.ParseSignature(a.Signature[:])
+	if err != nil {
+		return false
+	}
+
+	return sig.Verify(a.MessageHash[:], pub)
+}
+
+// Resign returns a copy of a signed by s over the same message hash,
+// with the nonce, timestamp and proof kept, as when a validator rotates
+// its key.
+// It does not check the existing signature.
+func (a *Attestation) Resign(s signer.Signer) (*Attestation, error) {
+	sig, err := s.Sign(a.MessageHash[:])
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := New(a.MessageHash[:], s.PublicKey(), sig)
+	if err != nil {
+		return nil, err
+	}
+	out.Nonce = a.Nonce
+	out.Timestamp = a.Timestamp
+	out.Proof = a.Proof
+	return out, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (a *Attestation) MarshalBinary() ([]byte, error) {
+	var flags byte
+	if a.Nonce != nil {
+		if a.Nonce.Sign() < 0 || a.Nonce.BitLen() > 256 {
+			return nil, ErrNonceRange
+		}
+		flags |= flagNonce
+	}
+	if a.Timestamp != nil {
+		if a.Timestamp.Unix() < 0 {
+			return nil, ErrTimestampRange
+		}
+		flags |= flagTimestamp
+	}
+	if a.Proof != nil {
+		if len(a.Proof.Siblings) > MaxProofLength {
+			return nil, fmt.Errorf("%w: %d siblings, at most %d", ErrProofLength, len(a.Proof.Siblings), MaxProofLength)
+		}
+		flags |= flagProof
+	}
+
+	data := make([]byte, 0, headerSize)
+	data = append(data, Version, flags)
+	data = append(data, a.MessageHash[:]...)
+	data = append(data, a.PublicKey[:]...)
+	data = append(data, a.Signature[:]...)
+
+	if a.Nonce != nil {
+		var nonce [32]byte
+		a.Nonce.FillBytes(nonce[:])
+		data = append(data, nonce[:]...)
+	}
+
+	if a.Timestamp != nil {
+		data = binary.BigEndian.AppendUint64(data, uint64(a.Timestamp.Unix()))
+	}
+
+	if a.Proof != nil {
+		data = binary.BigEndian.AppendUint32(data, a.Proof.Index)
+		data = append(data, byte(len(a.Proof.Siblings)))
+		for _, sibling := range a.Proof.Siblings {
+			data = append(data, sibling[:]...)
+		}
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (a *Attestation) UnmarshalBinary(data []byte) error {
+	if len(data) < headerSize {
+		return fmt.Errorf("%w: %d bytes is shorter than the %d byte header", ErrLength, len(data), headerSize)
+	}
+
+	if data[0] != Version {
+		return fmt.Errorf("%w: %d", ErrVersion, data[0])
+	}
+
+	flags := data[1]
+	if flags&^(flagNonce|flagProof|flagTimestamp) != 0 {
+		return fmt.Errorf("%w: 0x%02x", ErrFlags, flags)
+	}
+
+	var out Attestation
+	rest := data[2:]
+	rest = rest[copy(out.MessageHash[:], rest):]
+	rest = rest[copy(out.PublicKey[:], rest):]
+	rest = rest[copy(out.Signature[:], rest):]
+
+	if flags&flagNonce != 0 {
+		if len(rest) < 32 {
+			return fmt.Errorf("%w: nonce is truncated", ErrLength)
+		}
+		out.Nonce = new(big.Int).SetBytes(rest[:32])
+		rest = rest[32:]
+	}
+
+	if flags&flagTimestamp != 0 {
+		if len(rest) < 8 {
+			return fmt.Errorf("%w: timestamp is truncated", ErrLength)
+		}
+		seconds := binary.BigEndian.Uint64(rest)
+		if seconds > math.MaxInt64 {
+			return ErrTimestampRange
+		}
+		timestamp := time.Unix(int64(seconds), 0).UTC()
+		out.Timestamp = &timestamp
+		rest = rest[8:]
+	}
+
+	if flags&flagProof != 0 {
+		if len(rest) < 5 {
+			return fmt.Errorf("%w: proof header is truncated", ErrLength)
+		}
+		proof := &Proof{Index: binary.BigEndian.Uint32(rest)}
+		count := int(rest[4])
+		rest = rest[5:]
+
+		if len(rest) < 32*count {
+			return fmt.Errorf("%w: proof is truncated", ErrLength)
+		}
+		proof.Siblings = make([][32]byte, count)
+		for i := range proof.Siblings {
+			rest = rest[copy(proof.Siblings[i][:], rest):]
+		}
+		out.Proof = proof
+	}
+
+	if len(rest) != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrLength, len(rest))
+	}
+
+	*a = out
+	return nil
+}
+
+// attestationJSON is the JSON form of an Attestation: 0x-prefixed hex
+// for byte fields and decimal strings for the nonce and the timestamp
+// in unix seconds, as sign -output json writes them.
+type attestationJSON struct {
+	MessageHash string     `json:"messageHash"`
+	PublicKey   string     `json:"publicKey"`
+	Signature   string     `json:"signature"`
+	Nonce       string     `json:"nonce,omitempty"`
+	Timestamp   string     `json:"timestamp,omitempty"`
+	Proof       *proofJSON `json:"proof,omitempty"`
+}
+
+type proofJSON struct {
+	Index    uint32   `json:"index"`
+	Siblings []string `json:"siblings"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a *Attestation) MarshalJSON() ([]byte, error) {
+	out := attestationJSON{
+		MessageHash: fmt.Sprintf("0x%x", a.MessageHash),
+		PublicKey:   fmt.Sprintf("0x%x", a.PublicKey),
+		Signature:   fmt.Sprintf("0x%x", a.Signature),
+	}
+
+	if a.Nonce != nil {
+		if a.Nonce.Sign() < 0 || a.Nonce.BitLen() > 256 {
+			return nil, ErrNonceRange
+		}
+		out.Nonce = a.Nonce.String()
+	}
+
+	if a.Timestamp != nil {
+		if a.Timestamp.Unix() < 0 {
+			return nil, ErrTimestampRange
+		}
+		out.Timestamp = strconv.FormatInt(a.Timestamp.Unix(), 10)
+	}
+
+	if a.Proof != nil {
+		out.Proof = &proofJSON{Index: a.Proof.Index, Siblings: make([]string, len(a.Proof.Siblings))}
+		for i, sibling := range a.Proof.Siblings {
+			out.Proof.Siblings[i] = fmt.Sprintf("0x%x", sibling)
+		}
+	}
+
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *Attestation) UnmarshalJSON(data []byte) error {
+	var in attestationJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	var out Attestation
+	if err := decodeFixed(out.MessageHash[:], in.MessageHash, "messageHash"); err != nil {
+		return err
+	}
+	if err := decodeFixed(out.PublicKey[:], in.PublicKey, "publicKey"); err != nil {
+		return err
+	}
+	if err := decodeFixed(out.Signature[:], in.Signature, "signature"); err != nil {
+		return err
+	}
+
+	if in.Nonce != "" {
+		nonce, ok := new(big.Int).SetString(in.Nonce, 0)
+		if !ok {
+			return fmt.Errorf("%w: %q is not a number", signer.ErrInvalidNonce, in.Nonce)
+		}
+		if nonce.Sign() < 0 || nonce.BitLen() > 256 {
+			return ErrNonceRange
+		}
+		out.Nonce = nonce
+	}
+
+	if in.Timestamp != "" {
+		seconds, err := strconv.ParseUint(in.Timestamp, 10, 63)
+		if err != nil {
+			return fmt.Errorf("%w: %q is not unix seconds", ErrTimestampRange, in.Timestamp)
+		}
+		timestamp := time.Unix(int64(seconds), 0).UTC()
+		out.Timestamp = &timestamp
+	}
+
+	if in.Proof != nil {
+		if len(in.Proof.Siblings) > MaxProofLength {
+			return fmt.Errorf("%w: %d siblings, at most %d", ErrProofLength, len(in.Proof.Siblings), MaxProofLength)
+		}
+		out.Proof = &Proof{Index: in.Proof.Index, Siblings: make([][32]byte, len(in.Proof.Siblings))}
+		for i, sibling := range in.Proof.Siblings {
+			if err := decodeFixed(out.Proof.Siblings[i][:], sibling, fmt.Sprintf("proof sibling %d", i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	*a = out
+	return nil
+}
+
+// decodeFixed decodes the hex string s into dst, which it must fill
+// exactly.
+func decodeFixed(dst []byte, s, field string) error {
+	decoded, err := signer.DecodeHex(s)
+	if err != nil {
+		return fmt.Errorf("%s: %w", field, err)
+	}
+	if len(decoded) != len(dst) {
+		return fmt.Errorf("%w: %s must be %d bytes, got %d", ErrLength, field, len(dst), len(decoded))
+	}
+	copy(dst, decoded)
+	return nil
+}
@@ -0,0 +1,431 @@
+package attestation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// testAttestation returns a signed attestation over "attested" by the
+// key with every byte set to 0x01, with a nonce and a two-sibling proof.
+func testAttestation(t testing.TB) (*Attestation, *btcec.PrivateKey) {
+	t.Helper()
+
+	priv, err := signer.PrivateKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := signer.HashMessage([]byte("attested"))
+	sig, err := signer.SignDeterministic(priv, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := New(hash, priv.PubKey(), sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Nonce = big.NewInt(42)
+	a.Proof = &Proof{Index: 3, Siblings: [][32]byte{{0xaa}, {0xbb}}}
+	return a, priv
+}
+
+func FuzzUnmarshalBinary(f *testing.F) {
+	a, _ := testAttestation(f)
+	full, err := a.MarshalBinary()
+	if err != nil {
+		f.Fatal(err)
+	}
+	stamped := *a
+	stamped.Timestamp = testTimestamp()
+	withTimestamp, err := stamped.MarshalBinary()
+	if err != nil {
+		f.Fatal(err)
+	}
+	a.Nonce, a.Proof = nil, nil
+	bare, err := a.MarshalBinary()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(full)
+	f.Add(withTimestamp)
+	f.Add(bare)
+	f.Add(full[:len(full)-1])
+	f.Add([]byte{Version, 0xff})
+
+	// Anything that decodes must encode back to the same bytes, so no
+	// two encodings mean the same attestation.
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var decoded Attestation
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			return
+		}
+		encoded, err := decoded.MarshalBinary()
+		if err != nil {
+			t.Fatalf("decoded attestation does not encode: %v", err)
+		}
+		if !bytes.Equal(encoded, data) {
+			t.Fatalf("%x encodes back to %x", data, encoded)
+		}
+	})
+}
+
+func FuzzUnmarshalJSON(f *testing.F) {
+	a, _ := testAttestation(f)
+	full, err := json.Marshal(a)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(full)
+	f.Add([]byte(`{"nonce":"0x10"}`))
+	f.Add([]byte(`{"timestamp":"1700000000"}`))
+	f.Add([]byte(`{"proof":{"siblings":[]}}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var decoded Attestation
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return
+		}
+		want, err := decoded.MarshalBinary()
+		if err != nil {
+			t.Fatalf("decoded attestation does not encode: %v", err)
+		}
+
+		encoded, err := json.Marshal(&decoded)
+		if err != nil {
+			t.Fatalf("decoded attestation does not encode as JSON: %v", err)
+		}
+		var again Attestation
+		if err := json.Unmarshal(encoded, &again); err != nil {
+			t.Fatalf("%s does not decode: %v", encoded, err)
+		}
+		if got, _ := again.MarshalBinary(); !bytes.Equal(got, want) {
+			t.Fatalf("JSON round trip changed the attestation: %s", encoded)
+		}
+	})
+}
+
+// testTimestamp is the signing time of the tests' timestamped
+// attestations.
+func testTimestamp() *time.Time {
+	t := time.Unix(1700000000, 0).UTC()
+	return &t
+}
+
+// variants returns the test attestation with every combination of the
+// optional fields.
+func variants(t *testing.T) map[string]*Attestation {
+	t.Helper()
+
+	full, _ := testAttestation(t)
+	out := make(map[string]*Attestation)
+	for set := 0; set < 8; set++ {
+		a := *full
+		var names []string
+		if set&flagNonce != 0 {
+			names = append(names, "nonce")
+		} else {
+			a.Nonce = nil
+		}
+		if set&flagTimestamp != 0 {
+			names = append(names, "timestamp")
+			a.Timestamp = testTimestamp()
+		}
+		if set&flagProof != 0 {
+			names = append(names, "proof")
+		} else {
+			a.Proof = nil
+		}
+
+		name := strings.Join(names, " and ")
+		if name == "" {
+			name = "bare"
+		}
+		out[name] = &a
+	}
+	return out
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	for name, a := range variants(t) {
+		t.Run(name, func(t *testing.T) {
+			data, err := a.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded Attestation
+			if err := decoded.UnmarshalBinary(data); err != nil {
+				t.Fatal(err)
+			}
+			if !sameAttestation(&decoded, a) {
+				t.Errorf("decoded %+v, want %+v", decoded, *a)
+			}
+			if !decoded.Verify() {
+				t.Error("decoded attestation does not verify")
+			}
+		})
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	for name, a := range variants(t) {
+		t.Run(name, func(t *testing.T) {
+			data, err := json.Marshal(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded Attestation
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !sameAttestation(&decoded, a) {
+				t.Errorf("decoded %s as %+v", data, decoded)
+			}
+
+			if (a.Nonce == nil) == bytes.Contains(data, []byte(`"nonce"`)) {
+				t.Errorf("nonce field presence does not match: %s", data)
+			}
+			if (a.Proof == nil) == bytes.Contains(data, []byte(`"proof"`)) {
+				t.Errorf("proof field presence does not match: %s", data)
+			}
+			if (a.Timestamp == nil) == bytes.Contains(data, []byte(`"timestamp":"1700000000"`)) {
+				t.Errorf("timestamp field presence does not match: %s", data)
+			}
+		})
+	}
+}
+
+// The layout is fixed, so every field sits at its documented offset.
+func TestBinaryLayout(t *testing.T) {
+	a, _ := testAttestation(t)
+	data, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := 130 + 32 + 4 + 1 + 2*32; len(data) != want {
+		t.Fatalf("%d bytes, want %d", len(data), want)
+	}
+	if data[0] != Version || data[1] != flagNonce|flagProof {
+		t.Errorf("header is %x, want version 1 and both flags", data[:2])
+	}
+	fields := []struct {
+		name string
+		got  []byte
+		want []byte
+	}{
+		{"message hash", data[2:34], a.MessageHash[:]},
+		{"public key", data[34:66], a.PublicKey[:]},
+		{"signature", data[66:130], a.Signature[:]},
+		{"nonce", data[130:162], append(make([]byte, 31), 42)},
+		{"proof index", data[162:166], []byte{0, 0, 0, 3}},
+		{"proof length", data[166:167], []byte{2}},
+		{"first sibling", data[167:199], a.Proof.Siblings[0][:]},
+		{"second sibling", data[199:231], a.Proof.Siblings[1][:]},
+	}
+	for _, f := range fields {
+		if !bytes.Equal(f.got, f.want) {
+			t.Errorf("%s is %x, want %x", f.name, f.got, f.want)
+		}
+	}
+}
+
+// The timestamp goes between the nonce and the proof, so attestations
+// without one keep the layout above.
+func TestBinaryLayoutTimestamp(t *testing.T) {
+	a, _ := testAttestation(t)
+	a.Timestamp = testTimestamp()
+	data, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := 130 + 32 + 8 + 4 + 1 + 2*32; len(data) != want {
+		t.Fatalf("%d bytes, want %d", len(data), want)
+	}
+	if data[1] != flagNonce|flagProof|flagTimestamp {
+		t.Errorf("flags are %02x, want all three", data[1])
+	}
+	if want := []byte{0, 0, 0, 0, 0x65, 0x53, 0xf1, 0x00}; !bytes.Equal(data[162:170], want) {
+		t.Errorf("timestamp is %x, want %x", data[162:170], want)
+	}
+	if !bytes.Equal(data[170:174], []byte{0, 0, 0, 3}) || data[174] != 2 {
+		t.Errorf("proof header is %x", data[170:175])
+	}
+}
+
+func TestUnmarshalBinaryRejects(t *testing.T) {
+	a, _ := testAttestation(t)
+	data, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	edit := func(f func([]byte) []byte) []byte {
+		return f(append([]byte(nil), data...))
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"short header", data[:headerSize-1], ErrLength},
+		{"version", edit(func(d []byte) []byte { d[0] = 2; return d }), ErrVersion},
+		{"unknown flag", edit(func(d []byte) []byte { d[1] |= 0x80; return d }), ErrFlags},
+		{"truncated nonce", data[:headerSize+31], ErrLength},
+		{"truncated proof header", data[:headerSize+32+4], ErrLength},
+		{"truncated proof", data[:len(data)-1], ErrLength},
+		{"trailing byte", edit(func(d []byte) []byte { return append(d, 0) }), ErrLength},
+		{"truncated timestamp", edit(func(d []byte) []byte { d[1] |= flagTimestamp; return d[:headerSize+32+7] }), ErrLength},
+		{"timestamp past int64", edit(func(d []byte) []byte {
+			d[1] = flagTimestamp
+			return append(d[:headerSize], 0x80, 0, 0, 0, 0, 0, 0, 0)
+		}), ErrTimestampRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var decoded Attestation
+			if err := decoded.UnmarshalBinary(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNonceRange(t *testing.T) {
+	a, _ := testAttestation(t)
+	a.Nonce = new(big.Int).Lsh(big.NewInt(1), 256)
+	if _, err := a.MarshalBinary(); !errors.Is(err, ErrNonceRange) {
+		t.Errorf("MarshalBinary: got %v, want ErrNonceRange", err)
+	}
+	if _, err := json.Marshal(a); !errors.Is(err, ErrNonceRange) {
+		t.Errorf("MarshalJSON: got %v, want ErrNonceRange", err)
+	}
+
+	a.Nonce = nil
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := bytes.Replace(data, []byte(`"proof"`), []byte(`"nonce":"7","proof"`), 1)
+	var decoded Attestation
+	if err := json.Unmarshal(valid, &decoded); err != nil || decoded.Nonce.Int64() != 7 {
+		t.Fatalf("JSON nonce 7: got %v, %v", decoded.Nonce, err)
+	}
+	for _, nonce := range []string{"-1", "0x1" + strings.Repeat("0", 64), "forty-two"} {
+		withNonce := bytes.Replace(data, []byte(`"proof"`), []byte(`"nonce":"`+nonce+`","proof"`), 1)
+		var decoded Attestation
+		if err := json.Unmarshal(withNonce, &decoded); err == nil {
+			t.Errorf("JSON nonce %s decoded", nonce)
+		}
+	}
+}
+
+func sameAttestation(a, b *Attestation) bool {
+	x, errX := a.MarshalBinary()
+	y, errY := b.MarshalBinary()
+	return errX == nil && errY == nil && bytes.Equal(x, y)
+}
+
+func TestTimestampRange(t *testing.T) {
+	a, _ := testAttestation(t)
+	before := time.Unix(-1, 0)
+	a.Timestamp = &before
+	if _, err := a.MarshalBinary(); !errors.Is(err, ErrTimestampRange) {
+		t.Errorf("MarshalBinary: got %v, want ErrTimestampRange", err)
+	}
+	if _, err := json.Marshal(a); !errors.Is(err, ErrTimestampRange) {
+		t.Errorf("MarshalJSON: got %v, want ErrTimestampRange", err)
+	}
+
+	a.Timestamp = nil
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, timestamp := range []string{"-1", "+1", "1.5", "2023-11-14T22:13:20Z", "9223372036854775808"} {
+		withTimestamp := bytes.Replace(data, []byte(`"proof"`), []byte(`"timestamp":"`+timestamp+`","proof"`), 1)
+		var decoded Attestation
+		if err := json.Unmarshal(withTimestamp, &decoded); !errors.Is(err, ErrTimestampRange) {
+			t.Errorf("JSON timestamp %s: got %v, want ErrTimestampRange", timestamp, err)
+		}
+	}
+}
+
+// The timestamp is signed with the message, so the attestation only
+// verifies over the hash re-derived with the timestamp it carries.
+func TestTimestampIsSigned(t *testing.T) {
+	priv, err := signer.PrivateKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("attested")
+
+	// hashAt is the digest of message signed at unix time seconds.
+	hashAt := func(seconds int64) []byte {
+		encoded, err := signer.EncodeWithTimestamp(time.Unix(seconds, 0), message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signer.HashMessage(encoded)
+	}
+
+	attest := func(seconds int64) *Attestation {
+		sig, err := signer.SignDeterministic(priv, hashAt(seconds))
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := New(hashAt(seconds), priv.PubKey(), sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		timestamp := time.Unix(seconds, 0).UTC()
+		a.Timestamp = &timestamp
+		return a
+	}
+
+	first, second := attest(1700000000), attest(1700000001)
+	if first.Signature == second.Signature {
+		t.Fatal("two timestamps gave the same signature")
+	}
+
+	data, err := json.Marshal(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Attestation
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Verify() {
+		t.Fatal("timestamped attestation does not verify")
+	}
+	if got := hashAt(decoded.Timestamp.Unix()); !bytes.Equal(got, decoded.MessageHash[:]) {
+		t.Errorf("carried timestamp re-derives %x, signed %x", got, decoded.MessageHash)
+	}
+
+	// The signature does not carry over to another time.
+	moved := *first
+	copy(moved.MessageHash[:], hashAt(1700000001))
+	if moved.Verify() {
+		t.Error("signature verifies for another timestamp")
+	}
+
+	resigned, err := first.Resign(signer.NewDeterministicSigner(priv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resigned.Timestamp == nil || !resigned.Timestamp.Equal(*first.Timestamp) {
+		t.Errorf("Resign dropped the timestamp: %v", resigned.Timestamp)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// batchLine is a non-blank line of a batch file.
+type batchLine struct {
+	number int
+	data   []byte
+}
+
+// readBatch reads a batch file and returns one digest per line, and
+// with jsonLines the nonce of each line, nil for lines without one. Lines
+// starting with 0x are decoded as hex, anything else is taken as raw
+// bytes. Blank lines are skipped. Every malformed line is reported with
+// its line number. Messages are hashed with a hasher from newHasher per
+// worker, tagged with domain as in signer.HashWithDomain when it is
+// non-nil. With jsonLines every line is a JSON request instead, see
+// jsonRequest.
+func readBatch(path string, workers int, newHasher func() (signer.Hasher, error), hashOnly, jsonLines bool, domain []byte, stats *signStats) ([][]byte, []*big.Int, error) {
+	lines, err := readBatchLines(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(lines) == 0 {
+		return nil, nil, errors.New("batch file has no messages")
+	}
+
+	hashers := make([]signer.Hasher, workers)
+	for i := range hashers {
+		hashers[i], err = newHasher()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	digests := make([][]byte, len(lines))
+	var nonces []*big.Int
+	if jsonLines {
+		nonces = make([]*big.Int, len(lines))
+	}
+	errs := make([]error, len(lines))
+	runWorkers(len(lines), workers, func(worker, i int) {
+		start := stats.now()
+		if jsonLines {
+			digests[i], nonces[i], errs[i] = jsonDigest(hashers[worker], lines[i].data, domain)
+		} else {
+			digests[i], errs[i] = batchDigest(hashers[worker], lines[i].data, hashOnly, domain)
+		}
+		stats.record(i, start)
+		if errs[i] != nil {
+			errs[i] = fmt.Errorf("line %d: %w", lines[i].number, errs[i])
+		}
+	})
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, err
+	}
+
+	return digests, nonces, nil
+}
+
+func readBatchLines(path string) ([]batchLine, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var (
+		lines  []batchLine
+		reader = bufio.NewReader(file)
+	)
+
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) > 0 {
+			lines = append(lines, batchLine{number: lineNumber, data: line})
+		}
+
+		if err == io.EOF {
+			return lines, nil
+		}
+	}
+}
+
+func batchDigest(hasher signer.Hasher, line []byte, hashOnly bool, domain []byte) ([]byte, error) {
+	if hashOnly {
+		return signer.DecodeHash(string(line))
+	}
+
+	message := line
+	if bytes.HasPrefix(line, []byte("0x")) {
+		decoded, err := signer.DecodeHex(string(line))
+		if err != nil {
+			return nil, err
+		}
+		message = decoded
+	}
+
+	if domain != nil {
+		return signer.HashWithDomain(hasher, domain, message), nil
+	}
+
+	return hasher.Hash(message), nil
+}
+
+// signAll signs every hash on up to workers goroutines. Signatures are
+// returned in the order of hashes. s must be safe for concurrent use,
+// which KeySigner is.
+func signAll(s signer.Signer, hashes [][]byte, workers int, stats *signStats) ([]*schnorr.Signature, error) {
+	signatures := make([]*schnorr.Signature, len(hashes))
+	errs := make([]error, len(hashes))
+	runWorkers(len(hashes), workers, func(_, i int) {
+		start := stats.now()
+		signatures[i], errs[i] = s.Sign(hashes[i])
+		stats.record(i, start)
+	})
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return signatures, nil
+}
+
+// runWorkers calls work(worker, i) for every i in [0, n) on up to
+// workers goroutines, numbered from 0, and waits for all of them. Each
+// result should be stored by i so the completion order does not matter.
+func runWorkers(n, workers int, work func(worker, i int)) {
+	if workers > n {
+		workers = n
+	}
+
+	var (
+		wg      sync.WaitGroup
+		indices = make(chan int)
+	)
+
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				work(worker, i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+// writeBatchFile writes n distinct messages, one per line, and returns
+// the file's path.
+func writeBatchFile(t testing.TB, n int) string {
+	t.Helper()
+
+	var lines strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&lines, "message %d\n", i)
+	}
+	path := filepath.Join(t.TempDir(), "batch.txt")
+	if err := os.WriteFile(path, []byte(lines.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newKeccak256() (signer.Hasher, error) {
+	return signer.NewHasher(signer.HashKeccak256, nil)
+}
+
+func TestRunWorkersCompletionOrder(t *testing.T) {
+	const n = 16
+
+	// Every item waits for the one after it, so they complete in
+	// reverse index order.
+	done := make([]chan struct{}, n+1)
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	close(done[n])
+
+	var (
+		mu        sync.Mutex
+		completed []int
+		results   = make([]int, n)
+	)
+	runWorkers(n, n, func(_, i int) {
+		<-done[i+1]
+		results[i] = i * i
+
+		mu.Lock()
+		completed = append(completed, i)
+		mu.Unlock()
+		close(done[i])
+	})
+
+	if len(completed) != n || completed[0] != n-1 || completed[n-1] != 0 {
+		t.Fatalf("items completed in order %v, want reverse index order", completed)
+	}
+	for i, result := range results {
+		if result != i*i {
+			t.Errorf("result %d is %d, want %d", i, result, i*i)
+		}
+	}
+}
+
+func TestBatchOrderAcrossWorkers(t *testing.T) {
+	path := writeBatchFile(t, 200)
+	priv, pub, err := signer.LoadKeyFromHex(strings.Repeat("01", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := signer.NewDeterministicSigner(priv)
+
+	sequential, _, err := readBatch(path, 1, newKeccak256, false, false, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := signAll(s, sequential, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, workers := range []int{2, 8, 500} {
+		digests, _, err := readBatch(path, workers, newKeccak256, false, false, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signatures, err := signAll(s, digests, workers, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range digests {
+			if string(digests[i]) != string(signer.HashMessage([]byte(fmt.Sprintf("message %d", i)))) {
+				t.Fatalf("workers=%d: digest %d is not line %d's", workers, i, i+1)
+			}
+			if !signatures[i].IsEqual(want[i]) || !signatures[i].Verify(digests[i], pub) {
+				t.Fatalf("workers=%d: signature %d is not over digest %d", workers, i, i)
+			}
+		}
+	}
+}
+
+func BenchmarkBatch(b *testing.B) {
+	path := writeBatchFile(b, 10000)
+	priv, _, err := signer.LoadKeyFromHex(strings.Repeat("01", 32))
+	if err != nil {
+		b.Fatal(err)
+	}
+	s := signer.NewKeySigner(priv)
+
+	counts := []int{1}
+	if procs := runtime.GOMAXPROCS(0); procs > 1 {
+		counts = append(counts, procs)
+	}
+	for _, workers := range counts {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				digests, _, err := readBatch(path, workers, newKeccak256, false, false, nil, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := signAll(s, digests, workers, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// benchMessage is the fixed message signed by bench.
+const benchMessage = "unchained bench"
+
+// bench is a developer command that signs a fixed message -count times
+// with an ephemeral key on -workers goroutines and prints the latency
+// and throughput, to compare hosts without a Go toolchain. A checksum
+// of every signature is printed so none of the work can be skipped.
+func bench(args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	count := flags.Int("count", 1000, "number of signatures")
+	workers := flags.Int("workers", 1, "sign on this many goroutines")
+	deterministic := flags.Bool("deterministic", false, "derive the nonce from key and message only (RFC6979)")
+	parseFlags(flags, args)
+
+	if *count < 1 || *workers < 1 {
+		fatal(usageError("-count and -workers must be at least 1"))
+	}
+
+	privateKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		fatal(keyError("Error generating key: %w", err))
+	}
+	s := newSigner(privateKey, *deterministic)
+	defer zeroSigner(s)
+
+	hash := signer.HashMessage([]byte(benchMessage))
+	hashes := make([][]byte, *count)
+	for i := range hashes {
+		hashes[i] = hash
+	}
+
+	stats := &signStats{}
+	signatures, err := signAll(s, hashes, *workers, stats)
+	if err != nil {
+		fatal(signError("Error signing message: %w", err))
+	}
+
+	checksum := sha256.New()
+	for _, sig := range signatures {
+		checksum.Write(sig.Serialize())
+	}
+
+	fmt.Printf("Workers: %d\n", *workers)
+	stats.report(os.Stdout)
+	fmt.Printf("Checksum: 0x%x\n", checksum.Sum(nil))
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBenchSmoke(t *testing.T) {
+	for _, workers := range []string{"1", "4"} {
+		t.Run("workers "+workers, func(t *testing.T) {
+			res := cliRun{}.run(t, "bench", "-count", "20", "-workers", workers)
+			if res.code != 0 {
+				t.Fatalf("exit %d: %s", res.code, res.stderr)
+			}
+			if got := field(t, res.stdout, "Workers"); got != workers {
+				t.Errorf("Workers is %q, want %s", got, workers)
+			}
+			checkStats(t, res.stdout, 20)
+
+			checksum, ok := strings.CutPrefix(field(t, res.stdout, "Checksum"), "0x")
+			if !ok || len(checksum) != 64 {
+				t.Errorf("Checksum is %q, want a 0x-prefixed SHA-256", checksum)
+			}
+		})
+	}
+
+	for _, args := range [][]string{{"-count", "0"}, {"-workers", "0"}} {
+		res := cliRun{}.run(t, append([]string{"bench"}, args...)...)
+		if res.code != exitUsage {
+			t.Errorf("%v: exit %d, want %d", args, res.code, exitUsage)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+// Package bundle collects the signatures of several validators over one
+// merkle root for submission in a single transaction. Validators are
+// named by their index in a fixed, ordered validator set, so the bundle
+// carries a participant bitmap instead of the keys themselves.
+//
+// A bundle holds either one BIP340 signature per participant or, when
+// the participants signed together with aggsig, a single signature that
+// verifies against the MuSig2 aggregate of their keys.
+//
+// The binary layout, all integers big-endian:
+//
+//	offset  size    field
+//	0       1       version, currently 1
+//	1       1       mode: 0 individual signatures, 1 aggregate
+//	2       32      merkle root
+//	34      2       validator set size n
+//	36      ⌈n/8⌉   participant bitmap, validator i is bit i%8 (least
+//	                significant first) of byte i/8
+//	        64*m    the m participants' signatures in index order, or a
+//	                single 64-byte aggregate signature
+package bundle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/TimeleapLabs/go-schnorr/aggsig"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// Version is the binary layout version written by MarshalBinary.
+const Version = 1
+
+// MaxValidators bounds the validator set, whose size is stored in two
+// bytes.
+const MaxValidators = 1<<16 - 1
+
+const (
+	modeIndividual byte = iota
+	modeAggregate
+)
+
+var (
+	ErrVersion          = errors.New("unsupported bundle version")
+	ErrLength           = errors.New("bundle has the wrong length")
+	ErrSetSize          = errors.New("validator set size does not match")
+	ErrIndex            = errors.New("validator index out of range")
+	ErrDuplicateSigner  = errors.New("validator already signed")
+	ErrInvalidSignature = errors.New("signature does not verify")
+	ErrMixedModes       = errors.New("bundle cannot hold both individual and aggregate signatures")
+	ErrEmpty            = errors.New("bundle has no signatures")
+	ErrRootMismatch     = errors.New("bundle is for a different root")
+)
+
+// Bundle is a set of signatures over Root by validators of a fixed set.
+type Bundle struct {
+	Root       [32]byte
+	validators []*btcec.PublicKey
+	signatures map[int][schnorr.SignatureSize]byte
+	signers    []bool
+	aggregate  *[schnorr.SignatureSize]byte
+}
+
+// New starts an empty bundle over root for the ordered validator set.
+func New(root []byte, validators []*btcec.PublicKey) (*Bundle, error) {
+	if len(root) != 32 {
+		return nil, fmt.Errorf("%w: root must be 32 bytes, got %d", ErrLength, len(root))
+	}
+	if len(validators) == 0 || len(validators) > MaxValidators {
+		return nil, fmt.Errorf("%w: %d validators, want 1 to %d", ErrSetSize, len(validators), MaxValidators)
+	}
+
+	b := &Bundle{
+		validators: validators,
+		signatures: make(map[int][schnorr.SignatureSize]byte),
+		signers:    make([]bool, len(validators)),
+	}
+	copy(b.Root[:], root)
+	return b, nil
+}
+
+// AddSignature adds validator index's signature over the root. The
+// signature must verify, and each validator can sign only once.
+func (b *Bundle) AddSignature(index int, sig *schnorr.Signature) error {
+	if b.aggregate != nil {
+		return ErrMixedModes
+	}
+	if err := b.checkIndex(index); err != nil {
+		return err
+	}
+	if b.signers[index] {
+		return fmt.Errorf("%w: %d", ErrDuplicateSigner, index)
+	}
+	if !sig.Verify(b.Root[:], b.validators[index]) {
+		return fmt.Errorf("%w: validator %d", ErrInvalidSignature, index)
+	}
+
+	var serialized [schnorr.SignatureSize]byte
+	copy(serialized[:], sig.Serialize())
+	b.signatures[index] = serialized
+	b.signers[index] = true
+	return nil
+}
+
+// SetAggregate sets a single aggsig signature over the root by the
+// validators at indices, replacing any earlier aggregate.
+func (b *Bundle) SetAggregate(indices []int, sig *schnorr.Signature) error {
+	if len(b.signatures) > 0 {
+		return ErrMixedModes
+	}
+
+	signers := make([]bool, len(b.validators))
+	for _, index := range indices {
+		if err := b.checkIndex(index); err != nil {
+			return err
+		}
+		if signers[index] {
+			return fmt.Errorf("%w: %d", ErrDuplicateSigner, index)
+		}
+		signers[index] = true
+	}
+
+	var serialized [schnorr.SignatureSize]byte
+	copy(serialized[:], sig.Serialize())
+
+	candidate := &Bundle{Root: b.Root, validators: b.validators, signers: signers, aggregate: &serialized}
+	if err := candidate.Verify(b.Root[:]); err != nil {
+		return err
+	}
+
+	b.signers = signers
+	b.aggregate = &serialized
+	return nil
+}
+
+// Signers returns the indices of the validators that signed, in order.
+func (b *Bundle) Signers() []int {
+	var indices []int
+	for i, signed := range b.signers {
+		if signed {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// Verify checks that the bundle is over root, holds at least one
+// signature and that every signature verifies. Reaching a quorum is
+// for the caller to decide from Signers.
+func (b *Bundle) Verify(root []byte) error {
+	if !bytes.Equal(root, b.Root[:]) {
+		return ErrRootMismatch
+	}
+
+	signers := b.Signers()
+	if len(signers) == 0 {
+		return ErrEmpty
+	}
+
+	if b.aggregate != nil {
+		keys := make([]*btcec.PublicKey, len(signers))
+		for i, index := range signers {
+			keys[i] = b.validators[index]
+		}
+
+		sig, err := schnorr.ParseSignature(b.aggregate[:])
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+		}
+		if _, err := aggsig.VerifyAggregate(keys, b.Root[:], sig); err != nil {
+			return fmt.Errorf("%w: aggregate: %w", ErrInvalidSignature, err)
+		}
+		return nil
+	}
+
+	for _, index := range signers {
+		serialized := b.signatures[index]
+		sig, err := schnorr.ParseSignature(serialized[:])
+		if err != nil || !sig.Verify(b.Root[:], b.validators[index]) {
+			return fmt.Errorf("%w: validator %d", ErrInvalidSignature, index)
+		}
+	}
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (b *Bundle) MarshalBinary() ([]byte, error) {
+	signers := b.Signers()
+	if len(signers) == 0 {
+		return nil, ErrEmpty
+	}
+
+	mode := modeIndividual
+	if b.aggregate != nil {
+		mode = modeAggregate
+	}
+
+	data := []byte{Version, mode}
+	data = append(data, b.Root[:]...)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(b.validators)))
+
+	bitmap := make([]byte, (len(b.validators)+7)/8)
+	for _, index := range signers {
+		bitmap[index/8] |= 1 << (index % 8)
+	}
+	data = append(data, bitmap...)
+
+	if b.aggregate != nil {
+		return append(data, b.aggregate[:]...), nil
+	}
+	for _, index := range signers {
+		serialized := b.signatures[index]
+		data = append(data, serialized[:]...)
+	}
+	return data, nil
+}
+
+// Parse decodes a bundle written by MarshalBinary for the given
+// validator set and verifies it.
+func Parse(data []byte, validators []*btcec.PublicKey) (*Bundle, error) {
+	if len(data) < 36 {
+		return nil, fmt.Errorf("%w: %d bytes is shorter than the header", ErrLength, len(data))
+	}
+	if data[0] != Version {
+		return nil, fmt.Errorf("%w: %d", ErrVersion, data[0])
+	}
+	mode := data[1]
+	if mode != modeIndividual && mode != modeAggregate {
+		return nil, fmt.Errorf("%w: unknown mode %d", ErrVersion, mode)
+	}
+
+	b, err := New(data[2:34], validators)
+	if err != nil {
+		return nil, err
+	}
+	if n := int(binary.BigEndian.Uint16(data[34:36])); n != len(validators) {
+		return nil, fmt.Errorf("%w: bundle is for %d validators, got %d", ErrSetSize, n, len(validators))
+	}
+
+	rest := data[36:]
+	bitmapSize := (len(validators) + 7) / 8
+	if len(rest) < bitmapSize {
+		return nil, fmt.Errorf("%w: bitmap is truncated", ErrLength)
+	}
+	bitmap, rest := rest[:bitmapSize], rest[bitmapSize:]
+
+	for i := range b.signers {
+		b.signers[i] = bitmap[i/8]&(1<<(i%8)) != 0
+	}
+	if len(validators)%8 != 0 && bitmap[bitmapSize-1]>>(len(validators)%8) != 0 {
+		return nil, fmt.Errorf("%w: bitmap names validators past the set", ErrIndex)
+	}
+	signers := b.Signers()
+
+	want := schnorr.SignatureSize * len(signers)
+	if mode == modeAggregate {
+		want = schnorr.SignatureSize
+	}
+	if len(rest) != want {
+		return nil, fmt.Errorf("%w: %d signature bytes, want %d", ErrLength, len(rest), want)
+	}
+
+	if mode == modeAggregate {
+		var serialized [schnorr.SignatureSize]byte
+		copy(serialized[:], rest)
+		b.aggregate = &serialized
+	} else {
+		for _, index := range signers {
+			var serialized [schnorr.SignatureSize]byte
+			rest = rest[copy(serialized[:], rest):]
+			b.signatures[index] = serialized
+		}
+	}
+
+	if err := b.Verify(b.Root[:]); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *Bundle) checkIndex(index int) error {
+	if index < 0 || index >= len(b.validators) {
+		return fmt.Errorf("%w: %d of %d", ErrIndex, index, len(b.validators))
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package bundle
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/TimeleapLabs/go-schnorr/aggsig"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+)
+
+// testValidators returns n validators, the i-th with every key byte
+// set to i+1.
+func testValidators(t testing.TB, n int) ([]*btcec.PrivateKey, []*btcec.PublicKey) {
+	t.Helper()
+
+	privs := make([]*btcec.PrivateKey, n)
+	pubs := make([]*btcec.PublicKey, n)
+	for i := range privs {
+		priv, err := signer.PrivateKeyFromBytes(bytes.Repeat([]byte{byte(i + 1)}, 32))
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs[i], pubs[i] = priv, priv.PubKey()
+	}
+	return privs, pubs
+}
+
+// testRoot is the merkle root the tests' validators sign.
+var testRoot = signer.HashMessage([]byte("bundle root"))
+
+// signedBundle returns a bundle over testRoot signed by the validators
+// at indices.
+func signedBundle(t testing.TB, privs []*btcec.PrivateKey, pubs []*btcec.PublicKey, indices ...int) *Bundle {
+	t.Helper()
+
+	b, err := New(testRoot, pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, index := range indices {
+		sig, err := signer.SignDeterministic(privs[index], testRoot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.AddSignature(index, sig); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return b
+}
+
+func FuzzParse(f *testing.F) {
+	privs, pubs := testValidators(f, 10)
+	for _, indices := range [][]int{{0}, {1, 3, 9}, {0, 1, 2, 3, 4, 5, 6, 7, 8, 9}} {
+		data, err := signedBundle(f, privs, pubs, indices...).MarshalBinary()
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte{Version, modeAggregate})
+
+	// Only a bundle with valid signatures parses, and it must encode
+	// back to the same bytes.
+	f.Fuzz(func(t *testing.T, data []byte) {
+		b, err := Parse(data, pubs)
+		if err != nil {
+			return
+		}
+		encoded, err := b.MarshalBinary()
+		if err != nil {
+			t.Fatalf("parsed bundle does not encode: %v", err)
+		}
+		if !bytes.Equal(encoded, data) {
+			t.Fatalf("%x encodes back to %x", data, encoded)
+		}
+	})
+}
+
+// aggregateSig runs an aggsig round over testRoot between the
+// validators at indices.
+func aggregateSig(t testing.TB, privs []*btcec.PrivateKey, pubs []*btcec.PublicKey, indices ...int) *schnorr.Signature {
+	t.Helper()
+
+	keys := make([]*btcec.PublicKey, len(indices))
+	nonces := make([]*aggsig.Nonces, len(indices))
+	pubNonces := make([][musig2.PubNonceSize]byte, len(indices))
+	for i, index := range indices {
+		keys[i] = pubs[index]
+		n, err := aggsig.GenerateNonces(privs[index])
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonces[i], pubNonces[i] = n, n.PubNonce
+	}
+
+	aggNonce, err := aggsig.AggregateNonces(pubNonces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partials := make([]*aggsig.PartialSignature, len(indices))
+	for i, index := range indices {
+		partials[i], err = aggsig.PartialSign(privs[index], nonces[i], aggNonce, keys, testRoot)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sig, err := aggsig.CombinePartialSigs(keys, aggNonce, testRoot, partials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func TestPartialParticipation(t *testing.T) {
+	privs, pubs := testValidators(t, 10)
+
+	// Signatures may arrive in any order.
+	b := signedBundle(t, privs, pubs, 9, 1, 3)
+	if got := b.Signers(); !slices.Equal(got, []int{1, 3, 9}) {
+		t.Errorf("signers are %v, want [1 3 9]", got)
+	}
+	if err := b.Verify(testRoot); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 36 + 2 + 3*64; len(data) != want {
+		t.Fatalf("bundle is %d bytes, want %d", len(data), want)
+	}
+	if data[0] != Version || data[1] != modeIndividual || !bytes.Equal(data[2:34], testRoot) {
+		t.Errorf("header is %x", data[:34])
+	}
+	if data[34] != 0 || data[35] != 10 {
+		t.Errorf("set size is %x, want 000a", data[34:36])
+	}
+	// Validators 1 and 3 in the first byte, 9 in the second.
+	if data[36] != 0x0a || data[37] != 0x02 {
+		t.Errorf("bitmap is %x, want 0a02", data[36:38])
+	}
+
+	// Signatures follow in index order, not arrival order.
+	for i, index := range []int{1, 3, 9} {
+		sig, err := schnorr.ParseSignature(data[38+64*i : 38+64*(i+1)])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sig.Verify(testRoot, pubs[index]) {
+			t.Errorf("signature %d is not validator %d's", i, index)
+		}
+	}
+
+	parsed, err := Parse(data, pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(parsed.Signers(), []int{1, 3, 9}) {
+		t.Errorf("parsed signers are %v", parsed.Signers())
+	}
+
+	if err := b.Verify(signer.HashMessage([]byte("other root"))); !errors.Is(err, ErrRootMismatch) {
+		t.Errorf("other root: got %v, want ErrRootMismatch", err)
+	}
+}
+
+func TestAggregateParticipation(t *testing.T) {
+	privs, pubs := testValidators(t, 10)
+
+	b, err := New(testRoot, pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetAggregate([]int{4, 0, 2}, aggregateSig(t, privs, pubs, 0, 2, 4)); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Signers(); !slices.Equal(got, []int{0, 2, 4}) {
+		t.Errorf("signers are %v, want [0 2 4]", got)
+	}
+
+	data, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 36 + 2 + 64; len(data) != want || data[1] != modeAggregate || data[36] != 0x15 {
+		t.Errorf("aggregate bundle is %x", data)
+	}
+	if _, err := Parse(data, pubs); err != nil {
+		t.Error(err)
+	}
+
+	// The aggregate of other signers does not verify for these.
+	if err := b.SetAggregate([]int{0, 2, 5}, aggregateSig(t, privs, pubs, 0, 2, 4)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("wrong signer set: got %v, want ErrInvalidSignature", err)
+	}
+	if !slices.Equal(b.Signers(), []int{0, 2, 4}) {
+		t.Error("a rejected aggregate changed the signers")
+	}
+
+	sig, err := signer.SignDeterministic(privs[1], testRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AddSignature(1, sig); !errors.Is(err, ErrMixedModes) {
+		t.Errorf("individual signature after aggregate: got %v, want ErrMixedModes", err)
+	}
+}
+
+func TestDuplicateSigner(t *testing.T) {
+	privs, pubs := testValidators(t, 4)
+	b := signedBundle(t, privs, pubs, 2)
+
+	// A second, different but valid signature by the same validator.
+	again, err := signer.SignMessage(privs[2], testRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AddSignature(2, again); !errors.Is(err, ErrDuplicateSigner) {
+		t.Errorf("second signature: got %v, want ErrDuplicateSigner", err)
+	}
+	if !slices.Equal(b.Signers(), []int{2}) {
+		t.Errorf("signers are %v, want [2]", b.Signers())
+	}
+
+	agg, err := New(testRoot, pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := agg.SetAggregate([]int{0, 1, 0}, aggregateSig(t, privs, pubs, 0, 1)); !errors.Is(err, ErrDuplicateSigner) {
+		t.Errorf("repeated aggregate index: got %v, want ErrDuplicateSigner", err)
+	}
+}
+
+func TestAddSignatureRejects(t *testing.T) {
+	privs, pubs := testValidators(t, 4)
+	b, err := New(testRoot, pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signer.SignDeterministic(privs[0], testRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, index := range []int{-1, 4} {
+		if err := b.AddSignature(index, sig); !errors.Is(err, ErrIndex) {
+			t.Errorf("index %d: got %v, want ErrIndex", index, err)
+		}
+	}
+	if err := b.AddSignature(1, sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("validator 0's signature as 1: got %v, want ErrInvalidSignature", err)
+	}
+
+	if _, err := b.MarshalBinary(); !errors.Is(err, ErrEmpty) {
+		t.Errorf("empty bundle: got %v, want ErrEmpty", err)
+	}
+	if err := b.Verify(testRoot); !errors.Is(err, ErrEmpty) {
+		t.Errorf("empty bundle: got %v, want ErrEmpty", err)
+	}
+
+	if _, err := New(testRoot[:31], pubs); !errors.Is(err, ErrLength) {
+		t.Errorf("short root: got %v, want ErrLength", err)
+	}
+	if _, err := New(testRoot, nil); !errors.Is(err, ErrSetSize) {
+		t.Errorf("no validators: got %v, want ErrSetSize", err)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	privs, pubs := testValidators(t, 10)
+	data, err := signedBundle(t, privs, pubs, 1, 3).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// change returns a copy of data with f applied.
+	change := func(f func([]byte) []byte) []byte { return f(bytes.Clone(data)) }
+
+	tests := []struct {
+		name string
+		data []byte
+		pubs []*btcec.PublicKey
+		want error
+	}{
+		{"short header", data[:35], pubs, ErrLength},
+		{"version", change(func(d []byte) []byte { d[0] = 2; return d }), pubs, ErrVersion},
+		{"mode", change(func(d []byte) []byte { d[1] = 2; return d }), pubs, ErrVersion},
+		{"other set", data, pubs[:9], ErrSetSize},
+		{"truncated signature", data[:len(data)-1], pubs, ErrLength},
+		{"extra signer bit", change(func(d []byte) []byte { d[36] |= 1; return d }), pubs, ErrLength},
+		{"bit past the set", change(func(d []byte) []byte { d[37] |= 0x80; return d }), pubs, ErrIndex},
+		{"tampered signature", change(func(d []byte) []byte { d[len(d)-1] ^= 1; return d }), pubs, ErrInvalidSignature},
+		{"swapped signatures", change(func(d []byte) []byte {
+			first := bytes.Clone(d[38:102])
+			copy(d[38:102], d[102:166])
+			copy(d[102:166], first)
+			return d
+		}), pubs, ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.data, tt.pubs); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/TimeleapLabs/go-schnorr/vectors"
+)
+
+// checkVectors recomputes the pinned signing vectors and fails if any
+// output changed or the committed fixtures.json no longer matches them.
+func checkVectors(args []string) {
+	flags := flag.NewFlagSet("check-vectors", flag.ExitOnError)
+	parseFlags(flags, args)
+
+	for _, v := range vectors.Vectors {
+		if err := v.Check(); err != nil {
+			fatal(fmt.Errorf("Vector %q failed: %w", v.Name, err))
+		}
+		infof("Vector %q ok", v.Name)
+	}
+
+	if err := vectors.CheckFixtures(); err != nil {
+		fatal(err)
+	}
+	infof("Fixtures ok")
+
+	if err := vectors.CheckWeightedAggregate(); err != nil {
+		fatal(err)
+	}
+	infof("Weighted aggregate ok")
+
+	if err := vectors.CheckNftPricesCalldata(); err != nil {
+		fatal(err)
+	}
+	infof("NftPrices calldata ok")
+
+	if err := vectors.CheckTaggedHashes(); err != nil {
+		fatal(err)
+	}
+	infof("Tagged hashes ok")
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/TimeleapLabs/go-schnorr/aggsig"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+// combine runs the final MuSig2 step offline: it reads the signers'
+// partial signatures from a JSON file and prints the combined signature.
+func combine(args []string) {
+	flags := flag.NewFlagSet("combine", flag.ExitOnError)
+	in := flags.String("in", "", "JSON file with the signers' partial signatures")
+	sigLayoutName := addSigLayoutFlag(flags)
+	parseFlags(flags, args)
+
+	sigLayout := parseSigLayout(*sigLayoutName)
+
+	if *in == "" {
+		fatal(usageError("-in is required"))
+	}
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		fatal(inputError("Error reading partial signatures: %w", err))
+	}
+
+	file, err := aggsig.ParseCombineFile(data)
+	if err != nil {
+		fatal(inputError("Error parsing partial signatures: %w", err))
+	}
+
+	signature, aggKey, err := file.Combine()
+	if err != nil {
+		fatal(signError("Error combining partial signatures: %w", err))
+	}
+
+	r, s, err := signer.SplitSignature(signature)
+	if err != nil {
+		fatal(signError("Error serializing signature: %w", err))
+	}
+	serialized, err := signer.SerializeSignature(signature, sigLayout)
+	if err != nil {
+		fatal(signError("Error serializing signature: %w", err))
+	}
+
+	fmt.Printf("Aggregated public key: 0x%x\n", signer.XOnlyPubKey(aggKey))
+	fmt.Printf("Signature: 0x%x\n", serialized)
+	debugf("Signature R: 0x%x", pad32(r[:]))
+	debugf("Signature S: 0x%x", pad32(s[:]))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"sort"
+	"strings"
+)
+
+// configKeys are the flags a -config file may set. A key that names a
+// flag the running subcommand does not have is ignored, so one file can
+// serve every subcommand.
+var configKeys = map[string]bool{
+	"hash":          true,
+	"hash-tag":      true,
+	"output":        true,
+	"domain":        true,
+	"keystore":      true,
+	"keystore-dir":  true,
+	"key-name":      true,
+	"pubkey-format": true,
+	"log-level":     true,
+}
+
+// parseFlags registers the flags every subcommand shares, parses args
+// and applies them. Values from a -config file fill in any flag not
+// given on the command line, so a flag beats the file and the file
+// beats the built-in default.
+func parseFlags(flags *flag.FlagSet, args []string) {
+	config := flags.String("config", "", "JSON file with default flag values")
+	level := addLogLevelFlag(flags)
+	flags.Parse(args)
+
+	if *config != "" {
+		if err := applyConfig(flags, *config); err != nil {
+			fatal(usageError("Error loading -config: %w", err))
+		}
+	}
+
+	setLogLevel(*level)
+}
+
+// applyConfig sets every flag named in the JSON object at path that was
+// not set on the command line.
+func applyConfig(flags *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+
+	var unknown []string
+	for name := range values {
+		if !configKeys[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return usageError("unknown keys %s", strings.Join(unknown, ", "))
+	}
+
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	for name, value := range values {
+		if set[name] || flags.Lookup(name) == nil {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return usageError("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	config := writeConfig(t, `{"hash": "sha256", "output": "json"}`)
+
+	tests := []struct {
+		name   string
+		args   []string
+		hash   string
+		output string
+		domain string
+	}{
+		{"defaults", nil, "keccak256", "text", ""},
+		{"config fills in", []string{"-config", config}, "sha256", "json", ""},
+		{"flag beats config", []string{"-config", config, "-hash", "bip340"}, "bip340", "json", ""},
+		{"flag before -config", []string{"-output", "text", "-config", config}, "sha256", "text", ""},
+		{"flag set to its default", []string{"-config", config, "-hash", "keccak256"}, "keccak256", "json", ""},
+		{"flag without config", []string{"-domain", "feeds"}, "keccak256", "text", "feeds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			hash := flags.String("hash", "keccak256", "")
+			output := flags.String("output", "text", "")
+			domain := flags.String("domain", "", "")
+			config := flags.String("config", "", "")
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if *config != "" {
+				if err := applyConfig(flags, *config); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if *hash != tt.hash || *output != tt.output || *domain != tt.domain {
+				t.Errorf("hash=%s output=%s domain=%q, want hash=%s output=%s domain=%q",
+					*hash, *output, *domain, tt.hash, tt.output, tt.domain)
+			}
+		})
+	}
+}
+
+func TestConfigIgnoresFlagsOfOtherSubcommands(t *testing.T) {
+	// keystore-dir is a known key even though this flag set lacks it.
+	config := writeConfig(t, `{"keystore-dir": "/keys", "output": "json"}`)
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	output := flags.String("output", "text", "")
+	if err := applyConfig(flags, config); err != nil {
+		t.Fatal(err)
+	}
+	if *output != "json" {
+		t.Errorf("output is %s, want json", *output)
+	}
+}
+
+func TestConfigRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown keys", `{"key": "0x01", "signature": "0x02"}`, "unknown keys key, signature"},
+		{"not an object", `["hash"]`, "cannot unmarshal"},
+		{"bad value", `{"log-level": 3}`, "cannot unmarshal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			err := applyConfig(flags, writeConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := applyConfig(flags, filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("missing config file loaded")
+	}
+}
+
+// The secret-bearing key flag cannot come from a config file, and the
+// file applies to a real run as well.
+func TestConfigFromCLI(t *testing.T) {
+	config := writeConfig(t, `{"output": "json"}`)
+
+	res := runCLI(t, "sign", "-message", "hello", "-config", config)
+	if res.code != 0 {
+		t.Fatalf("exit %d: %s", res.code, res.stderr)
+	}
+	if !strings.HasPrefix(res.stdout, "{") {
+		t.Errorf("-config output json ignored:\n%s", res.stdout)
+	}
+
+	res = runCLI(t, "sign", "-message", "hello", "-config", config, "-output", "text")
+	if !strings.HasPrefix(res.stdout, "Public key: ") {
+		t.Errorf("-output text did not beat the config:\n%s", res.stdout)
+	}
+
+	res = runCLI(t, "sign", "-message", "hello", "-config", writeConfig(t, `{"key": "0x01"}`))
+	if res.code != exitUsage {
+		t.Errorf("config with key: exit %d, want %d", res.code, exitUsage)
+	}
+}
@@ -0,0 +1,81 @@
+package eip712
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ProofOfStakeName and ProofOfStakeVersion are the EIP-712 domain
+// ProofOfStake is deployed with.
+const (
+	ProofOfStakeName    = "Unchained Proof of Stake"
+	ProofOfStakeVersion = "1.0.0"
+)
+
+// SetNftPricesSelector is the function selector of
+// ProofOfStake.setNftPrices(NftPrices, Signature).
+var SetNftPricesSelector = keccak([]byte(
+	"setNftPrices((uint256[],uint256[],uint256),(uint256,uint256))",
+))[:4]
+
+// EncodeSetNftPrices returns the ABI-encoded call to setNftPrices with
+// the given payload and its 64-byte BIP340 signature, which the
+// contract takes as its rx and s words.
+//
+// The payload tuple is dynamic, so the head holds its offset followed by
+// the static signature tuple inline:
+//
+//	selector
+//	0x60                  offset of the NftPrices tuple
+//	rx, s                 signature
+//	0x60, offset, nonce   NftPrices head: offsets of nfts and prices
+//	len, nfts...
+//	len, prices...
+func EncodeSetNftPrices(nfts, prices []*big.Int, nonce *big.Int, sig []byte) ([]byte, error) {
+	if len(nfts) != len(prices) {
+		return nil, errors.New("nfts and prices must have the same length")
+	}
+	if len(sig) != 64 {
+		return nil, fmt.Errorf("signature must be 64 bytes, got %d", len(sig))
+	}
+
+	encodedNfts, err := encodeDynamicUint256Array(nfts)
+	if err != nil {
+		return nil, err
+	}
+	encodedPrices, err := encodeDynamicUint256Array(prices)
+	if err != nil {
+		return nil, err
+	}
+	encodedNonce, err := EncodeUint256(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	calldata := append([]byte(nil), SetNftPricesSelector...)
+	calldata = append(calldata, word(3*32)...)
+	calldata = append(calldata, sig...)
+
+	calldata = append(calldata, word(3*32)...)
+	calldata = append(calldata, word(3*32+len(encodedNfts))...)
+	calldata = append(calldata, encodedNonce...)
+	calldata = append(calldata, encodedNfts...)
+	calldata = append(calldata, encodedPrices...)
+
+	return calldata, nil
+}
+
+// encodeDynamicUint256Array encodes values like abi.encode(uint256[]),
+// the length word followed by the elements.
+func encodeDynamicUint256Array(values []*big.Int) ([]byte, error) {
+	elements, err := encodeUint256Array(values)
+	if err != nil {
+		return nil, err
+	}
+	return append(word(len(values)), elements...), nil
+}
+
+func word(n int) []byte {
+	return big.NewInt(int64(n)).FillBytes(make([]byte, 32))
+}
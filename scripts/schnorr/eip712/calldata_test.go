@@ -0,0 +1,114 @@
+package eip712
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// The calldata of setNftPrices with nfts [1, 2] priced at [1e18, 16],
+// nonce 0, and the signature of the EIP-712 NftPrices vector. The
+// selector was computed independently as the first 4 bytes of
+// keccak256("setNftPrices((uint256[],uint256[],uint256),(uint256,uint256))").
+const (
+	fixtureSignature = "c87ef9c77e6a7498255f1037e69d24bd1249e533ad50d1d58db896fa57aff5da" +
+		"e1689fd89fc63594ce16f173dae65b83b9f43c9a505324ac027c688adba28837"
+	fixtureCalldata = "556935eb" +
+		"0000000000000000000000000000000000000000000000000000000000000060" + // offset of NftPrices
+		"c87ef9c77e6a7498255f1037e69d24bd1249e533ad50d1d58db896fa57aff5da" + // rx
+		"e1689fd89fc63594ce16f173dae65b83b9f43c9a505324ac027c688adba28837" + // s
+		"0000000000000000000000000000000000000000000000000000000000000060" + // offset of nfts
+		"00000000000000000000000000000000000000000000000000000000000000c0" + // offset of prices
+		"0000000000000000000000000000000000000000000000000000000000000000" + // nonce
+		"0000000000000000000000000000000000000000000000000000000000000002" + // nfts
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000002" + // prices
+		"0000000000000000000000000000000000000000000000000de0b6b3a7640000" +
+		"0000000000000000000000000000000000000000000000000000000000000010"
+)
+
+func TestEncodeSetNftPricesFixture(t *testing.T) {
+	if got := hex.EncodeToString(SetNftPricesSelector); got != "556935eb" {
+		t.Errorf("selector is %s, want 556935eb", got)
+	}
+
+	sig, err := hex.DecodeString(fixtureSignature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oneEther := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+	calldata, err := EncodeSetNftPrices(
+		[]*big.Int{big.NewInt(1), big.NewInt(2)},
+		[]*big.Int{oneEther, big.NewInt(16)},
+		big.NewInt(0),
+		sig,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(calldata); got != fixtureCalldata {
+		t.Errorf("calldata is\n%s\nwant\n%s", got, fixtureCalldata)
+	}
+}
+
+// The prices offset follows the length of the nfts array.
+func TestEncodeSetNftPricesOffsets(t *testing.T) {
+	sig := bytes.Repeat([]byte{0xab}, 64)
+	calldata, err := EncodeSetNftPrices(
+		[]*big.Int{big.NewInt(7)},
+		[]*big.Int{big.NewInt(9)},
+		big.NewInt(3),
+		sig,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	words := calldata[4:]
+	if len(words) != 10*32 {
+		t.Fatalf("calldata has %d bytes after the selector, want %d", len(words), 10*32)
+	}
+	wordAt := func(i int) string { return hex.EncodeToString(words[32*i : 32*(i+1)]) }
+	want := map[int]string{
+		0: "60", // offset of NftPrices
+		3: "60", // offset of nfts
+		4: "a0", // offset of prices, 0x60 + 2 words
+		5: "03", // nonce
+		6: "01", // nfts length
+		7: "07",
+		8: "01", // prices length
+		9: "09",
+	}
+	for i, tail := range want {
+		if got := wordAt(i); got != strings.Repeat("0", 64-len(tail))+tail {
+			t.Errorf("word %d is %s, want 0x%s", i, got, tail)
+		}
+	}
+	if !bytes.Equal(words[32:96], sig) {
+		t.Error("signature is not inline after the payload offset")
+	}
+}
+
+func TestEncodeSetNftPricesRejects(t *testing.T) {
+	one := []*big.Int{big.NewInt(1)}
+	sig := make([]byte, 64)
+
+	if _, err := EncodeSetNftPrices(one, nil, big.NewInt(0), sig); err == nil {
+		t.Error("mismatched lengths were encoded")
+	}
+	if _, err := EncodeSetNftPrices(one, one, big.NewInt(0), sig[:63]); err == nil {
+		t.Error("63-byte signature was encoded")
+	}
+	if _, err := EncodeSetNftPrices([]*big.Int{big.NewInt(-1)}, one, big.NewInt(0), sig); !errors.Is(err, ErrUint256Range) {
+		t.Errorf("negative nft: got %v, want ErrUint256Range", err)
+	}
+	tooBig := new(big.Int).Lsh(big.NewInt(1), 256)
+	if _, err := EncodeSetNftPrices(one, one, tooBig, sig); !errors.Is(err, ErrUint256Range) {
+		t.Errorf("2^256 nonce: got %v, want ErrUint256Range", err)
+	}
+}
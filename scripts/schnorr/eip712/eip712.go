@@ -0,0 +1,114 @@
+// Package eip712 computes EIP-712 typed data digests matching the
+// SchnorrUser contract, which is what the on-chain verifier checks
+// signatures against.
+package eip712
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+var (
+	DomainTypeHash = keccak([]byte(
+		"EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)",
+	))
+	NftPricesTypeHash = keccak([]byte(
+		"NftPrices(uint256[] nfts,uint256[] prices,uint256 nonce)",
+	))
+)
+
+var ErrUint256Range = errors.New("value does not fit in a uint256")
+
+// Domain is the EIP-712 domain of a SchnorrUser contract.
+type Domain struct {
+	Name              string
+	Version           string
+	ChainID           *big.Int
+	VerifyingContract signer.Address
+}
+
+// Separator returns the domain separator, mirroring SchnorrUser.hash.
+func (d Domain) Separator() ([]byte, error) {
+	chainID, err := EncodeUint256(d.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	var contract [32]byte
+	copy(contract[12:], d.VerifyingContract[:])
+
+	return keccak(
+		DomainTypeHash,
+		keccak([]byte(d.Name)),
+		keccak([]byte(d.Version)),
+		chainID,
+		contract[:],
+	), nil
+}
+
+// HashTypedData returns keccak256(0x1901 || domain || structHash) where
+// structHash is keccak256(typeHash || encodedStruct).
+func HashTypedData(domain, typeHash, encodedStruct []byte) []byte {
+	structHash := keccak(typeHash, encodedStruct)
+	return keccak([]byte{0x19, 0x01}, domain, structHash)
+}
+
+// HashNftPrices returns the digest SetNftPrices.eip712Hash computes for
+// the given payload.
+func HashNftPrices(domain []byte, nfts, prices []*big.Int, nonce *big.Int) ([]byte, error) {
+	if len(nfts) != len(prices) {
+		return nil, errors.New("nfts and prices must have the same length")
+	}
+
+	encodedNfts, err := encodeUint256Array(nfts)
+	if err != nil {
+		return nil, err
+	}
+	encodedPrices, err := encodeUint256Array(prices)
+	if err != nil {
+		return nil, err
+	}
+	encodedNonce, err := EncodeUint256(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := make([]byte, 0, 96)
+	encoded = append(encoded, keccak(encodedNfts)...)
+	encoded = append(encoded, keccak(encodedPrices)...)
+	encoded = append(encoded, encodedNonce...)
+
+	return HashTypedData(domain, NftPricesTypeHash, encoded), nil
+}
+
+// EncodeUint256 returns v as a 32-byte big-endian word.
+func EncodeUint256(v *big.Int) ([]byte, error) {
+	if v == nil || v.Sign() < 0 || v.BitLen() > 256 {
+		return nil, ErrUint256Range
+	}
+
+	return v.FillBytes(make([]byte, 32)), nil
+}
+
+// encodeUint256Array packs values like abi.encodePacked(uint256[]).
+func encodeUint256Array(values []*big.Int) ([]byte, error) {
+	encoded := make([]byte, 0, 32*len(values))
+	for _, v := range values {
+		word, err := EncodeUint256(v)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, word...)
+	}
+	return encoded, nil
+}
+
+func keccak(parts ...[]byte) []byte {
+	var buf []byte
+	for _, part := range parts {
+		buf = append(buf, part...)
+	}
+	return signer.HashMessage(buf)
+}
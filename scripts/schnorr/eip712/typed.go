@@ -0,0 +1,122 @@
+package eip712
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+// Uint256 is a JSON uint256, written either as a number or as a
+// decimal or 0x-prefixed hex string.
+type Uint256 big.Int
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (u *Uint256) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+
+	value, ok := new(big.Int).SetString(text, 0)
+	if !ok {
+		return fmt.Errorf("invalid uint256 %s", data)
+	}
+
+	if value.Sign() < 0 || value.BitLen() > 256 {
+		return ErrUint256Range
+	}
+
+	*u = Uint256(*value)
+	return nil
+}
+
+// Int returns u as a big.Int.
+func (u *Uint256) Int() *big.Int {
+	return (*big.Int)(u)
+}
+
+// TypedData is a JSON signing request for one of the contract's typed
+// payloads.
+type TypedData struct {
+	Domain struct {
+		Name              string  `json:"name"`
+		Version           string  `json:"version"`
+		ChainID           Uint256 `json:"chainId"`
+		VerifyingContract string  `json:"verifyingContract"`
+	} `json:"domain"`
+	Type    string          `json:"type"`
+	Message json.RawMessage `json:"message"`
+}
+
+// NftPrices is the message of a NftPrices typed data request.
+type NftPrices struct {
+	Nfts   []*Uint256 `json:"nfts"`
+	Prices []*Uint256 `json:"prices"`
+	Nonce  *Uint256   `json:"nonce"`
+}
+
+// messageHashers maps each supported type to the function hashing its
+// message under a domain separator.
+var messageHashers = map[string]func(domain []byte, message json.RawMessage) ([]byte, error){
+	"NftPrices": hashNftPricesMessage,
+}
+
+// ParseTypedData decodes a JSON typed data request.
+func ParseTypedData(data []byte) (*TypedData, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var typed TypedData
+	if err := decoder.Decode(&typed); err != nil {
+		return nil, err
+	}
+
+	return &typed, nil
+}
+
+// Digest returns the EIP-712 digest to sign for the request.
+func (t *TypedData) Digest() ([]byte, error) {
+	hasher, ok := messageHashers[t.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported typed data type %q", t.Type)
+	}
+
+	contract, err := signer.ParseAddress(t.Domain.VerifyingContract)
+	if err != nil {
+		return nil, fmt.Errorf("invalid verifyingContract: %w", err)
+	}
+
+	separator, err := Domain{
+		Name:              t.Domain.Name,
+		Version:           t.Domain.Version,
+		ChainID:           t.Domain.ChainID.Int(),
+		VerifyingContract: contract,
+	}.Separator()
+	if err != nil {
+		return nil, err
+	}
+
+	return hasher(separator, t.Message)
+}
+
+func hashNftPricesMessage(domain []byte, message json.RawMessage) ([]byte, error) {
+	var prices NftPrices
+	if err := json.Unmarshal(message, &prices); err != nil {
+		return nil, err
+	}
+
+	if prices.Nonce == nil {
+		return nil, fmt.Errorf("NftPrices message is missing the nonce")
+	}
+
+	return HashNftPrices(domain, toInts(prices.Nfts), toInts(prices.Prices), prices.Nonce.Int())
+}
+
+func toInts(values []*Uint256) []*big.Int {
+	ints := make([]*big.Int, len(values))
+	for i, v := range values {
+		ints[i] = v.Int()
+	}
+	return ints
+}
@@ -0,0 +1,125 @@
+package eip712
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+// rule checks one property of a value and returns a problem or nil.
+type rule[T any] func(T) error
+
+// domainRules apply to the domain of every request.
+var domainRules = []rule[*TypedData]{
+	func(t *TypedData) error {
+		if t.Domain.Name == "" || t.Domain.Version == "" {
+			return errors.New("domain name and version are required")
+		}
+		return nil
+	},
+	func(t *TypedData) error {
+		if t.Domain.ChainID.Int().Sign() == 0 {
+			return errors.New("domain chainId is zero")
+		}
+		return nil
+	},
+	func(t *TypedData) error {
+		contract, err := signer.ParseAddress(t.Domain.VerifyingContract)
+		if err != nil {
+			return fmt.Errorf("invalid verifyingContract: %w", err)
+		}
+		if contract == (signer.Address{}) {
+			return errors.New("verifyingContract is the zero address")
+		}
+		return nil
+	},
+}
+
+// messageValidators maps each supported type to the function checking
+// its message. A type listed in messageHashers should be listed here
+// too.
+var messageValidators = map[string]func(message json.RawMessage) []error{
+	"NftPrices": validateNftPricesMessage,
+}
+
+var nftPricesRules = []rule[*NftPrices]{
+	func(p *NftPrices) error {
+		if len(p.Nfts) == 0 || len(p.Prices) == 0 {
+			return errors.New("nfts and prices must not be empty")
+		}
+		return nil
+	},
+	func(p *NftPrices) error {
+		// setNftPrices reads prices[i] for every nfts[i].
+		if len(p.Nfts) != len(p.Prices) {
+			return fmt.Errorf("%d nfts but %d prices", len(p.Nfts), len(p.Prices))
+		}
+		return nil
+	},
+	func(p *NftPrices) error {
+		for i, v := range append(append([]*Uint256(nil), p.Nfts...), p.Prices...) {
+			if v == nil {
+				return fmt.Errorf("entry %d is null", i)
+			}
+		}
+		return nil
+	},
+	func(p *NftPrices) error {
+		// A repeated id would have its price silently overwritten.
+		for i := 1; i < len(p.Nfts); i++ {
+			if p.Nfts[i] == nil || p.Nfts[i-1] == nil {
+				continue
+			}
+			if p.Nfts[i].Int().Cmp(p.Nfts[i-1].Int()) <= 0 {
+				return fmt.Errorf("nfts must be strictly increasing, entry %d is not", i)
+			}
+		}
+		return nil
+	},
+	func(p *NftPrices) error {
+		if p.Nonce == nil {
+			return errors.New("nonce is missing")
+		}
+		return nil
+	},
+}
+
+// Validate checks the request without hashing or signing it and reports
+// every problem found. An empty result means the request is valid.
+func (t *TypedData) Validate() []error {
+	problems := check(t, domainRules)
+
+	validator, ok := messageValidators[t.Type]
+	if !ok {
+		return append(problems, fmt.Errorf("unsupported typed data type %q", t.Type))
+	}
+
+	return append(problems, validator(t.Message)...)
+}
+
+func validateNftPricesMessage(message json.RawMessage) []error {
+	var prices NftPrices
+	if err := json.Unmarshal(message, &prices); err != nil {
+		return []error{err}
+	}
+
+	return prices.Validate()
+}
+
+// Validate checks the payload on its own, without a domain, and reports
+// every problem found.
+func (p *NftPrices) Validate() []error {
+	return check(p, nftPricesRules)
+}
+
+func check[T any](value T, rules []rule[T]) []error {
+	var problems []error
+	for _, r := range rules {
+		if err := r(value); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return problems
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+
+	"github.com/TimeleapLabs/go-schnorr/eip712"
+	"github.com/TimeleapLabs/go-schnorr/keystore"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+// Exit codes, the same for every subcommand:
+//
+//	0  success
+//	1  any other failure
+//	2  usage error: bad or conflicting flags
+//	3  key error: missing, malformed or undecryptable key
+//	4  input error: unreadable or malformed message, signature or file
+//	5  signing failed
+//	6  verification failed: the signature does not match
+//	7  merkle proof failed (verify-proof)
+//	8  preimage mismatch: the message does not hash to the signed hash
+//	   (verify -verify-preimage)
+const (
+	exitFailure  = 1
+	exitUsage    = 2
+	exitKey      = 3
+	exitInput    = 4
+	exitSign     = 5
+	exitVerify   = 6
+	exitBadProof = 7
+	exitPreimage = 8
+)
+
+// exitError attaches an exit code to an error.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// codedError formats an error like fmt.Errorf with the given exit code,
+// unless it wraps an error that already has one, which is more specific.
+func codedError(code int, format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+
+	var inner *exitError
+	if errors.As(err, &inner) {
+		code = inner.code
+	}
+
+	return &exitError{code: code, err: err}
+}
+
+func usageError(format string, args ...any) error {
+	return codedError(exitUsage, format, args...)
+}
+
+func keyError(format string, args ...any) error {
+	return codedError(exitKey, format, args...)
+}
+
+func inputError(format string, args ...any) error {
+	return codedError(exitInput, format, args...)
+}
+
+func signError(format string, args ...any) error {
+	return codedError(exitSign, format, args...)
+}
+
+func verifyError(format string, args ...any) error {
+	return codedError(exitVerify, format, args...)
+}
+
+// exitCode maps err to an exit code: the code it was created with, or
+// else one derived from the library errors it wraps.
+func exitCode(err error) int {
+	var coded *exitError
+	var pathErr *fs.PathError
+	switch {
+	case errors.As(err, &coded):
+		return coded.code
+	case errors.Is(err, signer.ErrInvalidKey),
+		errors.Is(err, keystore.ErrWrongPassphrase),
+		errors.Is(err, keystore.ErrCorrupted):
+		return exitKey
+	case errors.Is(err, signer.ErrUnknownFormat),
+		errors.Is(err, signer.ErrUnknownHash):
+		return exitUsage
+	case errors.Is(err, signer.ErrDecodeMessage),
+		errors.Is(err, signer.ErrInvalidSignature),
+		errors.Is(err, signer.ErrInvalidNonce),
+		errors.Is(err, signer.ErrInvalidTimestamp),
+		errors.Is(err, eip712.ErrUint256Range),
+		errors.As(err, &pathErr):
+		return exitInput
+	case errors.Is(err, signer.ErrSignFailed):
+		return exitSign
+	}
+	return exitFailure
+}
+
+// fatal logs err and exits with its exit code. Every subcommand ends
+// through here on failure.
+func fatal(err error) {
+	log.Print(err)
+	os.Exit(exitCode(err))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/TimeleapLabs/go-schnorr/eip712"
+	"github.com/TimeleapLabs/go-schnorr/keystore"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+func TestExitCode(t *testing.T) {
+	_, pathErr := os.Open("/nonexistent/schnorr")
+
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"other failure", errors.New("boom"), exitFailure},
+		{"usage", usageError("bad flag"), exitUsage},
+		{"key", keyError("no key"), exitKey},
+		{"input", inputError("bad message"), exitInput},
+		{"sign", signError("sign failed"), exitSign},
+		{"verify", verifyError("mismatch"), exitVerify},
+		{"bad proof", codedError(exitBadProof, "proof failed"), exitBadProof},
+		{"preimage", codedError(exitPreimage, "preimage mismatch"), exitPreimage},
+
+		// A wrapped exit code is more specific than the outer one.
+		{"nested", inputError("reading: %w", keyError("no key")), exitKey},
+		{"wrapped by fmt", fmt.Errorf("context: %w", verifyError("mismatch")), exitVerify},
+
+		{"invalid key", fmt.Errorf("x: %w", signer.ErrInvalidKey), exitKey},
+		{"wrong passphrase", keystore.ErrWrongPassphrase, exitKey},
+		{"corrupted keystore", keystore.ErrCorrupted, exitKey},
+		{"unknown format", signer.ErrUnknownFormat, exitUsage},
+		{"unknown hash", signer.ErrUnknownHash, exitUsage},
+		{"decode message", signer.ErrDecodeMessage, exitInput},
+		{"invalid signature", signer.ErrInvalidSignature, exitInput},
+		{"invalid nonce", signer.ErrInvalidNonce, exitInput},
+		{"invalid timestamp", signer.ErrInvalidTimestamp, exitInput},
+		{"uint256 range", eip712.ErrUint256Range, exitInput},
+		{"missing file", pathErr, exitInput},
+		{"sign failed", signer.ErrSignFailed, exitSign},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.code {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.code)
+			}
+		})
+	}
+}
+
+func TestCodedErrorMessage(t *testing.T) {
+	err := usageError("-%s is required", "in")
+	if err.Error() != "-in is required" {
+		t.Errorf("message is %q", err.Error())
+	}
+
+	inner := signer.ErrInvalidKey
+	if err := keyError("loading: %w", inner); !errors.Is(err, inner) {
+		t.Error("coded error does not unwrap to its cause")
+	}
+}
+
+// The codes are what the binary actually exits with.
+func TestExitCodesFromCLI(t *testing.T) {
+	tests := []struct {
+		name string
+		run  cliRun
+		args []string
+		code int
+	}{
+		{"success", cliRun{env: []string{"SCHNORR_KEY=" + testKeyHex}}, []string{"sign", "-message", "hi"}, 0},
+		{"usage", cliRun{env: []string{"SCHNORR_KEY=" + testKeyHex}}, []string{"sign", "-output", "yaml"}, exitUsage},
+		{"key", cliRun{}, []string{"sign", "-message", "hi"}, exitKey},
+		{"input", cliRun{env: []string{"SCHNORR_KEY=" + testKeyHex}}, []string{"sign", "-hash-only", "-message", "0xzz"}, exitInput},
+		{"verify", cliRun{}, []string{"verify", "-message", "hi", "-pubkey", testPubKey, "-signature", "0x" + strings.Repeat("11", 32) + strings.Repeat("22", 32)}, exitVerify},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if res := tt.run.run(t, tt.args...); res.code != tt.code {
+				t.Errorf("exit %d, want %d: %s", res.code, tt.code, res.stderr)
+			}
+		})
+	}
+}
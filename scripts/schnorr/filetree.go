@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/TimeleapLabs/go-schnorr/merkle"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+type fileTreeOutput struct {
+	Root      string         `json:"root"`
+	PublicKey string         `json:"publicKey"`
+	Signature string         `json:"signature"`
+	Manifest  map[string]int `json:"manifest"`
+}
+
+// signFileTree builds a merkle tree over the files below a directory,
+// one leaf per file in sorted path order, and signs its root.
+func signFileTree(args []string) {
+	flags := flag.NewFlagSet("sign-file-tree", flag.ExitOnError)
+	dir := flags.String("dir", "", "directory to attest to")
+	followSymlinks := flags.Bool("follow-symlinks", false, "follow symlinks instead of skipping them")
+	output := flags.String("output", "text", "output format: text or json")
+	sigLayoutName := addSigLayoutFlag(flags)
+	key := addKeyFlags(flags)
+	parseFlags(flags, args)
+
+	sigLayout := parseSigLayout(*sigLayoutName)
+
+	if *dir == "" {
+		fatal(usageError("-dir is required"))
+	}
+
+	paths, err := collectFiles(*dir, *followSymlinks)
+	if err != nil {
+		fatal(inputError("Error walking directory: %w", err))
+	}
+
+	if len(paths) == 0 {
+		fatal(inputError("No files found in %s", *dir))
+	}
+
+	// Files are hashed as they are read, so only one leaf hash per file
+	// is held however large the snapshot is.
+	hashes := make([][]byte, len(paths))
+	manifest := make(map[string]int, len(paths))
+	for i, p := range paths {
+		hashes[i], err = hashFileLeaf(filepath.Join(*dir, filepath.FromSlash(p)))
+		if err != nil {
+			fatal(inputError("Error reading file: %w", err))
+		}
+		manifest[p] = i
+	}
+
+	tree, err := merkle.NewFromLeafHashes(hashes)
+	if err != nil {
+		fatal(fmt.Errorf("Error building merkle tree: %w", err))
+	}
+
+	privateKey, _ := key.load()
+	s := newSigner(privateKey, false)
+	defer zeroSigner(s)
+
+	signature, err := s.Sign(tree.Root())
+	if err != nil {
+		fatal(signError("Error signing root: %w", err))
+	}
+	serialized, err := signer.SerializeSignature(signature, sigLayout)
+	if err != nil {
+		fatal(signError("Error serializing signature: %w", err))
+	}
+
+	out := fileTreeOutput{
+		Root:      fmt.Sprintf("0x%x", tree.Root()),
+		PublicKey: fmt.Sprintf("0x%x", signer.XOnlyPubKey(s.PublicKey())),
+		Signature: fmt.Sprintf("0x%x", serialized),
+		Manifest:  manifest,
+	}
+
+	if *output == "json" {
+		err := json.NewEncoder(os.Stdout).Encode(out)
+		if err != nil {
+			fatal(fmt.Errorf("Error encoding output: %w", err))
+		}
+		return
+	}
+
+	fmt.Printf("Public key: %s\n", out.PublicKey)
+	fmt.Printf("Root: %s\n", out.Root)
+	fmt.Printf("Signature: %s\n", out.Signature)
+	for i, p := range paths {
+		fmt.Printf("Leaf %d: %s\n", i, p)
+	}
+}
+
+// hashFileLeaf returns the merkle leaf hash of the file at path.
+func hashFileLeaf(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return merkle.HashLeafReader(file)
+}
+
+// collectFiles returns the slash separated paths of all regular files
+// below root, sorted so the order does not depend on the filesystem.
+// Symlinks are skipped unless follow is set, in which case every real
+// directory is visited at most once to avoid cycles.
+func collectFiles(root string, follow bool) ([]string, error) {
+	var (
+		paths   []string
+		visited = map[string]bool{}
+	)
+
+	var walk func(dir, rel string) error
+	walk = func(dir, rel string) error {
+		real, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return err
+		}
+		if visited[real] {
+			return nil
+		}
+		visited[real] = true
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			full := filepath.Join(dir, entry.Name())
+			name := path.Join(rel, entry.Name())
+
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+
+			if info.Mode()&os.ModeSymlink != 0 {
+				if !follow {
+					continue
+				}
+				info, err = os.Stat(full)
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			switch {
+			case info.IsDir():
+				if err := walk(full, name); err != nil {
+					return err
+				}
+			case info.Mode().IsRegular():
+				paths = append(paths, name)
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(root, ""); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/TimeleapLabs/go-schnorr/merkle"
+)
+
+// writeTree creates files, a map of slash separated paths to contents,
+// below dir in the given order.
+func writeTree(t *testing.T, dir string, order []string, files map[string]string) {
+	t.Helper()
+
+	for _, name := range order {
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(files[name]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// signTree runs sign-file-tree over dir with JSON output.
+func signTree(t *testing.T, dir string, extra ...string) fileTreeOutput {
+	t.Helper()
+
+	args := append([]string{"sign-file-tree", "-dir", dir, "-output", "json"}, extra...)
+	res := runCLI(t, args...)
+	if res.code != 0 {
+		t.Fatalf("sign-file-tree: exit %d: %s", res.code, res.stderr)
+	}
+
+	var out fileTreeOutput
+	if err := json.Unmarshal([]byte(res.stdout), &out); err != nil {
+		t.Fatalf("decoding %q: %v", res.stdout, err)
+	}
+	return out
+}
+
+// treeRoot is the root merkle.New gives for the contents in order.
+func treeRoot(t *testing.T, contents ...string) string {
+	t.Helper()
+
+	data := make([][]byte, len(contents))
+	for i, c := range contents {
+		data[i] = []byte(c)
+	}
+	tree, err := merkle.New(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("0x%x", tree.Root())
+}
+
+func TestSignFileTree(t *testing.T) {
+	files := map[string]string{
+		"b.txt":     "bravo",
+		"a.txt":     "alpha",
+		"sub/c.txt": "charlie",
+	}
+	dir := t.TempDir()
+	writeTree(t, dir, []string{"sub/c.txt", "b.txt", "a.txt"}, files)
+
+	out := signTree(t, dir)
+
+	wantManifest := map[string]int{"a.txt": 0, "b.txt": 1, "sub/c.txt": 2}
+	if !reflect.DeepEqual(out.Manifest, wantManifest) {
+		t.Errorf("manifest %v, want %v", out.Manifest, wantManifest)
+	}
+	if want := treeRoot(t, "alpha", "bravo", "charlie"); out.Root != want {
+		t.Errorf("root %s, want %s", out.Root, want)
+	}
+	if out.PublicKey != testPubKey {
+		t.Errorf("public key %s, want %s", out.PublicKey, testPubKey)
+	}
+
+	res := runCLI(t, "verify", "-message", out.Root, "-hash-only", "-pubkey", out.PublicKey, "-signature", out.Signature)
+	if res.code != 0 {
+		t.Errorf("root signature does not verify: exit %d: %s", res.code, res.stderr)
+	}
+}
+
+func TestSignFileTreeOrdering(t *testing.T) {
+	files := map[string]string{
+		"z":       "last",
+		"a":       "first",
+		"m/inner": "middle",
+		"m0":      "after the directory",
+	}
+
+	first, second := t.TempDir(), t.TempDir()
+	writeTree(t, first, []string{"a", "m/inner", "m0", "z"}, files)
+	writeTree(t, second, []string{"z", "m0", "m/inner", "a"}, files)
+
+	a, b := signTree(t, first), signTree(t, second)
+	if a.Root != b.Root {
+		t.Errorf("roots differ with creation order: %s and %s", a.Root, b.Root)
+	}
+	if !reflect.DeepEqual(a.Manifest, b.Manifest) {
+		t.Errorf("manifests differ with creation order: %v and %v", a.Manifest, b.Manifest)
+	}
+	if want := treeRoot(t, "first", "middle", "after the directory", "last"); a.Root != want {
+		t.Errorf("root %s, want %s over the sorted paths", a.Root, want)
+	}
+}
+
+func TestSignFileTreeEmpty(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	res := runCLI(t, "sign-file-tree", "-dir", dir)
+	if res.code != exitInput {
+		t.Errorf("exit %d, want %d: %s", res.code, exitInput, res.stderr)
+	}
+}
+
+func TestSignFileTreeSymlinks(t *testing.T) {
+	outside := t.TempDir()
+	writeTree(t, outside, []string{"linked.txt", "dir/deep.txt"}, map[string]string{
+		"linked.txt":   "linked",
+		"dir/deep.txt": "deep",
+	})
+
+	dir := t.TempDir()
+	writeTree(t, dir, []string{"real.txt"}, map[string]string{"real.txt": "real"})
+	links := map[string]string{
+		"file":   filepath.Join(outside, "linked.txt"),
+		"dir":    filepath.Join(outside, "dir"),
+		"broken": filepath.Join(outside, "missing"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+	}
+
+	skipped := signTree(t, dir)
+	if want := map[string]int{"real.txt": 0}; !reflect.DeepEqual(skipped.Manifest, want) {
+		t.Errorf("without -follow-symlinks: manifest %v, want %v", skipped.Manifest, want)
+	}
+	if want := treeRoot(t, "real"); skipped.Root != want {
+		t.Errorf("without -follow-symlinks: root %s, want %s", skipped.Root, want)
+	}
+
+	followed := signTree(t, dir, "-follow-symlinks")
+	want := map[string]int{"dir/deep.txt": 0, "file": 1, "real.txt": 2}
+	if !reflect.DeepEqual(followed.Manifest, want) {
+		t.Errorf("with -follow-symlinks: manifest %v, want %v", followed.Manifest, want)
+	}
+	if want := treeRoot(t, "deep", "linked", "real"); followed.Root != want {
+		t.Errorf("with -follow-symlinks: root %s, want %s", followed.Root, want)
+	}
+}
+
+func TestSignFileTreeSymlinkCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, []string{"top.txt", "sub/inner.txt"}, map[string]string{
+		"top.txt":       "top",
+		"sub/inner.txt": "inner",
+	})
+	if err := os.Symlink("..", filepath.Join(dir, "sub", "up")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := os.Symlink(".", filepath.Join(dir, "self")); err != nil {
+		t.Fatal(err)
+	}
+
+	out := signTree(t, dir, "-follow-symlinks")
+	want := map[string]int{"sub/inner.txt": 0, "top.txt": 1}
+	if !reflect.DeepEqual(out.Manifest, want) {
+		t.Errorf("manifest %v, want %v", out.Manifest, want)
+	}
+}
@@ -0,0 +1,374 @@
+// Package frost implements t-of-n threshold schnorr signing with FROST
+// and a trusted dealer. Any t holders of a key share can produce a
+// regular BIP340 signature that verifies against the group public key,
+// while fewer than t cannot.
+//
+// Signing takes two rounds. In SignRound1 every participating signer
+// commits to a pair of nonces and shares the commitment. In SignRound2
+// every signer produces a signature share over the full commitment
+// list, and Aggregate sums the shares into the final signature.
+package frost
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/TimeleapLabs/go-schnorr/internal/round"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+var (
+	ErrInvalidThreshold  = errors.New("threshold must satisfy 1 <= t <= n")
+	ErrNotEnoughSigners  = errors.New("not enough signers to meet the threshold")
+	ErrDuplicateSigner   = errors.New("duplicate signer index")
+	ErrSignerNotIncluded = errors.New("signer is not part of the commitment list")
+	ErrNoncesUsed        = errors.New("nonces were already used")
+	ErrWrongCommitment   = errors.New("commitment list does not hold this signer's commitment")
+	ErrMessageSize       = errors.New("message must be a 32-byte digest")
+	ErrInvalidSignature  = errors.New("aggregated signature does not verify against the group key")
+)
+
+var (
+	bindingTag   = []byte("FROST/rho")
+	challengeTag = []byte("BIP0340/challenge")
+)
+
+// KeyShare is one participant's share of the group secret.
+type KeyShare struct {
+	Index     uint32
+	Threshold int
+	Secret    btcec.ModNScalar
+	GroupKey  *btcec.PublicKey
+}
+
+// PublicShare returns the public key of this share, which other
+// participants can use to check signature shares.
+func (s *KeyShare) PublicShare() *btcec.PublicKey {
+	return btcec.PrivKeyFromScalar(&s.Secret).PubKey()
+}
+
+// Commitment is the public part of a signer's round one nonces.
+type Commitment struct {
+	Index uint32
+	D     *btcec.PublicKey
+	E     *btcec.PublicKey
+}
+
+// Nonces holds a signer's secret round one nonces. They must be used
+// for exactly one signature.
+type Nonces struct {
+	d, e       btcec.ModNScalar
+	Commitment Commitment
+}
+
+// SignatureShare is a signer's round two output.
+type SignatureShare struct {
+	Index uint32
+	Z     btcec.ModNScalar
+}
+
+// GenerateShares splits a fresh random group secret into n shares, any
+// t of which can sign. The secret is chosen so the group key has an
+// even Y coordinate, as BIP340 x-only keys require.
+func GenerateShares(t, n int) (*btcec.PublicKey, []*KeyShare, error) {
+	if t < 1 || t > n {
+		return nil, nil, ErrInvalidThreshold
+	}
+
+	coefficients := make([]btcec.ModNScalar, t)
+	for i := range coefficients {
+		scalar, err := randomScalar()
+		if err != nil {
+			return nil, nil, err
+		}
+		coefficients[i] = *scalar
+	}
+
+	groupKey := btcec.PrivKeyFromScalar(&coefficients[0]).PubKey()
+	if isOdd(groupKey) {
+		for i := range coefficients {
+			coefficients[i].Negate()
+		}
+		groupKey = btcec.PrivKeyFromScalar(&coefficients[0]).PubKey()
+	}
+
+	shares := make([]*KeyShare, n)
+	for i := range shares {
+		index := uint32(i + 1)
+
+		var x btcec.ModNScalar
+		x.SetInt(index)
+
+		// Horner evaluation of f(x) = a0 + a1*x + ... + a(t-1)*x^(t-1).
+		var y btcec.ModNScalar
+		for k := t - 1; k >= 0; k-- {
+			y.Mul(&x).Add(&coefficients[k])
+		}
+
+		shares[i] = &KeyShare{
+			Index:     index,
+			Threshold: t,
+			Secret:    y,
+			GroupKey:  groupKey,
+		}
+	}
+
+	for i := range coefficients {
+		coefficients[i].Zero()
+	}
+
+	return groupKey, shares, nil
+}
+
+// SignRound1 generates the nonces for one signing session.
+func SignRound1(share *KeyShare) (*Nonces, error) {
+	d, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+	e, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Nonces{
+		d: *d,
+		e: *e,
+		Commitment: Commitment{
+			Index: share.Index,
+			D:     btcec.PrivKeyFromScalar(d).PubKey(),
+			E:     btcec.PrivKeyFromScalar(e).PubKey(),
+		},
+	}, nil
+}
+
+// SignRound2 produces this signer's share of the signature over msg,
+// given the commitments of every participating signer, its own
+// included. The nonces are wiped afterwards, and signing again with
+// them fails with ErrNoncesUsed: two shares with the same nonces reveal
+// the secret share. So does signing against a commitment other than
+// the one the nonces were made for, which fails with
+// ErrWrongCommitment.
+func SignRound2(share *KeyShare, nonces *Nonces, commitments []Commitment, msg []byte) (*SignatureShare, error) {
+	if len(msg) != 32 {
+		return nil, ErrMessageSize
+	}
+
+	if nonces.d.IsZero() || nonces.e.IsZero() {
+		return nil, ErrNoncesUsed
+	}
+	if nonces.Commitment.Index != share.Index {
+		return nil, fmt.Errorf("%w: nonces are for signer %d, not %d", ErrWrongCommitment, nonces.Commitment.Index, share.Index)
+	}
+
+	if len(commitments) < share.Threshold {
+		return nil, ErrNotEnoughSigners
+	}
+
+	commitments, err := sortCommitments(commitments)
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	for _, c := range commitments {
+		if c.Index != share.Index {
+			continue
+		}
+		found = true
+		if !sameKey(c.D, nonces.Commitment.D) || !sameKey(c.E, nonces.Commitment.E) {
+			return nil, fmt.Errorf("%w: signer %d", ErrWrongCommitment, share.Index)
+		}
+	}
+	if !found {
+		return nil, ErrSignerNotIncluded
+	}
+
+	nonce, rhos, err := groupNonce(share.GroupKey, commitments, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	d, e := nonces.d, nonces.e
+	if isOdd(nonce) {
+		d.Negate()
+		e.Negate()
+	}
+
+	challenge := challenge(nonce, share.GroupKey, msg)
+	lambda := lagrange(share.Index, commitments)
+
+	// z = d + e*rho + lambda*s*c
+	var z btcec.ModNScalar
+	z.Set(&share.Secret).Mul(lambda).Mul(challenge)
+	e.Mul(rhos[share.Index])
+	z.Add(&d).Add(&e)
+
+	nonces.d.Zero()
+	nonces.e.Zero()
+
+	return &SignatureShare{Index: share.Index, Z: z}, nil
+}
+
+// Aggregate combines the signature shares into a BIP340 signature and
+// checks it against the group key.
+func Aggregate(groupKey *btcec.PublicKey, commitments []Commitment, shares []*SignatureShare, msg []byte) (*schnorr.Signature, error) {
+	if len(msg) != 32 {
+		return nil, ErrMessageSize
+	}
+
+	if len(shares) != len(commitments) {
+		return nil, fmt.Errorf("got %d signature shares for %d commitments", len(shares), len(commitments))
+	}
+
+	commitments, err := sortCommitments(commitments)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, _, err := groupNonce(groupKey, commitments, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var z btcec.ModNScalar
+	for _, share := range shares {
+		z.Add(&share.Z)
+	}
+
+	var r btcec.FieldVal
+	r.SetByteSlice(schnorr.SerializePubKey(nonce))
+
+	signature := schnorr.NewSignature(&r, &z)
+	if !signature.Verify(msg, groupKey) {
+		return nil, ErrInvalidSignature
+	}
+
+	return signature, nil
+}
+
+// groupNonce computes the per-signer binding factors and the group
+// nonce R = sum(D_i + rho_i*E_i).
+func groupNonce(groupKey *btcec.PublicKey, commitments []Commitment, msg []byte) (*btcec.PublicKey, map[uint32]*btcec.ModNScalar, error) {
+	var encoded bytes.Buffer
+	for _, c := range commitments {
+		binary.Write(&encoded, binary.BigEndian, c.Index)
+		encoded.Write(c.D.SerializeCompressed())
+		encoded.Write(c.E.SerializeCompressed())
+	}
+
+	var (
+		rhos  = make(map[uint32]*btcec.ModNScalar, len(commitments))
+		total btcec.JacobianPoint
+	)
+
+	for _, c := range commitments {
+		var index [4]byte
+		binary.BigEndian.PutUint32(index[:], c.Index)
+
+		hash := chainhash.TaggedHash(
+			bindingTag, schnorr.SerializePubKey(groupKey), msg, encoded.Bytes(), index[:],
+		)
+		rho := new(btcec.ModNScalar)
+		rho.SetByteSlice(hash[:])
+		rhos[c.Index] = rho
+
+		var d, e btcec.JacobianPoint
+		c.D.AsJacobian(&d)
+		c.E.AsJacobian(&e)
+
+		btcec.ScalarMultNonConst(rho, &e, &e)
+		btcec.AddNonConst(&d, &e, &d)
+		btcec.AddNonConst(&total, &d, &total)
+	}
+
+	if total == (btcec.JacobianPoint{}) || total.Z.IsZero() {
+		return nil, nil, errors.New("group nonce is the point at infinity")
+	}
+
+	total.ToAffine()
+	return btcec.NewPublicKey(&total.X, &total.Y), rhos, nil
+}
+
+// challenge computes the BIP340 challenge e = H(R || P || m).
+func challenge(nonce, groupKey *btcec.PublicKey, msg []byte) *btcec.ModNScalar {
+	hash := chainhash.TaggedHash(
+		challengeTag, schnorr.SerializePubKey(nonce), schnorr.SerializePubKey(groupKey), msg,
+	)
+
+	c := new(btcec.ModNScalar)
+	c.SetByteSlice(hash[:])
+	return c
+}
+
+// lagrange returns the Lagrange coefficient of signer i at zero over the
+// participating signers.
+func lagrange(i uint32, commitments []Commitment) *btcec.ModNScalar {
+	var xi, num, den btcec.ModNScalar
+	xi.SetInt(i)
+	num.SetInt(1)
+	den.SetInt(1)
+
+	for _, c := range commitments {
+		if c.Index == i {
+			continue
+		}
+
+		var xj, diff btcec.ModNScalar
+		xj.SetInt(c.Index)
+		diff.NegateVal(&xi).Add(&xj)
+
+		num.Mul(&xj)
+		den.Mul(&diff)
+	}
+
+	return num.Mul(den.InverseNonConst())
+}
+
+func sortCommitments(commitments []Commitment) ([]Commitment, error) {
+	sorted := append([]Commitment(nil), commitments...)
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a].Index < sorted[b].Index })
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Index == sorted[i-1].Index {
+			return nil, ErrDuplicateSigner
+		}
+	}
+
+	return sorted, nil
+}
+
+func randomScalar() (*btcec.ModNScalar, error) {
+	priv, err := btcec.NewPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return &priv.Key, nil
+}
+
+// sameKey reports whether a and b are both set and the same point.
+func sameKey(a, b *btcec.PublicKey) bool {
+	return a != nil && b != nil && a.IsEqual(b)
+}
+
+func isOdd(pub *btcec.PublicKey) bool {
+	return pub.SerializeCompressed()[0] == 0x03
+}
+
+// CollectCommitments waits for n round one commitments on ch, giving up
+// when ctx is done.
+func CollectCommitments(ctx context.Context, n int, ch <-chan Commitment) ([]Commitment, error) {
+	return round.Collect(ctx, n, ch)
+}
+
+// CollectShares waits for n signature shares on ch, giving up when ctx
+// is done.
+func CollectShares(ctx context.Context, n int, ch <-chan *SignatureShare) ([]*SignatureShare, error) {
+	return round.Collect(ctx, n, ch)
+}
@@ -0,0 +1,183 @@
+package frost
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// subsets returns every subset of size k of shares.
+func subsets(shares []*KeyShare, k int) [][]*KeyShare {
+	if k == 0 {
+		return [][]*KeyShare{nil}
+	}
+	if len(shares) < k {
+		return nil
+	}
+
+	var out [][]*KeyShare
+	for _, rest := range subsets(shares[1:], k-1) {
+		out = append(out, append([]*KeyShare{shares[0]}, rest...))
+	}
+	return append(out, subsets(shares[1:], k)...)
+}
+
+// round1 runs SignRound1 for every signer.
+func round1(t *testing.T, signers []*KeyShare) ([]*Nonces, []Commitment) {
+	t.Helper()
+
+	nonces := make([]*Nonces, len(signers))
+	commitments := make([]Commitment, len(signers))
+	for i, share := range signers {
+		n, err := SignRound1(share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonces[i] = n
+		commitments[i] = n.Commitment
+	}
+	return nonces, commitments
+}
+
+// sign runs both rounds for signers and aggregates the result.
+func sign(t *testing.T, groupKey *btcec.PublicKey, signers []*KeyShare, msg []byte) (*schnorr.Signature, error) {
+	t.Helper()
+
+	nonces, commitments := round1(t, signers)
+
+	shares := make([]*SignatureShare, len(signers))
+	for i, share := range signers {
+		sigShare, err := SignRound2(share, nonces[i], commitments, msg)
+		if err != nil {
+			return nil, err
+		}
+		shares[i] = sigShare
+	}
+
+	return Aggregate(groupKey, commitments, shares, msg)
+}
+
+func TestAnyThresholdSubsetSigns(t *testing.T) {
+	const threshold, total = 3, 5
+
+	groupKey, shares, err := GenerateShares(threshold, total)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := sha256.Sum256([]byte("3 of 5"))
+
+	for _, signers := range subsets(shares, threshold) {
+		sig, err := sign(t, groupKey, signers, msg[:])
+		if err != nil {
+			t.Fatalf("signers %v: %v", indices(signers), err)
+		}
+		if !sig.Verify(msg[:], groupKey) {
+			t.Errorf("signers %v: signature does not verify", indices(signers))
+		}
+	}
+
+	// More than t signers may sign too.
+	if _, err := sign(t, groupKey, shares, msg[:]); err != nil {
+		t.Errorf("all %d signers: %v", total, err)
+	}
+}
+
+func TestFewerThanThresholdCannotSign(t *testing.T) {
+	const threshold, total = 3, 5
+
+	groupKey, shares, err := GenerateShares(threshold, total)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := sha256.Sum256([]byte("2 of 5"))
+
+	for _, signers := range subsets(shares, threshold-1) {
+		if _, err := sign(t, groupKey, signers, msg[:]); !errors.Is(err, ErrNotEnoughSigners) {
+			t.Errorf("signers %v: got %v, want ErrNotEnoughSigners", indices(signers), err)
+		}
+	}
+
+	// Shares that claim a lower threshold still cannot produce a valid
+	// signature: t-1 points do not determine the degree t-1 polynomial.
+	signers := shares[:threshold-1]
+	for _, share := range signers {
+		share.Threshold = threshold - 1
+	}
+	if _, err := sign(t, groupKey, signers, msg[:]); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("t-1 signers with a lowered threshold: got %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestSignRound2RejectsReusedNonces(t *testing.T) {
+	_, shares, err := GenerateShares(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := shares[:2]
+	nonces, commitments := round1(t, signers)
+
+	first := sha256.Sum256([]byte("first"))
+	if _, err := SignRound2(signers[0], nonces[0], commitments, first[:]); err != nil {
+		t.Fatal(err)
+	}
+
+	second := sha256.Sum256([]byte("second"))
+	if _, err := SignRound2(signers[0], nonces[0], commitments, second[:]); !errors.Is(err, ErrNoncesUsed) {
+		t.Errorf("second use of nonces: got %v, want ErrNoncesUsed", err)
+	}
+}
+
+func TestSignRound2RejectsWrongCommitment(t *testing.T) {
+	_, shares, err := GenerateShares(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := shares[:2]
+	nonces, commitments := round1(t, signers)
+	msg := sha256.Sum256([]byte("swapped"))
+
+	// The coordinator lists a different commitment for signer 0.
+	other, err := SignRound1(signers[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapped := []Commitment{other.Commitment, commitments[1]}
+	if _, err := SignRound2(signers[0], nonces[0], swapped, msg[:]); !errors.Is(err, ErrWrongCommitment) {
+		t.Errorf("swapped commitment: got %v, want ErrWrongCommitment", err)
+	}
+
+	// Nonces made for another signer.
+	if _, err := SignRound2(signers[1], nonces[0], commitments, msg[:]); !errors.Is(err, ErrWrongCommitment) {
+		t.Errorf("another signer's nonces: got %v, want ErrWrongCommitment", err)
+	}
+
+	// The signer's own commitment must be listed.
+	_, outsiders := round1(t, shares[1:])
+	if _, err := SignRound2(signers[0], nonces[0], outsiders, msg[:]); !errors.Is(err, ErrSignerNotIncluded) {
+		t.Errorf("signer left out: got %v, want ErrSignerNotIncluded", err)
+	}
+
+	// A rejected attempt does not use up the nonces.
+	if _, err := SignRound2(signers[0], nonces[0], commitments, msg[:]); err != nil {
+		t.Errorf("signing after a rejected attempt: %v", err)
+	}
+}
+
+func TestGenerateSharesRejectsBadThreshold(t *testing.T) {
+	for _, tt := range []struct{ t, n int }{{0, 3}, {4, 3}, {-1, 1}} {
+		if _, _, err := GenerateShares(tt.t, tt.n); !errors.Is(err, ErrInvalidThreshold) {
+			t.Errorf("GenerateShares(%d, %d): got %v, want ErrInvalidThreshold", tt.t, tt.n, err)
+		}
+	}
+}
+
+func indices(shares []*KeyShare) []uint32 {
+	out := make([]uint32, len(shares))
+	for i, share := range shares {
+		out[i] = share.Index
+	}
+	return out
+}
@@ -0,0 +1,38 @@
+// Command genfixtures regenerates vectors/fixtures.json from the signing
+// vectors. Run it from the module root after an intentional change to
+// hashing or encoding and commit the resulting diff:
+//
+//	go run ./genfixtures
+//
+// Signatures use deterministic nonces, so the output is the same on
+// every run and platform.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/TimeleapLabs/go-schnorr/vectors"
+)
+
+func main() {
+	out := flag.String("out", "vectors/fixtures.json", "file to write the fixtures to")
+	flag.Parse()
+
+	fixtures, err := vectors.Fixtures()
+	if err != nil {
+		log.Fatalf("Error computing fixtures: %v", err)
+	}
+
+	data, err := vectors.MarshalFixtures(fixtures)
+	if err != nil {
+		log.Fatalf("Error encoding fixtures: %v", err)
+	}
+
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		log.Fatalf("Error writing fixtures: %v", err)
+	}
+
+	log.Printf("Wrote %d fixtures to %s", len(fixtures), *out)
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TimeleapLabs/go-schnorr/eip712"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+type inputFlags struct {
+	message     *string
+	messageFile *string
+	fields      *fieldList
+	stdin       *bool
+	stream      *bool
+	hashOnly    *bool
+	domain      *string
+	nonce       *string
+	timestamp   *string
+	canonical   *bool
+	hash        *hashFlags
+
+	// now is the time source for -timestamp now. The caller sets it,
+	// so a test can fix the time.
+	now func() time.Time
+
+	// signedAt caches the -timestamp value, so "now" is read once and
+	// the time printed is the time signed.
+	signedAt *time.Time
+}
+
+// fieldList collects the values of a repeated -fields flag.
+type fieldList []string
+
+func (f *fieldList) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *fieldList) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+type hashFlags struct {
+	name *string
+	tag  *string
+}
+
+func addHashFlags(flags *flag.FlagSet) *hashFlags {
+	return &hashFlags{
+		name: flags.String("hash", signer.HashKeccak256, "message hash: keccak256, sha256 or bip340"),
+		tag:  flags.String("hash-tag", "", "tag for the bip340 tagged hash"),
+	}
+}
+
+// newHasher returns a fresh hasher for the -hash flags.
+func (h *hashFlags) newHasher() (signer.Hasher, error) {
+	if *h.tag != "" && *h.name != signer.HashBIP340 {
+		return nil, usageError("-hash-tag is only used with -hash bip340")
+	}
+	return signer.NewHasher(*h.name, []byte(*h.tag))
+}
+
+func (h *hashFlags) isDefault() bool {
+	return *h.name == signer.HashKeccak256 && *h.tag == ""
+}
+
+func addInputFlags(flags *flag.FlagSet) *inputFlags {
+	fields := &fieldList{}
+	flags.Var(fields, "fields", "repeatable message field, hex (0x-prefixed) or raw; fields are length-prefixed before hashing")
+
+	return &inputFlags{
+		message:     flags.String("message", "", "message to sign"),
+		messageFile: flags.String("message-file", "", "read the message from a file"),
+		fields:      fields,
+		stdin:       flags.Bool("stdin", false, "read the message from stdin until EOF"),
+		stream:      flags.Bool("stream", false, "hash -message-file or -stdin in chunks as it is read instead of loading it into memory"),
+		hashOnly:    flags.Bool("hash-only", false, "treat the hex input as an already hashed 32-byte digest"),
+		domain:      flags.String("domain", "", "domain tag to hash the message under, hash(hash(domain) || message)"),
+		nonce:       flags.String("nonce", "", "replay-protection nonce, a uint256 prefixed to the message before hashing"),
+		timestamp:   flags.String("timestamp", "", "sign the time with the message: now, unix seconds or RFC 3339"),
+		canonical:   flags.Bool("canonical-json", false, "the message is JSON: sort its keys and strip whitespace before hashing"),
+		hash:        addHashFlags(flags),
+	}
+}
+
+// sources returns how many message sources were given.
+func (in *inputFlags) sources() int {
+	sources := 0
+	if *in.message != "" {
+		sources++
+	}
+	if *in.messageFile != "" {
+		sources++
+	}
+	if *in.stdin {
+		sources++
+	}
+	if len(*in.fields) > 0 {
+		sources++
+	}
+	return sources
+}
+
+// read returns the raw message bytes from whichever source was given.
+// Exactly one source must be set.
+func (in *inputFlags) read() ([]byte, error) {
+	switch sources := in.sources(); {
+	case sources == 0:
+		return nil, usageError("no message given, use one of -message, -message-file, -stdin or -fields")
+	case sources > 1:
+		return nil, usageError("only one of -message, -message-file, -stdin or -fields may be used")
+	}
+
+	if *in.canonical {
+		return in.readCanonical()
+	}
+
+	switch {
+	case len(*in.fields) > 0:
+		return in.encodeFields()
+	case *in.messageFile != "":
+		return os.ReadFile(*in.messageFile)
+	case *in.stdin:
+		return io.ReadAll(os.Stdin)
+	default:
+		return []byte(*in.message), nil
+	}
+}
+
+// readCanonical reads a JSON message for -canonical-json and re-encodes
+// it with signer.CanonicalJSON, so the signer and the verifier hash the
+// same bytes however each of them serialized the object.
+func (in *inputFlags) readCanonical() ([]byte, error) {
+	if *in.hashOnly || len(*in.fields) > 0 {
+		return nil, usageError("-canonical-json cannot be combined with -hash-only or -fields")
+	}
+
+	var (
+		message []byte
+		err     error
+	)
+	switch {
+	case *in.messageFile != "":
+		message, err = os.ReadFile(*in.messageFile)
+	case *in.stdin:
+		message, err = io.ReadAll(os.Stdin)
+	default:
+		message = []byte(*in.message)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(message) {
+		return nil, fmt.Errorf("%w: -canonical-json message is not valid JSON", signer.ErrDecodeMessage)
+	}
+
+	return signer.CanonicalJSON(json.RawMessage(message))
+}
+
+// encodeFields decodes the -fields values and length-prefixes them with
+// signer.EncodeFields.
+func (in *inputFlags) encodeFields() ([]byte, error) {
+	if *in.hashOnly {
+		return nil, usageError("-fields cannot be combined with -hash-only")
+	}
+
+	fields := make([][]byte, len(*in.fields))
+	for i, value := range *in.fields {
+		if !strings.HasPrefix(value, "0x") {
+			fields[i] = []byte(value)
+			continue
+		}
+
+		field, err := signer.DecodeHex(value)
+		if err != nil {
+			return nil, fmt.Errorf("field %d: %w", i, err)
+		}
+		fields[i] = field
+	}
+
+	return signer.EncodeFields(fields...), nil
+}
+
+// domainTag returns the -domain tag, or nil if none was given.
+func (in *inputFlags) domainTag() ([]byte, error) {
+	if *in.domain == "" {
+		return nil, nil
+	}
+	if *in.hashOnly {
+		return nil, usageError("-domain cannot be combined with -hash-only")
+	}
+	return []byte(*in.domain), nil
+}
+
+// nonceValue returns the -nonce value, decimal or 0x hex, or nil if
+// none was given.
+func (in *inputFlags) nonceValue() (*big.Int, error) {
+	if *in.nonce == "" {
+		return nil, nil
+	}
+	if *in.hashOnly {
+		return nil, usageError("-nonce cannot be combined with -hash-only")
+	}
+
+	nonce, ok := new(big.Int).SetString(*in.nonce, 0)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not a number", signer.ErrInvalidNonce, *in.nonce)
+	}
+	return nonce, nil
+}
+
+// timestampValue returns the -timestamp time, or nil if none was given.
+func (in *inputFlags) timestampValue() (*time.Time, error) {
+	if *in.timestamp == "" || in.signedAt != nil {
+		return in.signedAt, nil
+	}
+	if *in.hashOnly {
+		return nil, usageError("-timestamp cannot be combined with -hash-only")
+	}
+
+	var t time.Time
+	if *in.timestamp == "now" {
+		t = in.now()
+	} else if seconds, err := strconv.ParseInt(*in.timestamp, 10, 64); err == nil {
+		t = time.Unix(seconds, 0)
+	} else if t, err = time.Parse(time.RFC3339, *in.timestamp); err != nil {
+		return nil, fmt.Errorf("%w: %q is not now, unix seconds or RFC 3339", signer.ErrInvalidTimestamp, *in.timestamp)
+	}
+
+	t = t.Truncate(time.Second)
+	in.signedAt = &t
+	return in.signedAt, nil
+}
+
+// digest returns the 32-byte hash to sign or verify. With -hash-only
+// the input is decoded as hex and used as is, otherwise it is wrapped
+// with the -timestamp and prefixed with the -nonce if given and hashed
+// with -hash, under the -domain tag if one was given. With -stream the
+// input is hashed as it is read.
+func (in *inputFlags) digest() ([]byte, error) {
+	hasher, err := in.hash.newHasher()
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := in.domainTag()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := in.nonceValue()
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := in.timestampValue()
+	if err != nil {
+		return nil, err
+	}
+
+	if *in.stream {
+		if timestamp != nil || *in.canonical {
+			return nil, usageError("-stream cannot be combined with -timestamp or -canonical-json")
+		}
+		return in.streamDigest(domain, nonce)
+	}
+
+	message, err := in.read()
+	if err != nil {
+		return nil, err
+	}
+
+	if *in.hashOnly {
+		return signer.DecodeHash(string(message))
+	}
+
+	if timestamp != nil {
+		message, err = signer.EncodeWithTimestamp(*timestamp, message)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if nonce != nil {
+		message, err = signer.EncodeWithNonce(nonce, message)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if domain != nil {
+		return signer.HashWithDomain(hasher, domain, message), nil
+	}
+
+	return hasher.Hash(message), nil
+}
+
+// streamDigest is digest for -stream: the file or stdin is hashed as
+// it is read, with the nonce written ahead of it.
+func (in *inputFlags) streamDigest(domain []byte, nonce *big.Int) ([]byte, error) {
+	if *in.hashOnly {
+		return nil, usageError("-stream cannot be combined with -hash-only")
+	}
+	if in.sources() != 1 || *in.messageFile == "" && !*in.stdin {
+		return nil, usageError("-stream needs exactly one of -message-file or -stdin")
+	}
+
+	var r io.Reader = os.Stdin
+	if *in.messageFile != "" {
+		file, err := os.Open(*in.messageFile)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	if nonce != nil {
+		prefix, err := signer.EncodeWithNonce(nonce, nil)
+		if err != nil {
+			return nil, err
+		}
+		r = io.MultiReader(bytes.NewReader(prefix), r)
+	}
+
+	return signer.DigestReader(*in.hash.name, []byte(*in.hash.tag), domain, r)
+}
+
+// readTypedData returns the EIP-712 digest of a typed data file.
+func readTypedData(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	typed, err := eip712.ParseTypedData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return typed.Digest()
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// -stream must sign the same digest as reading the file all at once.
+func TestStreamMatchesMessageFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.bin")
+	if err := os.WriteFile(path, []byte(strings.Repeat("dataset row\n", 50000)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		extra []string
+	}{
+		{"plain", nil},
+		{"nonce", []string{"-nonce", "7"}},
+		{"domain", []string{"-domain", "feeds"}},
+		{"sha256", []string{"-hash", "sha256"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"sign", "-message-file", path, "-output", "json"}, tt.extra...)
+
+			var hashes [2]string
+			for i, stream := range []bool{false, true} {
+				args := args
+				if stream {
+					args = append(args[:len(args):len(args)], "-stream")
+				}
+				res := runCLI(t, args...)
+				if res.code != 0 {
+					t.Fatalf("exit %d: %s", res.code, res.stderr)
+				}
+				var out signOutput
+				if err := json.Unmarshal([]byte(res.stdout), &out); err != nil {
+					t.Fatal(err)
+				}
+				hashes[i] = out.MessageHash
+			}
+			if hashes[0] != hashes[1] {
+				t.Errorf("streamed digest %s, want %s", hashes[1], hashes[0])
+			}
+		})
+	}
+}
+
+func TestStreamRejects(t *testing.T) {
+	tests := [][]string{
+		{"sign", "-message", "hello", "-stream"},
+		{"sign", "-message-file", "x", "-hash-only", "-stream"},
+	}
+	for _, args := range tests {
+		if res := runCLI(t, args...); res.code != exitUsage {
+			t.Errorf("%v: exit %d, want %d", args, res.code, exitUsage)
+		}
+	}
+}
+
+func TestCanonicalJSONSigning(t *testing.T) {
+	first := `{"feed": "BTC/USD", "price": 65000}`
+	second := "{\n  \"price\": 65000,\n  \"feed\": \"BTC/USD\"\n}"
+
+	a := signJSON(t, first, "-canonical-json", "-deterministic")
+	b := signJSON(t, second, "-canonical-json", "-deterministic")
+	if a.Signature != b.Signature || a.MessageHash != b.MessageHash {
+		t.Fatalf("two orderings signed differently: %s and %s", a.Signature, b.Signature)
+	}
+
+	verify := func(message string, extra ...string) int {
+		args := append([]string{"verify", "-message", message, "-pubkey", testPubKey, "-signature", a.Signature}, extra...)
+		return cliRun{}.run(t, args...).code
+	}
+	if code := verify(second, "-canonical-json"); code != 0 {
+		t.Errorf("reordered message with -canonical-json: exit %d", code)
+	}
+	if code := verify(second); code != exitVerify {
+		t.Errorf("reordered message without -canonical-json: exit %d, want %d", code, exitVerify)
+	}
+
+	res := runCLI(t, "sign", "-message", `{"feed": }`, "-canonical-json")
+	if res.code != exitInput {
+		t.Errorf("invalid JSON: exit %d, want %d: %s", res.code, exitInput, res.stderr)
+	}
+}
+
+// -timestamp now reads the caller's clock once, so the time printed is
+// the time signed, and signs the same digest as that time given in
+// seconds.
+func TestTimestampNow(t *testing.T) {
+	parse := func(args ...string) *inputFlags {
+		flags := flag.NewFlagSet("sign", flag.ContinueOnError)
+		input := addInputFlags(flags)
+		if err := flags.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		return input
+	}
+
+	at := time.Unix(1700000000, 500)
+	calls := 0
+	now := parse("-message", "hello", "-timestamp", "now")
+	now.now = func() time.Time {
+		calls++
+		return at
+	}
+
+	got, err := now.digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedAt, err := now.timestampValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("clock read %d times, want once", calls)
+	}
+	if !signedAt.Equal(at.Truncate(time.Second)) {
+		t.Errorf("signed at %v, want %v", signedAt, at.Truncate(time.Second))
+	}
+
+	want, err := parse("-message", "hello", "-timestamp", "1700000000").digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("digest %x, want %x as with -timestamp 1700000000", got, want)
+	}
+}
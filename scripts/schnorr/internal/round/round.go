@@ -0,0 +1,28 @@
+// Package round collects the messages of one protocol round from the
+// other participants.
+package round
+
+import (
+	"context"
+	"fmt"
+)
+
+// Collect reads n values from ch. It returns early with an error if ctx
+// is done before all values arrive or if ch is closed.
+func Collect[T any](ctx context.Context, n int, ch <-chan T) ([]T, error) {
+	values := make([]T, 0, n)
+
+	for len(values) < n {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("round aborted after %d of %d messages: %w", len(values), n, ctx.Err())
+		case value, ok := <-ch:
+			if !ok {
+				return nil, fmt.Errorf("round channel closed after %d of %d messages", len(values), n)
+			}
+			values = append(values, value)
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/TimeleapLabs/go-schnorr/keystore"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/joho/godotenv"
+	"golang.org/x/term"
+)
+
+type keyFlags struct {
+	keystore    *string
+	keystoreDir *string
+	keyName     *string
+	keyCmd      *string
+	key         *string
+}
+
+func addKeyFlags(flags *flag.FlagSet) *keyFlags {
+	return &keyFlags{
+		keystore:    flags.String("keystore", "", "load the key from an encrypted keystore"),
+		keystoreDir: flags.String("keystore-dir", "", "directory of named keystores to pick -key-name from"),
+		keyName:     flags.String("key-name", "", "load the keystore with this name from -keystore-dir"),
+		keyCmd:      flags.String("key-cmd", "", "run this shell command and read the hex private key from its stdout"),
+		key:         flags.String("key", "", "hex private key, overrides SCHNORR_KEY"),
+	}
+}
+
+// load returns the signing key. A keystore, given by path or by name in
+// -keystore-dir, takes precedence, then -key-cmd, then the -key flag,
+// then SCHNORR_KEY from the environment or a .env file.
+func (k *keyFlags) load() (*btcec.PrivateKey, *btcec.PublicKey) {
+	path := *k.keystore
+	if *k.keyName != "" || *k.keystoreDir != "" {
+		if *k.keyName == "" || *k.keystoreDir == "" {
+			fatal(usageError("-key-name and -keystore-dir must be used together"))
+		}
+		if path != "" {
+			fatal(usageError("-key-name cannot be combined with -keystore"))
+		}
+
+		var err error
+		path, err = keystore.Find(*k.keystoreDir, *k.keyName)
+		if err != nil {
+			fatal(keyError("Error selecting key: %w", err))
+		}
+	}
+
+	if path != "" {
+		passphrase, err := readPassphrase("Passphrase: ")
+		if err != nil {
+			fatal(keyError("Error reading passphrase: %w", err))
+		}
+
+		privateKey, err := keystore.LoadKeystore(path, passphrase)
+		clear(passphrase)
+		if err != nil {
+			fatal(keyError("Error loading keystore: %w", err))
+		}
+
+		return privateKey, privateKey.PubKey()
+	}
+
+	if *k.keyCmd != "" {
+		privateKey, err := readKeyCommand(*k.keyCmd)
+		if err != nil {
+			fatal(keyError("Error loading key from -key-cmd: %w", err))
+		}
+
+		return privateKey, privateKey.PubKey()
+	}
+
+	keyHex := *k.key
+	if keyHex == "" {
+		// A missing .env is fine as long as the key is already in the
+		// environment, as is usual in containers and CI.
+		err := godotenv.Load()
+		keyHex = os.Getenv("SCHNORR_KEY")
+		if keyHex == "" {
+			if err != nil {
+				fatal(keyError("No .env file found and SCHNORR_KEY is not set"))
+			}
+			fatal(keyError("SCHNORR_KEY is not set"))
+		}
+	}
+
+	privateKey, publicKey, err := signer.LoadKeyFromHex(keyHex)
+	if err != nil {
+		fatal(keyError("Error decoding schnorr key: %w", err))
+	}
+
+	return privateKey, publicKey
+}
+
+// zeroSigner wipes the key held by s, if it holds one in memory.
+func zeroSigner(s signer.Signer) {
+	if z, ok := s.(interface{ Zero() }); ok {
+		z.Zero()
+	}
+}
+
+// newSigner wraps priv in the Signer the subcommands sign through.
+func newSigner(priv *btcec.PrivateKey, deterministic bool) signer.Signer {
+	if deterministic {
+		return signer.NewDeterministicSigner(priv)
+	}
+	return signer.NewKeySigner(priv)
+}
+
+// readKeyCommand runs command through sh and parses its stdout as a hex
+// private key, so the key never has to be written to disk. Stdin and
+// stderr are passed through for commands that prompt. The output is
+// zeroed once parsed and never included in errors.
+func readKeyCommand(command string) (*btcec.PrivateKey, error) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	defer clear(out)
+	if err != nil {
+		return nil, err
+	}
+
+	keyHex := bytes.TrimPrefix(bytes.TrimSpace(out), []byte("0x"))
+	keyBytes := make([]byte, hex.DecodedLen(len(keyHex)))
+	defer clear(keyBytes)
+	if _, err := hex.Decode(keyBytes, keyHex); err != nil {
+		return nil, fmt.Errorf("%w: command output is not hex", signer.ErrInvalidKey)
+	}
+
+	return signer.PrivateKeyFromBytes(keyBytes)
+}
+
+// readPassphrase prompts on stderr and reads a passphrase from the
+// terminal without echoing it.
+func readPassphrase(prompt string) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, errors.New("stdin is not a terminal")
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+
+	return passphrase, err
+}
+
+// readNewPassphrase prompts twice and makes sure both entries match.
+func readNewPassphrase() ([]byte, error) {
+	passphrase, err := readPassphrase("New passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+
+	confirm, err := readPassphrase("Repeat passphrase: ")
+	if err != nil {
+		clear(passphrase)
+		return nil, err
+	}
+	defer clear(confirm)
+
+	if !bytes.Equal(passphrase, confirm) {
+		clear(passphrase)
+		return nil, errors.New("passphrases do not match")
+	}
+
+	return passphrase, nil
+}
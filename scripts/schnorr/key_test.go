@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// mockSigner stands in for a key the tests never see, such as one held
+// by an HSM. It returns a canned signature for every hash, or err, and
+// records the hashes it was asked to sign.
+type mockSigner struct {
+	publicKey *btcec.PublicKey
+	signature *schnorr.Signature
+	err       error
+
+	mu     sync.Mutex
+	hashes [][]byte
+	zeroed bool
+}
+
+func newMockSigner(t testing.TB) *mockSigner {
+	t.Helper()
+	pub, err := signer.ParsePublicKey("0x1b84c5567b126440995d3ed5aaba0565d71e1834604819ff9c17f5e9d5dd078f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signer.ParseSignature("0x" + strings.Repeat("11", 32) + strings.Repeat("22", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &mockSigner{publicKey: pub, signature: sig}
+}
+
+func (m *mockSigner) Sign(hash []byte) (*schnorr.Signature, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hashes = append(m.hashes, append([]byte(nil), hash...))
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.signature, nil
+}
+
+func (m *mockSigner) PublicKey() *btcec.PublicKey {
+	return m.publicKey
+}
+
+func (m *mockSigner) Zero() {
+	m.zeroed = true
+}
+
+func TestSignAllThroughSigner(t *testing.T) {
+	mock := newMockSigner(t)
+	hashes := make([][]byte, 20)
+	for i := range hashes {
+		hashes[i] = []byte{byte(i)}
+	}
+
+	signatures, err := signAll(mock, hashes, 4, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signatures) != len(hashes) {
+		t.Fatalf("%d signatures, want %d", len(signatures), len(hashes))
+	}
+	for i, sig := range signatures {
+		if sig != mock.signature {
+			t.Errorf("signature %d is not the signer's", i)
+		}
+	}
+
+	seen := make(map[byte]bool)
+	for _, hash := range mock.hashes {
+		seen[hash[0]] = true
+	}
+	if len(mock.hashes) != len(hashes) || len(seen) != len(hashes) {
+		t.Errorf("signer was asked for %d hashes, %d distinct, want %d", len(mock.hashes), len(seen), len(hashes))
+	}
+}
+
+func TestSignAllSignerError(t *testing.T) {
+	mock := newMockSigner(t)
+	mock.err = errors.New("device unplugged")
+
+	_, err := signAll(mock, [][]byte{{1}, {2}}, 2, nil)
+	if !errors.Is(err, mock.err) {
+		t.Errorf("got %v, want the signer's error", err)
+	}
+}
+
+func TestServerReplyThroughSigner(t *testing.T) {
+	mock := newMockSigner(t)
+	name, tag := signer.HashKeccak256, ""
+	s := &server{
+		signer:     mock,
+		format:     signer.PubKeyXOnly,
+		layout:     signer.SigLayoutRS,
+		hash:       &hashFlags{name: &name, tag: &tag},
+		hashOnly:   true,
+		maxMessage: 1 << 10,
+		inFlight:   make(chan struct{}, 1),
+	}
+	hasher, err := s.hash.newHasher()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := "0x" + strings.Repeat("ab", 32)
+	reply := s.reply(hasher, []byte(digest))
+	if reply.Error != "" {
+		t.Fatal(reply.Error)
+	}
+	if reply.MessageHash != digest {
+		t.Errorf("message hash is %s, want %s", reply.MessageHash, digest)
+	}
+	if want := "0x" + strings.Repeat("11", 32) + strings.Repeat("22", 32); reply.Signature != want {
+		t.Errorf("signature is %s, want the signer's %s", reply.Signature, want)
+	}
+	if reply.PublicKey != "0x1b84c5567b126440995d3ed5aaba0565d71e1834604819ff9c17f5e9d5dd078f" {
+		t.Errorf("public key is %s, want the signer's", reply.PublicKey)
+	}
+
+	mock.err = errors.New("remote signer timed out")
+	if reply := s.reply(hasher, []byte(digest)); reply.Error != mock.err.Error() || reply.signOutput != nil {
+		t.Errorf("failed sign replied %+v, want only the signer's error", reply)
+	}
+}
+
+func TestZeroSigner(t *testing.T) {
+	mock := newMockSigner(t)
+	zeroSigner(mock)
+	if !mock.zeroed {
+		t.Error("zeroSigner did not zero the signer")
+	}
+}
+
+func TestReadKeyCommand(t *testing.T) {
+	want := strings.Repeat("01", 32)
+	for _, command := range []string{
+		"echo " + want,
+		"echo 0x" + want,
+		"printf '  0x%s\\n\\n' " + want,
+	} {
+		priv, err := readKeyCommand(command)
+		if err != nil {
+			t.Fatalf("%s: %v", command, err)
+		}
+		if got := hex.EncodeToString(priv.Serialize()); got != want {
+			t.Errorf("%s: loaded key %s, want %s", command, got, want)
+		}
+	}
+}
+
+func TestReadKeyCommandRejects(t *testing.T) {
+	secret := strings.Repeat("ab", 31) + "zz"
+	tests := []struct {
+		name    string
+		command string
+	}{
+		{"not hex", "echo " + secret},
+		{"short", "echo " + strings.Repeat("01", 31)},
+		{"zero", "echo " + strings.Repeat("00", 32)},
+		{"no output", "true"},
+		{"command fails", "echo " + strings.Repeat("01", 32) + "; exit 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readKeyCommand(tt.command)
+			if err == nil {
+				t.Fatal("loaded a key")
+			}
+			if strings.Contains(err.Error(), secret) || strings.Contains(err.Error(), strings.Repeat("01", 31)) {
+				t.Errorf("error includes the command output: %v", err)
+			}
+		})
+	}
+}
+
+func TestSignWithKeyCommand(t *testing.T) {
+	res := cliRun{}.run(t, "sign", "-message", "hello", "-key-cmd", "echo "+testKeyHex)
+	if res.code != 0 {
+		t.Fatalf("exit %d: %s", res.code, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "Public key: "+testPubKey) {
+		t.Errorf("signed with another key:\n%s", res.stdout)
+	}
+
+	if res := (cliRun{}).run(t, "sign", "-message", "hello", "-key-cmd", "exit 1"); res.code != exitKey {
+		t.Errorf("failing -key-cmd: exit %d, want %d: %s", res.code, exitKey, res.stderr)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/TimeleapLabs/go-schnorr/keystore"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// keygen creates a new keypair. btcec.NewPrivateKey draws its
+// randomness from crypto/rand.
+func keygen(args []string) {
+	flags := flag.NewFlagSet("keygen", flag.ExitOnError)
+	out := flags.String("out", "", "write the keypair to a .env-style file")
+	force := flags.Bool("force", false, "overwrite the output file if it exists")
+	keystorePath := flags.String("keystore", "", "write the key to an encrypted keystore instead")
+	keystoreDir := flags.String("keystore-dir", "", "write the key to an encrypted keystore named -key-name in this directory")
+	keyName := flags.String("key-name", "", "name of the key in -keystore-dir")
+	parseFlags(flags, args)
+
+	if (*keystoreDir == "") != (*keyName == "") {
+		fatal(usageError("-key-name and -keystore-dir must be used together"))
+	}
+	if *keystoreDir != "" {
+		if *keystorePath != "" {
+			fatal(usageError("-key-name cannot be combined with -keystore"))
+		}
+
+		path, err := keystore.EntryPath(*keystoreDir, *keyName)
+		if err != nil {
+			fatal(usageError("Error parsing -key-name: %w", err))
+		}
+		if err := os.MkdirAll(*keystoreDir, 0o700); err != nil {
+			fatal(fmt.Errorf("Error creating keystore directory: %w", err))
+		}
+		*keystorePath = path
+	}
+
+	privateKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		fatal(keyError("Error generating private key: %w", err))
+	}
+
+	privateKeyHex := fmt.Sprintf("%x", privateKey.Serialize())
+	publicKeyHex := fmt.Sprintf("0x%x", signer.XOnlyPubKey(privateKey.PubKey()))
+
+	if *keystorePath != "" {
+		passphrase, err := readNewPassphrase()
+		if err != nil {
+			fatal(keyError("Error reading passphrase: %w", err))
+		}
+
+		err = keystore.SaveKeystore(*keystorePath, privateKey, passphrase)
+		clear(passphrase)
+		if err != nil {
+			fatal(fmt.Errorf("Error writing keystore: %w", err))
+		}
+
+		fmt.Printf("Public key: %s\n", publicKeyHex)
+		infof("Keystore written to %s", *keystorePath)
+		return
+	}
+
+	if *out == "" {
+		fmt.Printf("Private key: %s\n", privateKeyHex)
+		fmt.Printf("Public key: %s\n", publicKeyHex)
+		return
+	}
+
+	data := fmt.Sprintf("SCHNORR_KEY=%s\nSCHNORR_PUBLIC_KEY=%s\n", privateKeyHex, publicKeyHex)
+	if err := writeKeyFile(*out, []byte(data), *force); err != nil {
+		fatal(err)
+	}
+
+	fmt.Printf("Public key: %s\n", publicKeyHex)
+	infof("Keypair written to %s", *out)
+}
+
+// writeKeyFile writes data to path with 0600 permissions. An existing
+// file is only replaced with force, and then by renaming a new file
+// over it: truncating it would keep its permissions, which may let
+// others read the key.
+func writeKeyFile(path string, data []byte, force bool) error {
+	if !force {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			if os.IsExist(err) {
+				return usageError("Refusing to overwrite %s, use -force", path)
+			}
+			return fmt.Errorf("Error creating output file: %w", err)
+		}
+		defer file.Close()
+
+		if _, err := file.Write(data); err != nil {
+			return fmt.Errorf("Error writing output file: %w", err)
+		}
+		return nil
+	}
+
+	// CreateTemp makes the file with 0600 permissions.
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("Error creating output file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("Error writing output file: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("Error replacing output file: %w", err)
+	}
+	return nil
+}
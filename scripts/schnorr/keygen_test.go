@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteKeyFileForceTightensMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeKeyFile(path, []byte("SCHNORR_KEY=01\n"), false); exitCode(err) != exitUsage {
+		t.Fatalf("overwrite without force: got %v, want a usage error", err)
+	}
+
+	if err := writeKeyFile(path, []byte("SCHNORR_KEY=01\n"), true); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("mode after -force is %o, want 600", mode)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "SCHNORR_KEY=01\n" {
+		t.Errorf("file holds %q", data)
+	}
+
+	// Only the key file is left behind.
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want 1", len(entries))
+	}
+}
+
+func TestWriteKeyFileNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := writeKeyFile(path, []byte("SCHNORR_KEY=01\n"), false); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("mode is %o, want 600", mode)
+	}
+}
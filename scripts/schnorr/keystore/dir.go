@@ -0,0 +1,105 @@
+package keystore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A keystore directory holds several named keys, one keystore per file
+// named <name>.json. Names are letters, digits, '.', '_' and '-', so a
+// name is always a plain file in the directory.
+
+// Ext is the file extension of keystores in a keystore directory.
+const Ext = ".json"
+
+var (
+	ErrKeyName    = errors.New("invalid key name")
+	ErrUnknownKey = errors.New("no key with that name")
+)
+
+var keyName = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+// Entry is a named key in a keystore directory. PublicKey is read from
+// the keystore's plain text header, without decrypting the key; only
+// LoadKeystore checks that it is the encrypted key's.
+type Entry struct {
+	Name      string
+	PublicKey string
+}
+
+// EntryPath returns the path of the key called name in dir, whether or
+// not it exists yet.
+func EntryPath(dir, name string) (string, error) {
+	if !keyName.MatchString(name) {
+		return "", fmt.Errorf("%w: %q", ErrKeyName, name)
+	}
+	return filepath.Join(dir, name+Ext), nil
+}
+
+// Find returns the path of the existing key called name in dir. An
+// unknown name gives ErrUnknownKey listing the names dir does have.
+func Find(dir, name string) (string, error) {
+	path, err := EntryPath(dir, name)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		entries, err := List(dir)
+		if err != nil {
+			return "", err
+		}
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name
+		}
+		if len(names) == 0 {
+			return "", fmt.Errorf("%w: %q, %s has no keys", ErrUnknownKey, name, dir)
+		}
+		return "", fmt.Errorf("%w: %q, have %s", ErrUnknownKey, name, strings.Join(names, ", "))
+	}
+
+	return path, nil
+}
+
+// List returns the keys in dir sorted by name. Files without the Ext
+// extension or with an invalid name are ignored.
+func List(dir string) ([]Entry, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		name, ok := strings.CutSuffix(file.Name(), Ext)
+		if !ok || !file.Type().IsRegular() || !keyName.MatchString(name) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var env envelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrCorrupted, file.Name(), err)
+		}
+
+		entries = append(entries, Entry{Name: name, PublicKey: env.PublicKey})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
@@ -0,0 +1,110 @@
+package keystore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// writeDirKeystore saves a new key under passphrase as name in dir.
+func writeDirKeystore(t *testing.T, dir, name, passphrase string) *btcec.PrivateKey {
+	t.Helper()
+
+	priv, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := EntryPath(dir, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := saveKeystore(path, priv, []byte(passphrase), testParams); err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+func TestFindSelectsNamedKey(t *testing.T) {
+	dir := t.TempDir()
+	writeDirKeystore(t, dir, "feeds", "pass")
+	prices := writeDirKeystore(t, dir, "prices", "pass")
+
+	path, err := Find(dir, "prices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "prices.json"); path != want {
+		t.Errorf("path is %s, want %s", path, want)
+	}
+	loaded, err := LoadKeystore(path, []byte("pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Key.Equals(&prices.Key) {
+		t.Error("loaded another key than the one named")
+	}
+}
+
+func TestFindUnknownName(t *testing.T) {
+	dir := t.TempDir()
+	writeDirKeystore(t, dir, "feeds", "pass")
+	writeDirKeystore(t, dir, "prices", "pass")
+
+	_, err := Find(dir, "votes")
+	if !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("got %v, want ErrUnknownKey", err)
+	}
+	if !strings.Contains(err.Error(), `"votes", have feeds, prices`) {
+		t.Errorf("error does not name the known keys: %v", err)
+	}
+
+	empty := t.TempDir()
+	_, err = Find(empty, "votes")
+	if !errors.Is(err, ErrUnknownKey) || !strings.Contains(err.Error(), empty+" has no keys") {
+		t.Errorf("empty dir: got %v, want ErrUnknownKey saying it has no keys", err)
+	}
+
+	for _, name := range []string{"", "../feeds", ".hidden", "a/b"} {
+		if _, err := Find(dir, name); !errors.Is(err, ErrKeyName) {
+			t.Errorf("name %q: got %v, want ErrKeyName", name, err)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	if entries, err := List(dir); err != nil || len(entries) != 0 {
+		t.Fatalf("empty dir: got %v, %v", entries, err)
+	}
+
+	prices := writeDirKeystore(t, dir, "prices", "pass")
+	feeds := writeDirKeystore(t, dir, "feeds", "pass")
+	// Neither is a keystore entry.
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "old.json"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{"feeds", publicKeyHeader(feeds)},
+		{"prices", publicKeyHeader(prices)},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %v, want %v", entries, want)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d is %v, want %v", i, entries[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Package keystore stores schnorr private keys on disk encrypted with a
+// passphrase. The key is derived with scrypt and the private key is
+// sealed with AES-256-GCM inside a small JSON envelope.
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	version   = 1
+	scryptN   = 1 << 18
+	scryptR   = 8
+	scryptP   = 1
+	keyLength = 32
+	saltSize  = 32
+
+	// A keystore's scrypt parameters are read from the file, so they
+	// are bounded before use: scrypt needs 128*N*r bytes and N*r*p
+	// rounds, and a crafted file could otherwise ask for any amount.
+	maxScryptN  = 1 << 20
+	maxScryptRP = 8
+)
+
+var (
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+	ErrCorrupted       = errors.New("keystore is corrupted")
+)
+
+type kdfParams struct {
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt string `json:"salt"`
+}
+
+type envelope struct {
+	Version    int       `json:"version"`
+	PublicKey  string    `json:"publicKey"`
+	KDF        string    `json:"kdf"`
+	KDFParams  kdfParams `json:"kdfParams"`
+	Cipher     string    `json:"cipher"`
+	Nonce      string    `json:"nonce"`
+	Ciphertext string    `json:"ciphertext"`
+}
+
+// SaveKeystore encrypts priv with passphrase and writes it to path.
+// The file is created with 0600 permissions and must not exist yet.
+func SaveKeystore(path string, priv *btcec.PrivateKey, passphrase []byte) error {
+	return saveKeystore(path, priv, passphrase, kdfParams{N: scryptN, R: scryptR, P: scryptP})
+}
+
+// saveKeystore is SaveKeystore with the scrypt parameters in params;
+// its salt is ignored.
+func saveKeystore(path string, priv *btcec.PrivateKey, passphrase []byte, params kdfParams) error {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	aead, err := newAEAD(passphrase, salt, params.N, params.R, params.P)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	plaintext := priv.Serialize()
+	ciphertext := aead.Seal(nil, nonce, plaintext, nil)
+	clear(plaintext)
+
+	data, err := json.MarshalIndent(envelope{
+		Version:   version,
+		PublicKey: publicKeyHeader(priv),
+		KDF:       "scrypt",
+		KDFParams: kdfParams{
+			N:    params.N,
+			R:    params.R,
+			P:    params.P,
+			Salt: hex.EncodeToString(salt),
+		},
+		Cipher:     "aes-256-gcm",
+		Nonce:      hex.EncodeToString(nonce),
+		Ciphertext: hex.EncodeToString(ciphertext),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(data)
+	return err
+}
+
+// LoadKeystore reads the keystore at path and decrypts it with
+// passphrase.
+func LoadKeystore(path string, passphrase []byte) (*btcec.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupted, err)
+	}
+
+	if env.Version != version || env.KDF != "scrypt" || env.Cipher != "aes-256-gcm" {
+		return nil, fmt.Errorf("%w: unsupported format", ErrCorrupted)
+	}
+
+	salt, err := hex.DecodeString(env.KDFParams.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad salt", ErrCorrupted)
+	}
+	nonce, err := hex.DecodeString(env.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad nonce", ErrCorrupted)
+	}
+	ciphertext, err := hex.DecodeString(env.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad ciphertext", ErrCorrupted)
+	}
+
+	params := env.KDFParams
+	if err := checkKDFParams(params); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(passphrase, salt, params.N, params.R, params.P)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupted, err)
+	}
+
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: bad nonce", ErrCorrupted)
+	}
+
+	// GCM cannot tell a wrong passphrase apart from a tampered
+	// ciphertext, both fail authentication.
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	defer clear(plaintext)
+
+	priv, err := signer.PrivateKeyFromBytes(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupted, err)
+	}
+
+	// The public key header is plain text so List can read it without
+	// a passphrase, and nothing seals it. A header that is not the
+	// sealed key's would have list-keys show one key and sign with
+	// another.
+	if want := publicKeyHeader(priv); env.PublicKey != want {
+		priv.Zero()
+		return nil, fmt.Errorf("%w: public key header %s is not the encrypted key's %s", ErrCorrupted, env.PublicKey, want)
+	}
+
+	return priv, nil
+}
+
+// publicKeyHeader returns the public key header of priv's keystore.
+func publicKeyHeader(priv *btcec.PrivateKey) string {
+	return fmt.Sprintf("0x%x", signer.XOnlyPubKey(priv.PubKey()))
+}
+
+// checkKDFParams rejects scrypt parameters scrypt would refuse or that
+// cost more than maxScryptN and maxScryptRP allow.
+func checkKDFParams(params kdfParams) error {
+	n, r, p := params.N, params.R, params.P
+	if n < 2 || n > maxScryptN || n&(n-1) != 0 {
+		return fmt.Errorf("%w: scrypt N must be a power of two up to %d, got %d", ErrCorrupted, maxScryptN, n)
+	}
+	if r < 1 || p < 1 || r > maxScryptRP/p {
+		return fmt.Errorf("%w: scrypt r*p must be between 1 and %d, got r=%d p=%d", ErrCorrupted, maxScryptRP, r, p)
+	}
+	return nil
+}
+
+func newAEAD(passphrase, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, n, r, p, keyLength)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(key)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
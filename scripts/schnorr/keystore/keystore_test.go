@@ -0,0 +1,169 @@
+package keystore
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// testParams keeps scrypt cheap so the tests run quickly.
+var testParams = kdfParams{N: 1 << 4, R: 8, P: 1}
+
+// writeTestKeystore saves a new key under passphrase to a temp dir.
+func writeTestKeystore(t *testing.T, passphrase string) (string, *btcec.PrivateKey) {
+	t.Helper()
+
+	priv, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "key.json")
+	if err := saveKeystore(path, priv, []byte(passphrase), testParams); err != nil {
+		t.Fatal(err)
+	}
+	return path, priv
+}
+
+// editEnvelope rewrites the keystore at path with edit applied.
+func editEnvelope(t *testing.T, path string, edit func(*envelope)) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		t.Fatal(err)
+	}
+	edit(&env)
+	if data, err = json.Marshal(env); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadKeystoreRoundTrip(t *testing.T) {
+	path, priv := writeTestKeystore(t, "correct horse")
+
+	loaded, err := LoadKeystore(path, []byte("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Key.Equals(&priv.Key) {
+		t.Error("loaded key differs from the saved key")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("keystore mode is %o, want 600", mode)
+	}
+}
+
+func TestSaveKeystoreRefusesOverwrite(t *testing.T) {
+	path, priv := writeTestKeystore(t, "pass")
+	if err := saveKeystore(path, priv, []byte("pass"), testParams); !errors.Is(err, os.ErrExist) {
+		t.Errorf("got %v, want os.ErrExist", err)
+	}
+}
+
+func TestLoadKeystoreWrongPassphrase(t *testing.T) {
+	path, _ := writeTestKeystore(t, "correct horse")
+
+	if _, err := LoadKeystore(path, []byte("battery staple")); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("got %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestLoadKeystoreCorrupted(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(*envelope)
+		want error
+	}{
+		{"version", func(e *envelope) { e.Version = 2 }, ErrCorrupted},
+		{"kdf", func(e *envelope) { e.KDF = "pbkdf2" }, ErrCorrupted},
+		{"cipher", func(e *envelope) { e.Cipher = "aes-128-cbc" }, ErrCorrupted},
+		{"salt", func(e *envelope) { e.KDFParams.Salt = "zz" }, ErrCorrupted},
+		{"nonce", func(e *envelope) { e.Nonce = "zz" }, ErrCorrupted},
+		{"nonce length", func(e *envelope) { e.Nonce = "00" }, ErrCorrupted},
+		{"ciphertext hex", func(e *envelope) { e.Ciphertext = "zz" }, ErrCorrupted},
+		{"public key", func(e *envelope) { e.PublicKey = "0x" + strings.Repeat("11", 32) }, ErrCorrupted},
+		{"no public key", func(e *envelope) { e.PublicKey = "" }, ErrCorrupted},
+		// GCM cannot tell tampering from a wrong passphrase.
+		{"ciphertext bit", func(e *envelope) {
+			flipped := []byte(e.Ciphertext)
+			if flipped[0] == '0' {
+				flipped[0] = '1'
+			} else {
+				flipped[0] = '0'
+			}
+			e.Ciphertext = string(flipped)
+		}, ErrWrongPassphrase},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, _ := writeTestKeystore(t, "pass")
+			editEnvelope(t, path, tt.edit)
+
+			if _, err := LoadKeystore(path, []byte("pass")); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("not json", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "key.json")
+		if err := os.WriteFile(path, []byte("SCHNORR_KEY=01"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadKeystore(path, []byte("pass")); !errors.Is(err, ErrCorrupted) {
+			t.Errorf("got %v, want ErrCorrupted", err)
+		}
+	})
+}
+
+func TestLoadKeystoreBoundsScryptParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		n, r, p int
+	}{
+		{"N not a power of two", 1000, 8, 1},
+		{"N too large", maxScryptN << 1, 8, 1},
+		{"N zero", 0, 8, 1},
+		{"r*p too large", 1 << 4, 8, 2},
+		{"r zero", 1 << 4, 0, 1},
+		{"p negative", 1 << 4, 8, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, _ := writeTestKeystore(t, "pass")
+			editEnvelope(t, path, func(e *envelope) {
+				e.KDFParams.N, e.KDFParams.R, e.KDFParams.P = tt.n, tt.r, tt.p
+			})
+
+			if _, err := LoadKeystore(path, []byte("pass")); !errors.Is(err, ErrCorrupted) {
+				t.Errorf("got %v, want ErrCorrupted", err)
+			}
+		})
+	}
+}
+
+func TestDefaultParamsAreAllowed(t *testing.T) {
+	if err := checkKDFParams(kdfParams{N: scryptN, R: scryptR, P: scryptP}); err != nil {
+		t.Errorf("SaveKeystore's own parameters are rejected: %v", err)
+	}
+}
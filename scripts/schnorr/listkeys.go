@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/TimeleapLabs/go-schnorr/keystore"
+)
+
+// listKeys prints the name and public key of every keystore in
+// -keystore-dir. Keys are not decrypted, so no passphrase is needed.
+func listKeys(args []string) {
+	flags := flag.NewFlagSet("list-keys", flag.ExitOnError)
+	dir := flags.String("keystore-dir", "", "directory of named keystores")
+	parseFlags(flags, args)
+
+	if *dir == "" {
+		fatal(usageError("-keystore-dir is required"))
+	}
+
+	entries, err := keystore.List(*dir)
+	if err != nil {
+		fatal(keyError("Error listing keys: %w", err))
+	}
+
+	for _, entry := range entries {
+		fmt.Printf("%s: %s\n", entry.Name, entry.PublicKey)
+	}
+	if len(entries) == 0 {
+		infof("No keys in %s", *dir)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListKeysEmptyDir(t *testing.T) {
+	dir := t.TempDir()
+	res := cliRun{}.run(t, "list-keys", "-keystore-dir", dir)
+	if res.code != 0 {
+		t.Fatalf("exit %d: %s", res.code, res.stderr)
+	}
+	if res.stdout != "" {
+		t.Errorf("listed keys in an empty dir: %q", res.stdout)
+	}
+	if !strings.Contains(res.stderr, "No keys in "+dir) {
+		t.Errorf("empty dir not reported: %q", res.stderr)
+	}
+}
+
+func TestKeyNameSelection(t *testing.T) {
+	dir := t.TempDir()
+	// Only the plain text header is read when listing or picking a name.
+	header := `{"version":1,"publicKey":"` + testPubKey + `"}`
+	if err := os.WriteFile(filepath.Join(dir, "feeds.json"), []byte(header), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	res := cliRun{}.run(t, "list-keys", "-keystore-dir", dir)
+	if res.code != 0 || res.stdout != "feeds: "+testPubKey+"\n" {
+		t.Errorf("list-keys: exit %d, %q: %s", res.code, res.stdout, res.stderr)
+	}
+
+	res = cliRun{}.run(t, "sign", "-message", "hello", "-keystore-dir", dir, "-key-name", "prices")
+	if res.code != exitKey || !strings.Contains(res.stderr, `no key with that name: "prices", have feeds`) {
+		t.Errorf("unknown name: exit %d, want %d: %s", res.code, exitKey, res.stderr)
+	}
+
+	res = cliRun{}.run(t, "sign", "-message", "hello", "-keystore-dir", dir)
+	if res.code != exitUsage {
+		t.Errorf("-keystore-dir without -key-name: exit %d, want %d", res.code, exitUsage)
+	}
+}
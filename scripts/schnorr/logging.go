@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+)
+
+// Results are printed to stdout with fmt, everything else goes through
+// the leveled helpers below to stderr, so pipelines can separate them.
+
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelError
+)
+
+var logLevels = map[string]logLevel{
+	"debug": levelDebug,
+	"info":  levelInfo,
+	"error": levelError,
+}
+
+var currentLevel = levelInfo
+
+// addLogLevelFlag registers -log-level on flags.
+func addLogLevelFlag(flags *flag.FlagSet) *string {
+	return flags.String("log-level", "info", "diagnostics to print: debug, info or error")
+}
+
+// setLogLevel applies a -log-level value.
+func setLogLevel(name string) {
+	l, ok := logLevels[name]
+	if !ok {
+		fatal(usageError("Unknown -log-level %q, use debug, info or error", name))
+	}
+	currentLevel = l
+}
+
+func logf(level logLevel, format string, args ...any) {
+	if level >= currentLevel {
+		log.Output(3, fmt.Sprintf(format, args...))
+	}
+}
+
+func debugf(format string, args ...any) { logf(levelDebug, format, args...) }
+
+func infof(format string, args ...any) { logf(levelInfo, format, args...) }
+
+func errorf(format string, args ...any) { logf(levelError, format, args...) }
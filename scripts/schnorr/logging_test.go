@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestResultsOnStdoutDiagnosticsOnStderr(t *testing.T) {
+	res := runCLI(t, "sign", "-message", "hello", "-log-level", "debug")
+	if res.code != 0 {
+		t.Fatalf("exit %d: %s", res.code, res.stderr)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(res.stdout), "\n") {
+		name, _, _ := strings.Cut(line, ": ")
+		switch name {
+		case "Public key", "Address", "Message", "Signature":
+		default:
+			t.Errorf("unexpected stdout line %q", line)
+		}
+	}
+	if !strings.Contains(res.stdout, "Public key: "+testPubKey) {
+		t.Errorf("stdout has no public key:\n%s", res.stdout)
+	}
+	if !strings.Contains(res.stderr, "Signature R: 0x") || !strings.Contains(res.stderr, "Signature S: 0x") {
+		t.Errorf("debug diagnostics missing from stderr:\n%s", res.stderr)
+	}
+	if strings.Contains(res.stderr, "Public key") {
+		t.Errorf("result on stderr:\n%s", res.stderr)
+	}
+}
+
+func TestJSONOutputIsOnlyJSON(t *testing.T) {
+	res := runCLI(t, "sign", "-message", "hello", "-output", "json", "-log-level", "debug")
+	if res.code != 0 {
+		t.Fatalf("exit %d: %s", res.code, res.stderr)
+	}
+
+	var out signOutput
+	decoder := json.NewDecoder(strings.NewReader(res.stdout))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&out); err != nil {
+		t.Fatalf("stdout is not a sign output: %v\n%s", err, res.stdout)
+	}
+	if decoder.More() {
+		t.Errorf("stdout has more than one JSON value:\n%s", res.stdout)
+	}
+	if out.PublicKey != testPubKey {
+		t.Errorf("public key is %s, want %s", out.PublicKey, testPubKey)
+	}
+}
+
+func TestLogLevelError(t *testing.T) {
+	res := runCLI(t, "sign", "-message", "hello", "-log-level", "error")
+	if res.code != 0 {
+		t.Fatalf("exit %d: %s", res.code, res.stderr)
+	}
+	if res.stderr != "" {
+		t.Errorf("-log-level error printed diagnostics:\n%s", res.stderr)
+	}
+	if !strings.Contains(res.stdout, "Signature: 0x") {
+		t.Errorf("-log-level error suppressed the result:\n%s", res.stdout)
+	}
+}
+
+func TestErrorsOnStderr(t *testing.T) {
+	res := cliRun{}.run(t, "sign", "-message", "hello")
+	if res.code != exitKey {
+		t.Fatalf("exit %d, want %d: %s", res.code, exitKey, res.stderr)
+	}
+	if res.stdout != "" {
+		t.Errorf("failed run wrote to stdout:\n%s", res.stdout)
+	}
+	if !strings.Contains(res.stderr, "SCHNORR_KEY is not set") {
+		t.Errorf("stderr lacks the error:\n%s", res.stderr)
+	}
+
+	if res := runCLI(t, "sign", "-message", "hello", "-log-level", "loud"); res.code != exitUsage || res.stdout != "" {
+		t.Errorf("unknown -log-level: exit %d, stdout %q", res.code, res.stdout)
+	}
+}
@@ -1,42 +1,86 @@
 package main
 
 import (
-	"encoding/hex"
-	"log"
 	"os"
-
-	"github.com/btcsuite/btcd/btcec/v2"
-	"github.com/btcsuite/btcd/btcec/v2/schnorr"
-	"github.com/joho/godotenv"
-	"github.com/wealdtech/go-merkletree/keccak256"
 )
 
 func main() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
-
-	schnorrKeyHex := os.Getenv("SCHNORR_KEY")
-	schnorrKeyBytes, err := hex.DecodeString(schnorrKeyHex)
-	if err != nil {
-		log.Fatal("Error decoding schnorr key")
-	}
-
-	privateKey, publicKey := btcec.PrivKeyFromBytes(schnorrKeyBytes)
-	log.Printf("Public key: 0x%x\n", publicKey.X().Bytes())
-
-	message := []byte("Hello, world!")
-	hash := keccak256.New().Hash(message)
-
-	log.Printf("Message: 0x%x\n", hash)
-
-	signature, err := schnorr.Sign(privateKey, hash, []schnorr.SignOption{}...)
-	if err != nil {
-		log.Fatal("Error signing message", err)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "sign":
+			sign(os.Args[2:])
+			return
+		case "verify":
+			verify(os.Args[2:])
+			return
+		case "keygen":
+			keygen(os.Args[2:])
+			return
+		case "check-vectors":
+			checkVectors(os.Args[2:])
+			return
+		case "verify-proof":
+			verifyProof(os.Args[2:])
+			return
+		case "validate":
+			validate(os.Args[2:])
+			return
+		case "serve":
+			serve(os.Args[2:])
+			return
+		case "combine":
+			combine(os.Args[2:])
+			return
+		case "aggverify":
+			aggVerify(os.Args[2:])
+			return
+		case "pubkey":
+			pubkey(os.Args[2:])
+			return
+		case "whosigned":
+			whoSigned(os.Args[2:])
+			return
+		case "merkle-root":
+			merkleRoot(os.Args[2:])
+			return
+		case "sign-file-tree":
+			signFileTree(os.Args[2:])
+			return
+		case "selftest":
+			selfTest(os.Args[2:])
+			return
+		case "split-key":
+			splitKey(os.Args[2:])
+			return
+		case "combine-key":
+			combineKey(os.Args[2:])
+			return
+		case "make-request":
+			makeRequest(os.Args[2:])
+			return
+		case "sign-request":
+			signRequest(os.Args[2:])
+			return
+		case "check-response":
+			checkResponse(os.Args[2:])
+			return
+		case "rotate":
+			rotate(os.Args[2:])
+			return
+		case "nft-prices":
+			nftPrices(os.Args[2:])
+			return
+		case "list-keys":
+			listKeys(os.Args[2:])
+			return
+		case "bench":
+			bench(os.Args[2:])
+			return
+		case "verify-batch":
+			verifyBatch(os.Args[2:])
+			return
+		}
 	}
 
-	log.Printf("Signature: 0x%x\n", signature.Serialize())
-	log.Printf("Signature: 0x%x\n", signature.Serialize()[:32])
-	log.Printf("Signature: 0x%x\n", signature.Serialize()[32:])
+	sign(os.Args[1:])
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// mainArgsEnv makes the test binary run main with the JSON-encoded
+// arguments it holds instead of the tests, so CLI tests can check the
+// exit code and output streams of a real run.
+const mainArgsEnv = "SCHNORR_TEST_MAIN_ARGS"
+
+// testKeyHex is the key tests sign with, and testPubKey its x-only
+// public key.
+const (
+	testKeyHex = "0x0101010101010101010101010101010101010101010101010101010101010101"
+	testPubKey = "0x1b84c5567b126440995d3ed5aaba0565d71e1834604819ff9c17f5e9d5dd078f"
+)
+
+func TestMain(m *testing.M) {
+	if encoded, ok := os.LookupEnv(mainArgsEnv); ok {
+		var args []string
+		if err := json.Unmarshal([]byte(encoded), &args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitFailure)
+		}
+		os.Args = append([]string{"schnorr"}, args...)
+		main()
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// cliRun describes how to run the CLI: in dir, or a fresh temporary
+// directory if empty, with stdin as input and env added to an
+// environment cleared of SCHNORR_ variables.
+type cliRun struct {
+	dir   string
+	stdin string
+	env   []string
+}
+
+type cliResult struct {
+	stdout string
+	stderr string
+	code   int
+}
+
+func (c cliRun) run(t *testing.T, args ...string) cliResult {
+	t.Helper()
+
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[0])
+	cmd.Dir = c.dir
+	if cmd.Dir == "" {
+		cmd.Dir = t.TempDir()
+	}
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "SCHNORR_") {
+			cmd.Env = append(cmd.Env, kv)
+		}
+	}
+	cmd.Env = append(cmd.Env, c.env...)
+	cmd.Env = append(cmd.Env, mainArgsEnv+"="+string(encoded))
+	cmd.Stdin = strings.NewReader(c.stdin)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	result := cliResult{}
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if !errors.As(err, &exit) {
+			t.Fatal(err)
+		}
+		result.code = exit.ExitCode()
+	}
+	result.stdout, result.stderr = stdout.String(), stderr.String()
+	return result
+}
+
+// runCLI runs the CLI with args and the test key in the environment.
+func runCLI(t *testing.T, args ...string) cliResult {
+	t.Helper()
+	return cliRun{env: []string{"SCHNORR_KEY=" + testKeyHex}}.run(t, args...)
+}
@@ -0,0 +1,272 @@
+// Package merkle builds keccak256 Merkle trees over signed data points.
+//
+// Leaves and inner nodes are domain separated so a node can never be
+// passed off as a leaf:
+//
+//	leaf = keccak256(0x00 || data)
+//	node = keccak256(0x01 || left || right)
+//
+// The leaf level is padded with zero hashes up to the next power of two,
+// so every proof has the same length and sibling order follows directly
+// from the leaf index. In Solidity a leaf is
+// keccak256(abi.encodePacked(bytes1(0x00), data)) and a node is
+// keccak256(abi.encodePacked(bytes1(0x01), left, right)).
+//
+// A multiproof covers several leaves with each needed sibling listed
+// once. A verifier walks the tree one level at a time: proven nodes are
+// paired with their proven neighbour when present and otherwise consume
+// the next sibling from the proof, so siblings are ordered bottom level
+// first and left to right within a level.
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/wealdtech/go-merkletree/keccak256"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+var ErrNoLeaves = errors.New("merkle tree needs at least one leaf")
+
+// Tree is an in-memory Merkle tree. levels[0] holds the padded leaf
+// hashes and the last level holds the root.
+type Tree struct {
+	leaves int
+	levels [][][]byte
+}
+
+// HashLeaf returns the leaf hash of a data point.
+func HashLeaf(data []byte) []byte {
+	return keccak256.New().Hash(append([]byte{leafPrefix}, data...))
+}
+
+// HashNode returns the hash of an inner node.
+func HashNode(left, right []byte) []byte {
+	buf := make([]byte, 0, 1+len(left)+len(right))
+	buf = append(buf, nodePrefix)
+	buf = append(buf, left...)
+	buf = append(buf, right...)
+	return keccak256.New().Hash(buf)
+}
+
+// HashLeafReader returns HashLeaf of everything read from r. r is hashed
+// in chunks as it is read, so the data point is never held in memory.
+func HashLeafReader(r io.Reader) ([]byte, error) {
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte{leafPrefix})
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// New builds a tree over data, one leaf per data point.
+func New(data [][]byte) (*Tree, error) {
+	hashes := make([][]byte, len(data))
+	for i := range data {
+		hashes[i] = HashLeaf(data[i])
+	}
+	return NewFromLeafHashes(hashes)
+}
+
+// NewFromLeafHashes builds a tree over leaf hashes computed with
+// HashLeaf or HashLeafReader, for callers that hash their data points
+// as they read them rather than holding them all.
+func NewFromLeafHashes(hashes [][]byte) (*Tree, error) {
+	if len(hashes) == 0 {
+		return nil, ErrNoLeaves
+	}
+
+	width := 1
+	for width < len(hashes) {
+		width *= 2
+	}
+
+	level := make([][]byte, width)
+	for i := range level {
+		if i < len(hashes) {
+			if len(hashes[i]) != 32 {
+				return nil, fmt.Errorf("leaf hash %d is %d bytes, want 32", i, len(hashes[i]))
+			}
+			level[i] = hashes[i]
+		} else {
+			level[i] = make([]byte, 32)
+		}
+	}
+
+	levels := [][][]byte{level}
+	for len(level) > 1 {
+		next := make([][]byte, len(level)/2)
+		for i := range next {
+			next[i] = HashNode(level[2*i], level[2*i+1])
+		}
+		levels = append(levels, next)
+		level = next
+	}
+
+	return &Tree{leaves: len(hashes), levels: levels}, nil
+}
+
+// Root returns the Merkle root.
+func (t *Tree) Root() []byte {
+	return t.levels[len(t.levels)-1][0]
+}
+
+// Len returns the number of leaves, not counting padding.
+func (t *Tree) Len() int {
+	return t.leaves
+}
+
+// Proof returns the sibling hashes from the leaf at index up to the
+// root, bottom first.
+func (t *Tree) Proof(index int) ([][]byte, error) {
+	if index < 0 || index >= t.leaves {
+		return nil, fmt.Errorf("leaf index %d out of range [0, %d)", index, t.leaves)
+	}
+
+	proof := make([][]byte, 0, len(t.levels)-1)
+	for _, level := range t.levels[:len(t.levels)-1] {
+		proof = append(proof, level[index^1])
+		index /= 2
+	}
+
+	return proof, nil
+}
+
+// VerifyProof reports whether the data point leaf sits at index in the
+// tree with the given root.
+func VerifyProof(root, leaf []byte, proof [][]byte, index int) bool {
+	if index < 0 || index >= 1<<len(proof) {
+		return false
+	}
+
+	hash := HashLeaf(leaf)
+	for _, sibling := range proof {
+		if index%2 == 0 {
+			hash = HashNode(hash, sibling)
+		} else {
+			hash = HashNode(sibling, hash)
+		}
+		index /= 2
+	}
+
+	return bytes.Equal(hash, root)
+}
+
+// Multiproof proves several leaves against one root at once. Indices
+// are the proven leaf positions in ascending order. Siblings holds only
+// the hashes that cannot be computed from the proven leaves themselves,
+// level by level from the bottom, left to right within a level. Depth
+// is the number of levels below the root.
+type Multiproof struct {
+	Indices  []int
+	Siblings [][]byte
+	Depth    int
+}
+
+// MultiProof returns a proof for the leaves at indices. The indices may
+// be given in any order but must be distinct; the proof lists them
+// sorted.
+func (t *Tree) MultiProof(indices []int) (*Multiproof, error) {
+	if len(indices) == 0 {
+		return nil, ErrNoLeaves
+	}
+
+	positions := append([]int(nil), indices...)
+	sort.Ints(positions)
+	for i, index := range positions {
+		if index < 0 || index >= t.leaves {
+			return nil, fmt.Errorf("leaf index %d out of range [0, %d)", index, t.leaves)
+		}
+		if i > 0 && positions[i-1] == index {
+			return nil, fmt.Errorf("leaf index %d given twice", index)
+		}
+	}
+
+	proof := &Multiproof{
+		Indices: append([]int(nil), positions...),
+		Depth:   len(t.levels) - 1,
+	}
+
+	for _, level := range t.levels[:len(t.levels)-1] {
+		var parents []int
+		for i := 0; i < len(positions); i++ {
+			position := positions[i]
+			if position%2 == 0 && i+1 < len(positions) && positions[i+1] == position+1 {
+				i++
+			} else {
+				proof.Siblings = append(proof.Siblings, level[position^1])
+			}
+			parents = append(parents, position/2)
+		}
+		positions = parents
+	}
+
+	return proof, nil
+}
+
+// VerifyMultiproof reports whether the data points leaves sit at
+// proof.Indices, in that order, in the tree with the given root.
+func VerifyMultiproof(root []byte, leaves [][]byte, proof *Multiproof) bool {
+	if proof == nil || len(leaves) == 0 || len(leaves) != len(proof.Indices) {
+		return false
+	}
+	if proof.Depth < 0 || proof.Depth >= strconv.IntSize-1 {
+		return false
+	}
+
+	positions := append([]int(nil), proof.Indices...)
+	hashes := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		if positions[i] < 0 || positions[i] >= 1<<proof.Depth {
+			return false
+		}
+		if i > 0 && positions[i] <= positions[i-1] {
+			return false
+		}
+		hashes[i] = HashLeaf(leaf)
+	}
+
+	siblings := proof.Siblings
+	for level := 0; level < proof.Depth; level++ {
+		var (
+			parents      []int
+			parentHashes [][]byte
+		)
+		for i := 0; i < len(positions); i++ {
+			position, hash := positions[i], hashes[i]
+
+			var sibling []byte
+			if position%2 == 0 && i+1 < len(positions) && positions[i+1] == position+1 {
+				i++
+				sibling = hashes[i]
+			} else {
+				if len(siblings) == 0 {
+					return false
+				}
+				sibling, siblings = siblings[0], siblings[1:]
+			}
+
+			if position%2 == 0 {
+				hash = HashNode(hash, sibling)
+			} else {
+				hash = HashNode(sibling, hash)
+			}
+			parents = append(parents, position/2)
+			parentHashes = append(parentHashes, hash)
+		}
+		positions, hashes = parents, parentHashes
+	}
+
+	return len(siblings) == 0 && len(hashes) == 1 && bytes.Equal(hashes[0], root)
+}
@@ -0,0 +1,334 @@
+package merkle
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func mustHex(t testing.TB, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// leafData returns n distinct data points.
+func leafData(n int) [][]byte {
+	data := make([][]byte, n)
+	for i := range data {
+		data[i] = []byte(fmt.Sprintf("leaf-%d", i))
+	}
+	return data
+}
+
+// The roots were computed independently of this package with keccak256
+// over 0x00 || data for leaves and 0x01 || left || right for nodes, as
+// the on-chain verifier hashes them.
+func TestRootVectors(t *testing.T) {
+	tests := []struct {
+		data []string
+		root string
+	}{
+		{[]string{"a"}, "9722201502e620d70d78ee63045f3493812c206b988cbbe76c28918a7364fdbd"},
+		{[]string{"a", "b"}, "00d25e3ecfd5a8430c58b5562d4a00f53ce3e76001e3683df8496c541fecb9da"},
+		{[]string{"a", "b", "c"}, "43c18e3d2898c506e9bf1565faf609ba574db5e798da81187b520176e10cfdf5"},
+	}
+
+	for _, tt := range tests {
+		data := make([][]byte, len(tt.data))
+		for i, s := range tt.data {
+			data[i] = []byte(s)
+		}
+
+		tree, err := New(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(tree.Root()); got != tt.root {
+			t.Errorf("root of %q is %s, want %s", tt.data, got, tt.root)
+		}
+	}
+}
+
+func TestDomainSeparation(t *testing.T) {
+	a, b := HashLeaf([]byte("a")), HashLeaf([]byte("b"))
+
+	tree, err := New([][]byte{[]byte("a"), []byte("b")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tree.Root(), HashNode(a, b)) {
+		t.Fatal("root is not the node hash of the two leaves")
+	}
+
+	// The concatenated children of the root, offered as a single leaf,
+	// must not hash to the root.
+	forged := append(append([]byte(nil), a...), b...)
+	if bytes.Equal(HashLeaf(forged), tree.Root()) {
+		t.Error("an inner node passes as a leaf")
+	}
+	if VerifyProof(tree.Root(), forged, nil, 0) {
+		t.Error("inner node verified as a leaf with an empty proof")
+	}
+}
+
+func TestNewRejectsNoLeaves(t *testing.T) {
+	if _, err := New(nil); !errors.Is(err, ErrNoLeaves) {
+		t.Errorf("got %v, want ErrNoLeaves", err)
+	}
+}
+
+func TestNewFromLeafHashes(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		data := leafData(n)
+		hashes := make([][]byte, n)
+		for i := range data {
+			var err error
+			hashes[i], err = HashLeafReader(bytes.NewReader(data[i]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(hashes[i], HashLeaf(data[i])) {
+				t.Fatalf("streamed hash of leaf %d is %x, want %x", i, hashes[i], HashLeaf(data[i]))
+			}
+		}
+
+		want, err := New(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := NewFromLeafHashes(hashes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.Root(), want.Root()) || got.Len() != want.Len() {
+			t.Errorf("n=%d: root %x over %d leaves, want %x over %d", n, got.Root(), got.Len(), want.Root(), want.Len())
+		}
+	}
+
+	if _, err := NewFromLeafHashes(nil); !errors.Is(err, ErrNoLeaves) {
+		t.Errorf("got %v, want ErrNoLeaves", err)
+	}
+	if _, err := NewFromLeafHashes([][]byte{[]byte("short")}); err == nil {
+		t.Error("accepted a leaf hash that is not 32 bytes")
+	}
+}
+
+func TestProofs(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		data := leafData(n)
+		tree, err := New(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range data {
+			proof, err := tree.Proof(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyProof(tree.Root(), data[i], proof, i) {
+				t.Errorf("n=%d: proof for leaf %d does not verify", n, i)
+			}
+			if n > 1 && VerifyProof(tree.Root(), data[i], proof, i^1) {
+				t.Errorf("n=%d: proof for leaf %d verifies at index %d", n, i, i^1)
+			}
+			if VerifyProof(tree.Root(), []byte("other"), proof, i) {
+				t.Errorf("n=%d: proof for leaf %d verifies another leaf", n, i)
+			}
+		}
+
+		if _, err := tree.Proof(n); err == nil {
+			t.Errorf("n=%d: proof for out of range leaf %d", n, n)
+		}
+	}
+}
+
+func TestProofLength(t *testing.T) {
+	tree, err := New(leafData(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := tree.Proof(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Five leaves pad to eight, three levels below the root.
+	if len(proof) != 3 {
+		t.Errorf("proof has %d siblings, want 3", len(proof))
+	}
+	// Leaf 4's sibling is padding.
+	if !bytes.Equal(proof[0], mustHex(t, "0000000000000000000000000000000000000000000000000000000000000000")) {
+		t.Errorf("sibling of the last leaf is %x, want the zero hash", proof[0])
+	}
+}
+
+func TestMultiProofSubsets(t *testing.T) {
+	for n := 1; n <= 7; n++ {
+		data := leafData(n)
+		tree, err := New(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for mask := 1; mask < 1<<n; mask++ {
+			var (
+				indices []int
+				leaves  [][]byte
+			)
+			for i := 0; i < n; i++ {
+				if mask&(1<<i) != 0 {
+					indices = append(indices, i)
+					leaves = append(leaves, data[i])
+				}
+			}
+
+			proof, err := tree.MultiProof(indices)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyMultiproof(tree.Root(), leaves, proof) {
+				t.Errorf("n=%d: multiproof for %v does not verify", n, indices)
+			}
+		}
+	}
+}
+
+func TestMultiProofSharedSiblings(t *testing.T) {
+	tree, err := New(leafData(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	level := func(l, i int) []byte { return tree.levels[l][i] }
+
+	tests := []struct {
+		name     string
+		indices  []int
+		siblings [][]byte
+	}{
+		{"single", []int{5}, [][]byte{level(0, 4), level(1, 3), level(2, 0)}},
+		// 2 and 3 are a pair, so neither needs the other as a sibling.
+		{"adjacent pair", []int{2, 3}, [][]byte{level(1, 0), level(2, 1)}},
+		// 1 and 2 are neighbours with different parents, which then
+		// pair up one level higher.
+		{"adjacent across parents", []int{1, 2}, [][]byte{level(0, 0), level(0, 3), level(2, 1)}},
+		// 0 and 7 share nothing until the root.
+		{"far apart", []int{0, 7}, [][]byte{level(0, 1), level(0, 6), level(1, 1), level(1, 2)}},
+		// 3 and 6 have outside siblings, but their parents pair with
+		// 4 and 5's parent one level up.
+		{"run", []int{3, 4, 5, 6}, [][]byte{level(0, 2), level(0, 7), level(1, 0)}},
+		{"all", []int{0, 1, 2, 3, 4, 5, 6, 7}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proof, err := tree.MultiProof(tt.indices)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if proof.Depth != 3 {
+				t.Errorf("depth is %d, want 3", proof.Depth)
+			}
+			if len(proof.Siblings) != len(tt.siblings) {
+				t.Fatalf("%d siblings, want %d", len(proof.Siblings), len(tt.siblings))
+			}
+			for i := range tt.siblings {
+				if !bytes.Equal(proof.Siblings[i], tt.siblings[i]) {
+					t.Errorf("sibling %d is %x, want %x", i, proof.Siblings[i], tt.siblings[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMultiProofIndices(t *testing.T) {
+	data := leafData(8)
+	tree, err := New(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := tree.MultiProof([]int{6, 1, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(proof.Indices) != "[1 4 6]" {
+		t.Errorf("indices are %v, want [1 4 6]", proof.Indices)
+	}
+	if !VerifyMultiproof(tree.Root(), [][]byte{data[1], data[4], data[6]}, proof) {
+		t.Error("multiproof for unsorted indices does not verify")
+	}
+
+	for _, indices := range [][]int{nil, {3, 3}, {5, 2, 5}, {-1}, {8}} {
+		if _, err := tree.MultiProof(indices); err == nil {
+			t.Errorf("MultiProof(%v) succeeded", indices)
+		}
+	}
+}
+
+func TestVerifyMultiproofRejects(t *testing.T) {
+	data := leafData(8)
+	tree, err := New(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := [][]byte{data[1], data[2], data[6]}
+	proof, err := tree.MultiProof([]int{1, 2, 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edit := func(f func(p *Multiproof)) *Multiproof {
+		p := &Multiproof{
+			Indices:  append([]int(nil), proof.Indices...),
+			Siblings: append([][]byte(nil), proof.Siblings...),
+			Depth:    proof.Depth,
+		}
+		f(p)
+		return p
+	}
+
+	tests := []struct {
+		name   string
+		leaves [][]byte
+		proof  *Multiproof
+	}{
+		{"nil proof", leaves, nil},
+		{"wrong leaf", [][]byte{data[1], data[3], data[6]}, proof},
+		{"leaves out of order", [][]byte{data[2], data[1], data[6]}, proof},
+		{"missing leaf", leaves[:2], proof},
+		{"shifted indices", leaves, edit(func(p *Multiproof) { p.Indices = []int{0, 2, 6} })},
+		{"duplicate index", leaves, edit(func(p *Multiproof) { p.Indices = []int{1, 1, 6} })},
+		{"index past depth", leaves, edit(func(p *Multiproof) { p.Indices = []int{1, 2, 8} })},
+		{"missing sibling", leaves, edit(func(p *Multiproof) { p.Siblings = p.Siblings[1:] })},
+		{"extra sibling", leaves, edit(func(p *Multiproof) { p.Siblings = append(p.Siblings, p.Siblings[0]) })},
+		{"swapped siblings", leaves, edit(func(p *Multiproof) { p.Siblings[0], p.Siblings[1] = p.Siblings[1], p.Siblings[0] })},
+		{"wrong depth", leaves, edit(func(p *Multiproof) { p.Depth = 2 })},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if VerifyMultiproof(tree.Root(), tt.leaves, tt.proof) {
+				t.Error("multiproof verified")
+			}
+		})
+	}
+}
+
+func BenchmarkRoot(b *testing.B) {
+	for _, n := range []int{256, 4096} {
+		data := leafData(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := New(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package merkle
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Leaves yields data points one at a time. Next returns io.EOF once
+// there are no more.
+type Leaves interface {
+	Next() ([]byte, error)
+}
+
+// StreamingRoot computes the same root as New over the data points from
+// leaves while holding only one pending node per level, so memory grows
+// with the depth of the tree rather than its size. It also returns a
+// proof, as Tree.Proof would give, for each of proofIndices in the same
+// order; only the siblings on those paths are kept.
+func StreamingRoot(leaves Leaves, proofIndices []int) ([]byte, [][][]byte, error) {
+	s := &stream{wanted: make(map[nodeKey][]byte)}
+	for _, index := range proofIndices {
+		if index < 0 {
+			return nil, nil, fmt.Errorf("leaf index %d out of range", index)
+		}
+		// The depth is not known yet, so note the sibling on every level
+		// a tree indexed by int can have.
+		for level := 0; level < strconv.IntSize-1; level++ {
+			s.wanted[nodeKey{level, (index >> level) ^ 1}] = nil
+		}
+	}
+
+	n := 0
+	for {
+		data, err := leaves.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		s.push(0, HashLeaf(data))
+		n++
+	}
+	if n == 0 {
+		return nil, nil, ErrNoLeaves
+	}
+
+	width, depth := 1, 0
+	for width < n {
+		width *= 2
+		depth++
+	}
+
+	// Pad with the largest aligned all-zero subtrees that fit, which
+	// hashes the same as padding leaf by leaf.
+	zeros := make([][]byte, depth+1)
+	zeros[0] = make([]byte, 32)
+	for level := 1; level <= depth; level++ {
+		zeros[level] = HashNode(zeros[level-1], zeros[level-1])
+	}
+	for count := n; count < width; {
+		level := 0
+		for count%(2<<level) == 0 && count+(2<<level) <= width {
+			level++
+		}
+		s.push(level, zeros[level])
+		count += 1 << level
+	}
+
+	proofs := make([][][]byte, len(proofIndices))
+	for i, index := range proofIndices {
+		if index >= n {
+			return nil, nil, fmt.Errorf("leaf index %d out of range [0, %d)", index, n)
+		}
+
+		proof := make([][]byte, depth)
+		for level := range proof {
+			proof[level] = s.wanted[nodeKey{level, (index >> level) ^ 1}]
+		}
+		proofs[i] = proof
+	}
+
+	return s.pending[depth], proofs, nil
+}
+
+type nodeKey struct {
+	level, position int
+}
+
+// stream is the state of StreamingRoot: the left node waiting for its
+// right sibling on each level, how many nodes each level has seen and
+// the proof siblings collected so far.
+type stream struct {
+	pending [][]byte
+	counts  []int
+	wanted  map[nodeKey][]byte
+}
+
+// push adds the next node on level and hashes it into its parent once
+// its sibling is known.
+func (s *stream) push(level int, hash []byte) {
+	for len(s.pending) <= level {
+		s.pending = append(s.pending, nil)
+		s.counts = append(s.counts, 0)
+	}
+
+	key := nodeKey{level, s.counts[level]}
+	s.counts[level]++
+	if _, ok := s.wanted[key]; ok {
+		s.wanted[key] = hash
+	}
+
+	if left := s.pending[level]; left != nil {
+		s.pending[level] = nil
+		s.push(level+1, HashNode(left, hash))
+		return
+	}
+	s.pending[level] = hash
+}
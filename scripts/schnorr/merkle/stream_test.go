@@ -0,0 +1,184 @@
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+)
+
+// sliceLeaves yields data in order.
+type sliceLeaves struct {
+	data [][]byte
+}
+
+func (l *sliceLeaves) Next() ([]byte, error) {
+	if len(l.data) == 0 {
+		return nil, io.EOF
+	}
+	next := l.data[0]
+	l.data = l.data[1:]
+	return next, nil
+}
+
+// countLeaves yields n data points like leafData without holding them.
+type countLeaves struct {
+	next, n int
+}
+
+func (l *countLeaves) Next() ([]byte, error) {
+	if l.next == l.n {
+		return nil, io.EOF
+	}
+	l.next++
+	return []byte(fmt.Sprintf("leaf-%d", l.next-1)), nil
+}
+
+// errLeaves fails after yielding n data points.
+type errLeaves struct {
+	n int
+}
+
+var errRead = errors.New("read failed")
+
+func (l *errLeaves) Next() ([]byte, error) {
+	if l.n == 0 {
+		return nil, errRead
+	}
+	l.n--
+	return []byte("leaf"), nil
+}
+
+func TestStreamingRootMatchesTree(t *testing.T) {
+	sizes := []int{1000, 1024, 1025}
+	for n := 1; n <= 70; n++ {
+		sizes = append(sizes, n)
+	}
+
+	for _, n := range sizes {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			data := leafData(n)
+			tree, err := New(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Out of order and with a repeat, as a caller may ask.
+			indices := []int{n - 1, 0, n / 2, n - 1}
+			root, proofs, err := StreamingRoot(&sliceLeaves{data}, indices)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(root, tree.Root()) {
+				t.Fatalf("root %x, want %x", root, tree.Root())
+			}
+
+			for i, index := range indices {
+				want, err := tree.Proof(index)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(proofs[i]) != len(want) {
+					t.Fatalf("proof %d has %d siblings, want %d", index, len(proofs[i]), len(want))
+				}
+				for level := range want {
+					if !bytes.Equal(proofs[i][level], want[level]) {
+						t.Errorf("proof %d level %d is %x, want %x", index, level, proofs[i][level], want[level])
+					}
+				}
+				if !VerifyProof(root, data[index], proofs[i], index) {
+					t.Errorf("proof %d does not verify", index)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamingRootRejects(t *testing.T) {
+	if _, _, err := StreamingRoot(&sliceLeaves{}, nil); !errors.Is(err, ErrNoLeaves) {
+		t.Errorf("no leaves: got %v, want ErrNoLeaves", err)
+	}
+	for _, index := range []int{-1, 4} {
+		if _, _, err := StreamingRoot(&sliceLeaves{leafData(4)}, []int{index}); err == nil {
+			t.Errorf("index %d of 4: no error", index)
+		}
+	}
+	if _, _, err := StreamingRoot(&errLeaves{3}, nil); !errors.Is(err, errRead) {
+		t.Errorf("read error: got %v, want it returned", err)
+	}
+}
+
+func BenchmarkStreamingRoot(b *testing.B) {
+	for _, n := range []int{256, 4096} {
+		data := leafData(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, _, err := StreamingRoot(&sliceLeaves{data}, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// peakLeaves passes on leaves and samples the live heap every 1<<16 of
+// them, keeping the largest sample.
+type peakLeaves struct {
+	Leaves
+	read int
+	peak uint64
+}
+
+func (l *peakLeaves) Next() ([]byte, error) {
+	l.read++
+	if l.read%(1<<16) == 0 {
+		l.sample()
+	}
+	return l.Leaves.Next()
+}
+
+func (l *peakLeaves) sample() {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	l.peak = max(l.peak, stats.HeapAlloc)
+}
+
+// Both builders allocate about the same per hash, so B/op says little;
+// peak-MiB is the live heap, which New grows with the whole tree and
+// StreamingRoot keeps at one node per level.
+func BenchmarkRootMillion(b *testing.B) {
+	const n = 1 << 20
+
+	b.Run("tree", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			leaves := &peakLeaves{Leaves: &countLeaves{n: n}}
+			data := make([][]byte, 0, n)
+			for {
+				leaf, err := leaves.Next()
+				if err == io.EOF {
+					break
+				}
+				data = append(data, leaf)
+			}
+			tree, err := New(data)
+			if err != nil {
+				b.Fatal(err)
+			}
+			leaves.sample()
+			runtime.KeepAlive(tree)
+			b.ReportMetric(float64(leaves.peak)/(1<<20), "peak-MiB")
+		}
+	})
+	b.Run("streaming", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			leaves := &peakLeaves{Leaves: &countLeaves{n: n}}
+			if _, _, err := StreamingRoot(leaves, []int{0, n - 1}); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportMetric(float64(leaves.peak)/(1<<20), "peak-MiB")
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/TimeleapLabs/go-schnorr/merkle"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+// merkleRoot prints the root of the tree over the lines of a file
+// without loading a key, so the tree can be built on one host and the
+// root signed on another with sign -hash-only. Leaves and nodes are
+// hashed as described in the merkle package: leaf = keccak256(0x00 ||
+// data), node = keccak256(0x01 || left || right), with the leaf level
+// padded with zero hashes to a power of two. A single leaf is its own
+// root, so its root is keccak256(0x00 || data).
+func merkleRoot(args []string) {
+	flags := flag.NewFlagSet("merkle-root", flag.ExitOnError)
+	in := flags.String("in", "", "file with one leaf per line, hex (0x-prefixed) or raw")
+	parseFlags(flags, args)
+
+	if *in == "" {
+		fatal(usageError("-in is required"))
+	}
+
+	file, err := os.Open(*in)
+	if err != nil {
+		fatal(inputError("Error reading leaves: %w", err))
+	}
+	defer file.Close()
+
+	// The leaves are hashed as they are read, so the file is never held
+	// in memory.
+	leaves := &lineLeaves{reader: bufio.NewReader(file)}
+	root, _, err := merkle.StreamingRoot(leaves, nil)
+	if errors.Is(err, merkle.ErrNoLeaves) {
+		fatal(inputError("No leaves found in %s", *in))
+	}
+	if err != nil {
+		fatal(inputError("Error reading leaves: %w", err))
+	}
+
+	debugf("Leaves: %d", leaves.count)
+	fmt.Printf("Root: 0x%x\n", root)
+}
+
+// lineLeaves yields the non-blank lines of a file as merkle leaves,
+// with surrounding whitespace trimmed, decoding those starting with 0x
+// as hex like readBatchLines and batchDigest do.
+type lineLeaves struct {
+	reader *bufio.Reader
+	number int
+	count  int
+}
+
+func (l *lineLeaves) Next() ([]byte, error) {
+	for {
+		line, err := l.reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		l.number++
+
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			l.count++
+			if !bytes.HasPrefix(line, []byte("0x")) {
+				return line, nil
+			}
+			leaf, decodeErr := signer.DecodeHex(string(line))
+			if decodeErr != nil {
+				return nil, fmt.Errorf("line %d: %w", l.number, decodeErr)
+			}
+			return leaf, nil
+		}
+
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TimeleapLabs/go-schnorr/merkle"
+	"golang.org/x/crypto/sha3"
+)
+
+func TestMerkleRoot(t *testing.T) {
+	tests := []struct {
+		name   string
+		lines  string
+		leaves []string
+	}{
+		{"no trailing newline", "alpha\nbravo", []string{"alpha", "bravo"}},
+		{"hex and raw", "0x616c706861\nbravo\r\n0x\n0xff00\n", []string{"alpha", "bravo", "", "\xff\x00"}},
+		{"blank lines", "\n  \nalpha\n\t\r\n\n bravo \n\n", []string{"alpha", "bravo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "leaves"), []byte(tt.lines), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			res := cliRun{dir: dir}.run(t, "merkle-root", "-in", "leaves")
+			if res.code != 0 {
+				t.Fatalf("exit %d: %s", res.code, res.stderr)
+			}
+
+			data := make([][]byte, len(tt.leaves))
+			for i, leaf := range tt.leaves {
+				data[i] = []byte(leaf)
+			}
+			tree, err := merkle.New(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := field(t, res.stdout, "Root"), fmt.Sprintf("0x%x", tree.Root()); got != want {
+				t.Errorf("root %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+// A single leaf is its own root, hashed as a leaf.
+func TestMerkleRootSingleLeaf(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "leaves"), []byte("alpha\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res := cliRun{dir: dir}.run(t, "merkle-root", "-in", "leaves")
+	if res.code != 0 {
+		t.Fatalf("exit %d: %s", res.code, res.stderr)
+	}
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte("\x00alpha"))
+	want := fmt.Sprintf("0x%x", h.Sum(nil))
+	if got := field(t, res.stdout, "Root"); got != want {
+		t.Errorf("root %s, want keccak256(0x00 || leaf) = %s", got, want)
+	}
+}
+
+func TestMerkleRootRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines string
+		want  int
+	}{
+		{"empty", "", exitInput},
+		{"only blank lines", "\n \n\t\n", exitInput},
+		{"bad hex", "alpha\n0xzz\n", exitInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "leaves"), []byte(tt.lines), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			res := cliRun{dir: dir}.run(t, "merkle-root", "-in", "leaves")
+			if res.code != tt.want {
+				t.Errorf("exit %d, want %d: %s", res.code, tt.want, res.stderr)
+			}
+		})
+	}
+
+	if res := (cliRun{}).run(t, "merkle-root", "-in", "missing"); res.code != exitInput {
+		t.Errorf("missing file: exit %d, want %d", res.code, exitInput)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/TimeleapLabs/go-schnorr/eip712"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+// nftPrices signs a ProofOfStake NftPrices payload and prints the
+// signature together with the setNftPrices calldata to submit. The
+// contract's payload has no expiry; a signed update stays valid until
+// its nonce is used.
+func nftPrices(args []string) {
+	flags := flag.NewFlagSet("nft-prices", flag.ExitOnError)
+	key := addKeyFlags(flags)
+	nftsList := flags.String("nfts", "", "comma-separated nft ids, strictly increasing")
+	pricesList := flags.String("prices", "", "comma-separated prices, one per nft")
+	nonceText := flags.String("nonce", "", "payload nonce, decimal or 0x-prefixed hex")
+	chainIDText := flags.String("chain-id", "", "chain id of the ProofOfStake deployment")
+	contractHex := flags.String("contract", "", "0x-prefixed ProofOfStake contract address")
+	deterministic := flags.Bool("deterministic", false, "derive the nonce from key and message only (RFC6979)")
+	sigLayoutName := addSigLayoutFlag(flags)
+	parseFlags(flags, args)
+
+	sigLayout := parseSigLayout(*sigLayoutName)
+
+	if *nftsList == "" || *pricesList == "" || *nonceText == "" || *chainIDText == "" || *contractHex == "" {
+		fatal(usageError("-nfts, -prices, -nonce, -chain-id and -contract are required"))
+	}
+
+	payload := &eip712.NftPrices{}
+	var err error
+	if payload.Nfts, err = parseUint256List(*nftsList); err != nil {
+		fatal(inputError("Error parsing -nfts: %w", err))
+	}
+	if payload.Prices, err = parseUint256List(*pricesList); err != nil {
+		fatal(inputError("Error parsing -prices: %w", err))
+	}
+	if payload.Nonce, err = parseUint256(*nonceText); err != nil {
+		fatal(inputError("Error parsing -nonce: %w", err))
+	}
+	if problems := payload.Validate(); len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Printf("Problem: %v\n", problem)
+		}
+		fatal(inputError("Payload has %d problem(s)", len(problems)))
+	}
+
+	chainID, err := parseUint256(*chainIDText)
+	if err != nil || chainID.Int().Sign() == 0 {
+		fatal(inputError("Error parsing -chain-id: must be a non-zero uint256"))
+	}
+	contract, err := signer.ParseAddress(*contractHex)
+	if err != nil {
+		fatal(inputError("Error parsing -contract: %w", err))
+	}
+	if contract == (signer.Address{}) {
+		fatal(inputError("Error parsing -contract: zero address"))
+	}
+
+	separator, err := eip712.Domain{
+		Name:              eip712.ProofOfStakeName,
+		Version:           eip712.ProofOfStakeVersion,
+		ChainID:           chainID.Int(),
+		VerifyingContract: contract,
+	}.Separator()
+	if err != nil {
+		fatal(inputError("Error hashing domain: %w", err))
+	}
+
+	nfts, prices, nonce := uint256Ints(payload.Nfts), uint256Ints(payload.Prices), payload.Nonce.Int()
+	hash, err := eip712.HashNftPrices(separator, nfts, prices, nonce)
+	if err != nil {
+		fatal(inputError("Error hashing prices: %w", err))
+	}
+
+	privateKey, _ := key.load()
+	s := newSigner(privateKey, *deterministic)
+	defer zeroSigner(s)
+
+	sig, err := s.Sign(hash)
+	if err != nil {
+		fatal(signError("Error signing prices: %w", err))
+	}
+
+	// The calldata is always in the contract's order, -sig-layout only
+	// applies to the printed signature.
+	calldata, err := eip712.EncodeSetNftPrices(nfts, prices, nonce, sig.Serialize())
+	if err != nil {
+		fatal(fmt.Errorf("Error encoding calldata: %w", err))
+	}
+	serialized, err := signer.SerializeSignature(sig, sigLayout)
+	if err != nil {
+		fatal(signError("Error serializing signature: %w", err))
+	}
+
+	fmt.Printf("Public key: 0x%x\n", signer.XOnlyPubKey(s.PublicKey()))
+	fmt.Printf("Message: 0x%x\n", hash)
+	fmt.Printf("Signature: 0x%x\n", serialized)
+	fmt.Printf("Calldata: 0x%x\n", calldata)
+}
+
+// parseUint256 parses a decimal or 0x-prefixed hex uint256.
+func parseUint256(text string) (*eip712.Uint256, error) {
+	value := new(eip712.Uint256)
+	if err := value.UnmarshalJSON([]byte(strings.TrimSpace(text))); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func parseUint256List(text string) ([]*eip712.Uint256, error) {
+	var values []*eip712.Uint256
+	for i, field := range strings.Split(text, ",") {
+		value, err := parseUint256(field)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func uint256Ints(values []*eip712.Uint256) []*big.Int {
+	ints := make([]*big.Int, len(values))
+	for i, v := range values {
+		ints[i] = v.Int()
+	}
+	return ints
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/TimeleapLabs/go-schnorr/eip712"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/TimeleapLabs/go-schnorr/vectors"
+)
+
+// nftPricesArgs signs the EIP-712 NftPrices vector's payload, nfts
+// [1, 2] priced at [1e18, 16] with nonce 0, for its hardhat deployment.
+func nftPricesArgs(extra ...string) []string {
+	return append([]string{
+		"nft-prices",
+		"-nfts", "1,2",
+		"-prices", "1000000000000000000,0x10",
+		"-nonce", "0",
+		"-chain-id", "31337",
+		"-contract", "0x5FbDB2315678afecb367f032d93F642f64180aa3",
+		"-deterministic",
+	}, extra...)
+}
+
+func TestNftPricesMatchesVector(t *testing.T) {
+	var vector *vectors.Vector
+	for i := range vectors.Vectors {
+		if vectors.Vectors[i].Name == "EIP-712 NftPrices" {
+			vector = &vectors.Vectors[i]
+		}
+	}
+	if vector == nil {
+		t.Fatal("no EIP-712 NftPrices vector")
+	}
+
+	res := runCLI(t, nftPricesArgs()...)
+	if res.code != 0 {
+		t.Fatalf("exit %d: %s", res.code, res.stderr)
+	}
+
+	if got := field(t, res.stdout, "Public key"); got != testPubKey {
+		t.Errorf("public key is %s, want %s", got, testPubKey)
+	}
+	if got := field(t, res.stdout, "Message"); got != vector.Digest {
+		t.Errorf("message is %s, want the vector's %s", got, vector.Digest)
+	}
+	if got := field(t, res.stdout, "Signature"); got != vector.Signature {
+		t.Errorf("signature is %s, want the vector's %s", got, vector.Signature)
+	}
+
+	// The calldata itself is pinned by vectors.CheckNftPricesCalldata
+	// and the eip712 tests.
+	if err := vectors.CheckNftPricesCalldata(); err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signer.DecodeHex(vector.Signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oneEther := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	calldata, err := eip712.EncodeSetNftPrices([]*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{oneEther, big.NewInt(16)}, big.NewInt(0), sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := field(t, res.stdout, "Calldata"), fmt.Sprintf("0x%x", calldata); got != want {
+		t.Errorf("calldata is\n%s\nwant\n%s", got, want)
+	}
+
+	// -sig-layout changes the printed signature, not the calldata.
+	swapped := runCLI(t, nftPricesArgs("-sig-layout", "s-r")...)
+	if swapped.code != 0 {
+		t.Fatalf("exit %d: %s", swapped.code, swapped.stderr)
+	}
+	if field(t, swapped.stdout, "Calldata") != field(t, res.stdout, "Calldata") {
+		t.Error("-sig-layout changed the calldata")
+	}
+	if want := "0x" + vector.Signature[66:] + vector.Signature[2:66]; field(t, swapped.stdout, "Signature") != want {
+		t.Errorf("s-r signature is %s, want %s", field(t, swapped.stdout, "Signature"), want)
+	}
+}
+
+func TestNftPricesRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"not increasing", nftPricesArgs("-nfts", "2,1"), exitInput},
+		{"repeated nft", nftPricesArgs("-nfts", "1,1"), exitInput},
+		{"fewer prices", nftPricesArgs("-prices", "5"), exitInput},
+		{"negative price", nftPricesArgs("-prices", "-1,5"), exitInput},
+		{"price past uint256", nftPricesArgs("-prices", "1,0x1"+strings.Repeat("0", 64)), exitInput},
+		{"zero chain id", nftPricesArgs("-chain-id", "0"), exitInput},
+		{"zero contract", nftPricesArgs("-contract", "0x"+strings.Repeat("0", 40)), exitInput},
+		{"missing nonce", nftPricesArgs("-nonce", ""), exitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if res := runCLI(t, tt.args...); res.code != tt.want {
+				t.Errorf("exit %d, want %d: %s", res.code, tt.want, res.stderr)
+			}
+		})
+	}
+}
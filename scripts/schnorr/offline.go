@@ -0,0 +1,209 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/TimeleapLabs/go-schnorr/offline"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// makeRequest prints a signing request for an air-gapped signer. The
+// message, hash, domain and nonce flags mean the same as for sign and
+// are carried in the request, so the signer computes the same digest.
+func makeRequest(args []string) {
+	flags := flag.NewFlagSet("make-request", flag.ExitOnError)
+	input := addInputFlags(flags)
+	input.now = time.Now
+	note := flags.String("note", "", "free text shown to the signer, such as what the message is for")
+	parseFlags(flags, args)
+
+	if _, err := input.hash.newHasher(); err != nil {
+		fatal(usageError("Error parsing -hash: %w", err))
+	}
+	if *input.timestamp != "" {
+		fatal(usageError("-timestamp cannot be carried in a request"))
+	}
+
+	domain, err := input.domainTag()
+	if err != nil {
+		fatal(err)
+	}
+
+	nonce, err := input.nonceValue()
+	if err != nil {
+		fatal(err)
+	}
+
+	message, err := input.read()
+	if err != nil {
+		fatal(inputError("Error reading message: %w", err))
+	}
+
+	if *input.hashOnly {
+		message, err = signer.DecodeHash(string(message))
+		if err != nil {
+			fatal(inputError("Error reading message: %w", err))
+		}
+	}
+
+	request := &offline.Request{
+		Hash:     *input.hash.name,
+		HashTag:  *input.hash.tag,
+		Domain:   string(domain),
+		Note:     *note,
+		Nonce:    nonce,
+		IsDigest: *input.hashOnly,
+		Message:  message,
+	}
+
+	digest, err := request.Digest()
+	if err != nil {
+		fatal(inputError("Error hashing message: %w", err))
+	}
+
+	text, err := offline.EncodeRequest(request)
+	if err != nil {
+		fatal(inputError("Error encoding request: %w", err))
+	}
+
+	fmt.Printf("Message: 0x%x\n", digest)
+	fmt.Printf("Request: %s\n", text)
+}
+
+// signRequest signs a request from make-request on the air-gapped host
+// and prints the response to carry back. What is being signed is logged
+// first so the operator can check it.
+func signRequest(args []string) {
+	flags := flag.NewFlagSet("sign-request", flag.ExitOnError)
+	key := addKeyFlags(flags)
+	requestText := flags.String("request", "", "request text from make-request")
+	requestFile := flags.String("request-file", "", "read the request text from a file")
+	deterministic := flags.Bool("deterministic", false, "derive the nonce from key and message only (RFC6979)")
+	parseFlags(flags, args)
+
+	request := readRequest(*requestText, *requestFile)
+
+	digest, err := request.Digest()
+	if err != nil {
+		fatal(inputError("Error hashing request: %w", err))
+	}
+
+	if request.Note != "" {
+		infof("Note: %s", request.Note)
+	}
+	if request.IsDigest {
+		infof("Digest given directly, the message is not known")
+	} else {
+		infof("Hash: %s, domain: %q, message: %q", request.Hash, request.Domain, request.Message)
+	}
+	if request.Nonce != nil {
+		infof("Nonce: %s", request.Nonce)
+	}
+
+	privateKey, _ := key.load()
+	s := newSigner(privateKey, *deterministic)
+	defer zeroSigner(s)
+
+	response, err := offline.Sign(s, request)
+	if err != nil {
+		fatal(signError("Error signing request: %w", err))
+	}
+
+	text, err := offline.EncodeResponse(response)
+	if err != nil {
+		fatal(fmt.Errorf("Error encoding response: %w", err))
+	}
+
+	fmt.Printf("Message: 0x%x\n", digest)
+	fmt.Printf("Response: %s\n", text)
+}
+
+// checkResponse checks a response from sign-request against the request
+// it answers and prints the signature for submission.
+func checkResponse(args []string) {
+	flags := flag.NewFlagSet("check-response", flag.ExitOnError)
+	requestText := flags.String("request", "", "request text from make-request")
+	requestFile := flags.String("request-file", "", "read the request text from a file")
+	responseText := flags.String("response", "", "response text from sign-request")
+	responseFile := flags.String("response-file", "", "read the response text from a file")
+	publicKeyHex := flags.String("pubkey", "", "0x-prefixed public key X coordinate the response must be signed by")
+	sigLayoutName := addSigLayoutFlag(flags)
+	parseFlags(flags, args)
+
+	sigLayout := parseSigLayout(*sigLayoutName)
+
+	request := readRequest(*requestText, *requestFile)
+
+	text, err := readText(*responseText, *responseFile, "response")
+	if err != nil {
+		fatal(err)
+	}
+
+	response, err := offline.DecodeResponse(text)
+	if err != nil {
+		fatal(inputError("Error decoding response: %w", err))
+	}
+
+	var publicKey *btcec.PublicKey
+	if *publicKeyHex != "" {
+		publicKey, err = signer.ParsePublicKey(*publicKeyHex)
+		if err != nil {
+			fatal(inputError("Error parsing public key: %w", err))
+		}
+	}
+
+	if err := offline.CheckResponse(request, response, publicKey); err != nil {
+		fatal(verifyError("Response is invalid: %w", err))
+	}
+
+	signature, err := schnorr.ParseSignature(response.Signature[:])
+	if err != nil {
+		fatal(verifyError("Response is invalid: %w", err))
+	}
+	serialized, err := signer.SerializeSignature(signature, sigLayout)
+	if err != nil {
+		fatal(fmt.Errorf("Error serializing signature: %w", err))
+	}
+
+	fmt.Printf("Public key: 0x%x\n", response.PublicKey)
+	fmt.Printf("Message: 0x%x\n", response.MessageHash)
+	fmt.Printf("Signature: 0x%x\n", serialized)
+	infof("Response is valid")
+}
+
+func readRequest(text, path string) *offline.Request {
+	text, err := readText(text, path, "request")
+	if err != nil {
+		fatal(err)
+	}
+
+	request, err := offline.DecodeRequest(text)
+	if err != nil {
+		fatal(inputError("Error decoding request: %w", err))
+	}
+	return request
+}
+
+// readText returns the -name flag value or the contents of the
+// -name-file, exactly one of which must be given.
+func readText(text, path, name string) (string, error) {
+	switch {
+	case text != "" && path != "":
+		return "", usageError("only one of -%s or -%s-file may be used", name, name)
+	case path != "":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", inputError("Error reading %s: %w", name, err)
+		}
+		return string(data), nil
+	case text != "":
+		return text, nil
+	default:
+		return "", usageError("-%s or -%s-file is required", name, name)
+	}
+}
@@ -0,0 +1,272 @@
+// Package offline carries signing requests to an air-gapped signer and
+// the signed attestations back as short text, suitable for copying by
+// hand or through a QR code. A request holds the message and everything
+// that goes into its digest; a response is an attestation of that
+// digest.
+//
+// Both are written as a prefix naming the kind and version followed by
+// unpadded base64url of a binary body:
+//
+//	unchained-request:1:<body>
+//	unchained-response:1:<body>
+//
+// A request body is, with lengths as uvarints:
+//
+//	1       flags: bit 0 nonce present, bit 1 message is a digest
+//	len+n   hash name
+//	len+n   bip340 hash tag
+//	len+n   domain
+//	len+n   note, free text shown to the signer
+//	32      nonce, if flag bit 0 is set
+//	len+n   message
+//
+// A response body is the attestation binary layout.
+package offline
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/TimeleapLabs/go-schnorr/attestation"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+const (
+	RequestPrefix  = "unchained-request:1:"
+	ResponsePrefix = "unchained-response:1:"
+)
+
+// MaxFieldSize bounds every length-prefixed request field.
+const MaxFieldSize = 1 << 20
+
+const (
+	flagNonce = 1 << iota
+	flagDigest
+)
+
+var (
+	ErrPrefix   = errors.New("unknown prefix or version")
+	ErrEncoding = errors.New("malformed encoding")
+	ErrMismatch = errors.New("response does not match the request")
+)
+
+var encoding = base64.RawURLEncoding
+
+// Request is a message to sign and how to hash it. With IsDigest set,
+// Message is the 32-byte digest itself and the hash fields are unused.
+type Request struct {
+	Hash     string
+	HashTag  string
+	Domain   string
+	Note     string
+	Nonce    *big.Int
+	IsDigest bool
+	Message  []byte
+}
+
+// Digest returns the 32-byte hash the request asks to be signed, the
+// same one sign computes from the equivalent flags.
+func (r *Request) Digest() ([]byte, error) {
+	if r.IsDigest {
+		if len(r.Message) != 32 {
+			return nil, fmt.Errorf("%w: digest must be 32 bytes, got %d", ErrEncoding, len(r.Message))
+		}
+		return bytes.Clone(r.Message), nil
+	}
+
+	hasher, err := signer.NewHasher(r.Hash, []byte(r.HashTag))
+	if err != nil {
+		return nil, err
+	}
+
+	message := r.Message
+	if r.Nonce != nil {
+		message, err = signer.EncodeWithNonce(r.Nonce, message)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Domain != "" {
+		return signer.HashWithDomain(hasher, []byte(r.Domain), message), nil
+	}
+	return hasher.Hash(message), nil
+}
+
+// EncodeRequest returns the text form of r.
+func EncodeRequest(r *Request) (string, error) {
+	var flags byte
+	if r.Nonce != nil {
+		if r.Nonce.Sign() < 0 || r.Nonce.BitLen() > 256 {
+			return "", attestation.ErrNonceRange
+		}
+		flags |= flagNonce
+	}
+	if r.IsDigest {
+		flags |= flagDigest
+	}
+
+	body := []byte{flags}
+	for _, field := range []string{r.Hash, r.HashTag, r.Domain, r.Note} {
+		body = appendField(body, []byte(field))
+	}
+	if r.Nonce != nil {
+		var nonce [32]byte
+		r.Nonce.FillBytes(nonce[:])
+		body = append(body, nonce[:]...)
+	}
+	body = appendField(body, r.Message)
+
+	return RequestPrefix + encoding.EncodeToString(body), nil
+}
+
+// DecodeRequest parses the text form of a request.
+func DecodeRequest(text string) (*Request, error) {
+	body, err := decodeText(text, RequestPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) == 0 {
+		return nil, fmt.Errorf("%w: empty request", ErrEncoding)
+	}
+	flags, rest := body[0], body[1:]
+	if flags&^(flagNonce|flagDigest) != 0 {
+		return nil, fmt.Errorf("%w: unknown flags 0x%02x", ErrEncoding, flags)
+	}
+
+	r := &Request{IsDigest: flags&flagDigest != 0}
+	for _, dst := range []*string{&r.Hash, &r.HashTag, &r.Domain, &r.Note} {
+		var field []byte
+		field, rest, err = readField(rest)
+		if err != nil {
+			return nil, err
+		}
+		*dst = string(field)
+	}
+
+	if flags&flagNonce != 0 {
+		if len(rest) < 32 {
+			return nil, fmt.Errorf("%w: nonce is truncated", ErrEncoding)
+		}
+		r.Nonce = new(big.Int).SetBytes(rest[:32])
+		rest = rest[32:]
+	}
+
+	r.Message, rest, err = readField(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrEncoding, len(rest))
+	}
+	return r, nil
+}
+
+// Sign signs the request's digest with s and returns the attestation to
+// carry back, with the request's nonce attached. Requests carry no
+// timestamp, so the attestation has none either.
+func Sign(s signer.Signer, r *Request) (*attestation.Attestation, error) {
+	digest, err := r.Digest()
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := s.Sign(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := attestation.New(digest, s.PublicKey(), sig)
+	if err != nil {
+		return nil, err
+	}
+	a.Nonce = r.Nonce
+	return a, nil
+}
+
+// EncodeResponse returns the text form of a.
+func EncodeResponse(a *attestation.Attestation) (string, error) {
+	body, err := a.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return ResponsePrefix + encoding.EncodeToString(body), nil
+}
+
+// DecodeResponse parses the text form of a response.
+func DecodeResponse(text string) (*attestation.Attestation, error) {
+	body, err := decodeText(text, ResponsePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &attestation.Attestation{}
+	if err := a.UnmarshalBinary(body); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// CheckResponse checks that a is a valid signature over r's digest,
+// carries r's nonce and no timestamp and, if pub is not nil, was made
+// by pub.
+func CheckResponse(r *Request, a *attestation.Attestation, pub *btcec.PublicKey) error {
+	digest, err := r.Digest()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !bytes.Equal(a.MessageHash[:], digest):
+		return fmt.Errorf("%w: signed hash 0x%x, requested 0x%x", ErrMismatch, a.MessageHash, digest)
+	case (a.Nonce == nil) != (r.Nonce == nil) || a.Nonce != nil && a.Nonce.Cmp(r.Nonce) != 0:
+		return fmt.Errorf("%w: nonce differs", ErrMismatch)
+	case a.Timestamp != nil:
+		return fmt.Errorf("%w: requests carry no timestamp", ErrMismatch)
+	case pub != nil && !bytes.Equal(a.PublicKey[:], signer.XOnlyPubKey(pub)):
+		return fmt.Errorf("%w: signed by 0x%x", ErrMismatch, a.PublicKey)
+	case !a.Verify():
+		return signer.ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func decodeText(text, prefix string) ([]byte, error) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, prefix) {
+		return nil, fmt.Errorf("%w: want %s", ErrPrefix, prefix)
+	}
+
+	body, err := encoding.DecodeString(strings.TrimPrefix(text, prefix))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncoding, err)
+	}
+	return body, nil
+}
+
+func appendField(body, field []byte) []byte {
+	body = binary.AppendUvarint(body, uint64(len(field)))
+	return append(body, field...)
+}
+
+func readField(body []byte) ([]byte, []byte, error) {
+	size, n := binary.Uvarint(body)
+	if n <= 0 {
+		return nil, nil, fmt.Errorf("%w: bad field length", ErrEncoding)
+	}
+	body = body[n:]
+
+	if size > MaxFieldSize || size > uint64(len(body)) {
+		return nil, nil, fmt.Errorf("%w: field is truncated", ErrEncoding)
+	}
+	return body[:size], body[size:], nil
+}
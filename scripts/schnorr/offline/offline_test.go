@@ -0,0 +1,275 @@
+package offline
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+func testKey(t *testing.T, b byte) *btcec.PrivateKey {
+	t.Helper()
+
+	priv, err := signer.PrivateKeyFromBytes(bytes.Repeat([]byte{b}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+// testRequest is a request using every field.
+func testRequest() *Request {
+	return &Request{
+		Hash:    signer.HashKeccak256,
+		Domain:  "feeds",
+		Note:    "BTC/USD round 12",
+		Nonce:   big.NewInt(12),
+		Message: []byte("BTC/USD 65000"),
+	}
+}
+
+func sameRequest(a, b *Request) bool {
+	sameNonce := (a.Nonce == nil) == (b.Nonce == nil) && (a.Nonce == nil || a.Nonce.Cmp(b.Nonce) == 0)
+	return a.Hash == b.Hash && a.HashTag == b.HashTag && a.Domain == b.Domain && a.Note == b.Note &&
+		a.IsDigest == b.IsDigest && bytes.Equal(a.Message, b.Message) && sameNonce
+}
+
+func TestRequestRoundTrip(t *testing.T) {
+	maxNonce := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	digest := signer.HashMessage([]byte("digest"))
+
+	tests := []struct {
+		name    string
+		request *Request
+	}{
+		{"every field", testRequest()},
+		{"message only", &Request{Hash: signer.HashKeccak256, Message: []byte("hello")}},
+		{"empty message", &Request{Hash: signer.HashKeccak256, Message: []byte{}}},
+		{"bip340 tag", &Request{Hash: signer.HashBIP340, HashTag: signer.TagChallenge, Message: []byte("hello")}},
+		{"nonce 0", &Request{Hash: signer.HashKeccak256, Nonce: big.NewInt(0), Message: []byte("hello")}},
+		{"max nonce", &Request{Hash: signer.HashKeccak256, Nonce: maxNonce, Message: []byte("hello")}},
+		{"digest", &Request{IsDigest: true, Message: digest}},
+		{"binary message", &Request{Hash: signer.HashSHA256, Message: []byte{0, 0xff, '\n', '-'}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := EncodeRequest(tt.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(text, RequestPrefix) {
+				t.Errorf("request %q lacks the prefix", text)
+			}
+			// Only characters that survive copying by hand or a URL.
+			if strings.ContainsAny(strings.TrimPrefix(text, RequestPrefix), "+/=\n ") {
+				t.Errorf("request %q is not base64url", text)
+			}
+
+			got, err := DecodeRequest(text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !sameRequest(got, tt.request) {
+				t.Errorf("decoded %+v, want %+v", got, tt.request)
+			}
+		})
+	}
+}
+
+func TestRequestDigest(t *testing.T) {
+	r := testRequest()
+	got, err := r.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same digest sign computes from -domain feeds -nonce 12.
+	hasher, err := signer.NewHasher(signer.HashKeccak256, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := signer.EncodeWithNonce(big.NewInt(12), []byte("BTC/USD 65000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := signer.HashWithDomain(hasher, []byte("feeds"), encoded); !bytes.Equal(got, want) {
+		t.Errorf("digest 0x%x, want 0x%x", got, want)
+	}
+
+	// The note is shown to the signer, not signed.
+	r.Note = "something else"
+	if again, _ := r.Digest(); !bytes.Equal(again, got) {
+		t.Error("the note changed the digest")
+	}
+
+	if _, err := (&Request{IsDigest: true, Message: []byte{1, 2}}).Digest(); !errors.Is(err, ErrEncoding) {
+		t.Errorf("short digest: got %v, want ErrEncoding", err)
+	}
+}
+
+func TestResponseRoundTrip(t *testing.T) {
+	priv := testKey(t, 0x01)
+	s := signer.NewDeterministicSigner(priv)
+	r := testRequest()
+
+	signed, err := Sign(s, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := EncodeResponse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(text, ResponsePrefix) {
+		t.Errorf("response %q lacks the prefix", text)
+	}
+
+	// The request travels as text too.
+	requestText, err := EncodeRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decodedRequest, err := DecodeRequest(requestText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := DecodeResponse(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckResponse(decodedRequest, response, priv.PubKey()); err != nil {
+		t.Fatal(err)
+	}
+
+	digest, _ := r.Digest()
+	if !bytes.Equal(response.MessageHash[:], digest) || response.Nonce.Cmp(r.Nonce) != 0 {
+		t.Errorf("response signs 0x%x at nonce %s, want 0x%x at %s", response.MessageHash, response.Nonce, digest, r.Nonce)
+	}
+}
+
+// A response is only accepted for the exact request it answers, so the
+// request's metadata is bound to the signature.
+func TestCheckResponseRejects(t *testing.T) {
+	priv := testKey(t, 0x01)
+	response, err := Sign(signer.NewDeterministicSigner(priv), testRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes to the request which must invalidate the response.
+	changes := map[string]func(r *Request){
+		"domain":   func(r *Request) { r.Domain = "other" },
+		"nonce":    func(r *Request) { r.Nonce = big.NewInt(13) },
+		"no nonce": func(r *Request) { r.Nonce = nil },
+		"message":  func(r *Request) { r.Message = []byte("BTC/USD 1") },
+		"hash":     func(r *Request) { r.Hash = signer.HashSHA256 },
+	}
+	for name, change := range changes {
+		r := testRequest()
+		change(r)
+		if err := CheckResponse(r, response, priv.PubKey()); !errors.Is(err, ErrMismatch) {
+			t.Errorf("%s changed: got %v, want ErrMismatch", name, err)
+		}
+	}
+
+	// Same digest, nonce dropped from the response.
+	stripped := *response
+	stripped.Nonce = nil
+	if err := CheckResponse(testRequest(), &stripped, nil); !errors.Is(err, ErrMismatch) {
+		t.Errorf("response without nonce: got %v, want ErrMismatch", err)
+	}
+
+	// A timestamp the request never asked for.
+	stamped := *response
+	now := time.Unix(1700000000, 0)
+	stamped.Timestamp = &now
+	if err := CheckResponse(testRequest(), &stamped, nil); !errors.Is(err, ErrMismatch) {
+		t.Errorf("response with timestamp: got %v, want ErrMismatch", err)
+	}
+
+	if err := CheckResponse(testRequest(), response, testKey(t, 0x02).PubKey()); !errors.Is(err, ErrMismatch) {
+		t.Errorf("other signer: got %v, want ErrMismatch", err)
+	}
+
+	tampered := *response
+	tampered.Signature[63] ^= 1
+	if err := CheckResponse(testRequest(), &tampered, nil); !errors.Is(err, signer.ErrInvalidSignature) {
+		t.Errorf("tampered signature: got %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestDecodeRequestRejects(t *testing.T) {
+	valid, err := EncodeRequest(testRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := encoding.DecodeString(strings.TrimPrefix(valid, RequestPrefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(body []byte) string { return RequestPrefix + encoding.EncodeToString(body) }
+
+	// A message field claiming more than MaxFieldSize bytes.
+	oversized := []byte{0}
+	for i := 0; i < 4; i++ {
+		oversized = appendField(oversized, nil)
+	}
+	oversized = binary.AppendUvarint(oversized, MaxFieldSize+1)
+
+	tests := []struct {
+		name string
+		text string
+		want error
+	}{
+		{"response prefix", ResponsePrefix + strings.TrimPrefix(valid, RequestPrefix), ErrPrefix},
+		{"other version", strings.Replace(valid, ":1:", ":2:", 1), ErrPrefix},
+		{"not base64url", RequestPrefix + "a+b/", ErrEncoding},
+		{"empty", RequestPrefix, ErrEncoding},
+		{"unknown flags", encode(append([]byte{0x80}, body[1:]...)), ErrEncoding},
+		{"truncated", encode(body[:len(body)-1]), ErrEncoding},
+		{"trailing bytes", encode(append(bytes.Clone(body), 0)), ErrEncoding},
+		{"nonce flag without nonce", encode([]byte{flagNonce, 0, 0, 0, 0}), ErrEncoding},
+		{"oversized field", encode(oversized), ErrEncoding},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeRequest(tt.text); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := DecodeRequest("  " + valid + "\n"); err != nil {
+		t.Errorf("surrounding whitespace: %v", err)
+	}
+}
+
+func TestEncodeRequestRejectsNonce(t *testing.T) {
+	for _, nonce := range []*big.Int{big.NewInt(-1), new(big.Int).Lsh(big.NewInt(1), 256)} {
+		r := testRequest()
+		r.Nonce = nonce
+		if _, err := EncodeRequest(r); err == nil {
+			t.Errorf("nonce %s was encoded", nonce)
+		}
+	}
+}
+
+func TestDecodeResponseRejects(t *testing.T) {
+	for _, text := range []string{
+		RequestPrefix + "AA",
+		ResponsePrefix + "a+b/",
+		ResponsePrefix + "AAAA",
+	} {
+		if _, err := DecodeResponse(text); err == nil {
+			t.Errorf("%q was decoded", text)
+		}
+	}
+}
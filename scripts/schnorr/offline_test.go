@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// field returns the value of the "label: value" line in output.
+func field(t *testing.T, output, label string) string {
+	t.Helper()
+
+	for _, line := range strings.Split(output, "\n") {
+		if value, ok := strings.CutPrefix(line, label+": "); ok {
+			return value
+		}
+	}
+	t.Fatalf("no %s in output:\n%s", label, output)
+	return ""
+}
+
+func TestOfflineSigning(t *testing.T) {
+	res := cliRun{}.run(t, "make-request", "-message", "BTC/USD 65000", "-domain", "feeds", "-nonce", "12", "-note", "round 12")
+	if res.code != 0 {
+		t.Fatalf("make-request: exit %d: %s", res.code, res.stderr)
+	}
+	request := field(t, res.stdout, "Request")
+	digest := field(t, res.stdout, "Message")
+
+	// The digest sign gives for the same flags.
+	direct := signJSON(t, "BTC/USD 65000", "-domain", "feeds", "-nonce", "12")
+	if digest != direct.MessageHash {
+		t.Fatalf("request digest %s, sign digest %s", digest, direct.MessageHash)
+	}
+
+	res = runCLI(t, "sign-request", "-request", request)
+	if res.code != 0 {
+		t.Fatalf("sign-request: exit %d: %s", res.code, res.stderr)
+	}
+	response := field(t, res.stdout, "Response")
+	for _, shown := range []string{"round 12", `"feeds"`, "Nonce: 12"} {
+		if !strings.Contains(res.stderr, shown) {
+			t.Errorf("sign-request did not show %s to the signer:\n%s", shown, res.stderr)
+		}
+	}
+
+	res = cliRun{}.run(t, "check-response", "-request", request, "-response", response, "-pubkey", testPubKey)
+	if res.code != 0 {
+		t.Fatalf("check-response: exit %d: %s", res.code, res.stderr)
+	}
+	if got := field(t, res.stdout, "Message"); got != digest {
+		t.Errorf("check-response message %s, want %s", got, digest)
+	}
+
+	// The same response against a request with another nonce.
+	res = cliRun{}.run(t, "make-request", "-message", "BTC/USD 65000", "-domain", "feeds", "-nonce", "13")
+	if res.code != 0 {
+		t.Fatalf("make-request: exit %d: %s", res.code, res.stderr)
+	}
+	res = cliRun{}.run(t, "check-response", "-request", field(t, res.stdout, "Request"), "-response", response)
+	if res.code != exitVerify {
+		t.Errorf("response for another request: exit %d, want %d: %s", res.code, exitVerify, res.stderr)
+	}
+
+	res = cliRun{}.run(t, "check-response", "-request", request, "-response", response, "-pubkey", "0x79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	if res.code != exitVerify {
+		t.Errorf("response from another signer: exit %d, want %d: %s", res.code, exitVerify, res.stderr)
+	}
+
+	res = cliRun{}.run(t, "check-response", "-request", request, "-response", "unchained-response:1:AAAA")
+	if res.code != exitInput {
+		t.Errorf("malformed response: exit %d, want %d", res.code, exitInput)
+	}
+}
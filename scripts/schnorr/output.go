@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+type signOutput struct {
+	PublicKey   string `json:"publicKey"`
+	Address     string `json:"address"`
+	MessageHash string `json:"messageHash"`
+	Signature   string `json:"signature"`
+	SignatureR  string `json:"signatureR"`
+	SignatureS  string `json:"signatureS"`
+	Nonce       string `json:"nonce,omitempty"`
+	Timestamp   string `json:"timestamp,omitempty"`
+}
+
+// addPubKeyFormatFlag registers -pubkey-format on flags.
+func addPubKeyFormatFlag(flags *flag.FlagSet) *string {
+	return flags.String("pubkey-format", string(signer.PubKeyXOnly), "public key encoding: xonly, compressed or uncompressed")
+}
+
+// parsePubKeyFormat validates the -pubkey-format flag value.
+func parsePubKeyFormat(name string) signer.PubKeyFormat {
+	format, err := signer.ParsePubKeyFormat(name)
+	if err != nil {
+		fatal(usageError("Error parsing -pubkey-format: %w", err))
+	}
+	return format
+}
+
+// addSigLayoutFlag registers -sig-layout on flags.
+func addSigLayoutFlag(flags *flag.FlagSet) *string {
+	return flags.String("sig-layout", string(signer.SigLayoutRS), "signature byte order: r-s, which the contract takes, or s-r")
+}
+
+// parseSigLayout validates the -sig-layout flag value.
+func parseSigLayout(name string) signer.SigLayout {
+	layout, err := signer.ParseSigLayout(name)
+	if err != nil {
+		fatal(usageError("Error parsing -sig-layout: %w", err))
+	}
+	return layout
+}
+
+func newSignOutput(publicKey *btcec.PublicKey, format signer.PubKeyFormat, layout signer.SigLayout, hash []byte, signature *schnorr.Signature) (signOutput, error) {
+	publicKeyBytes, err := signer.SerializePubKey(publicKey, format)
+	if err != nil {
+		return signOutput{}, err
+	}
+
+	r, s, err := signer.SplitSignature(signature)
+	if err != nil {
+		return signOutput{}, err
+	}
+
+	serialized, err := signer.SerializeSignature(signature, layout)
+	if err != nil {
+		return signOutput{}, err
+	}
+
+	return signOutput{
+		PublicKey:   fmt.Sprintf("0x%x", publicKeyBytes),
+		Address:     signer.EthereumAddress(publicKey).Hex(),
+		MessageHash: fmt.Sprintf("0x%x", pad32(hash)),
+		Signature:   fmt.Sprintf("0x%x", serialized),
+		SignatureR:  fmt.Sprintf("0x%x", pad32(r[:])),
+		SignatureS:  fmt.Sprintf("0x%x", pad32(s[:])),
+	}, nil
+}
+
+// pad32 left-pads b with zero bytes to exactly 32 bytes, the width of a
+// bytes32 or uint256 on chain, so a value with leading zero bytes keeps
+// its width in the output. b must be at most 32 bytes.
+func pad32(b []byte) [32]byte {
+	if len(b) > 32 {
+		panic(fmt.Sprintf("pad32: %d bytes do not fit in 32", len(b)))
+	}
+
+	var padded [32]byte
+	copy(padded[32-len(b):], b)
+	return padded
+}
+
+func (out signOutput) print(format string) {
+	switch format {
+	case "json":
+		err := json.NewEncoder(os.Stdout).Encode(out)
+		if err != nil {
+			fatal(fmt.Errorf("Error encoding output: %w", err))
+		}
+	default:
+		fmt.Printf("Public key: %s\n", out.PublicKey)
+		fmt.Printf("Address: %s\n", out.Address)
+		fmt.Printf("Message: %s\n", out.MessageHash)
+		if out.Nonce != "" {
+			fmt.Printf("Nonce: %s\n", out.Nonce)
+		}
+		if out.Timestamp != "" {
+			fmt.Printf("Timestamp: %s\n", out.Timestamp)
+		}
+		fmt.Printf("Signature: %s\n", out.Signature)
+		debugf("Signature R: %s", out.SignatureR)
+		debugf("Signature S: %s", out.SignatureS)
+	}
+}
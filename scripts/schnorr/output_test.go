@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+func TestPad32(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want string
+	}{
+		{nil, strings.Repeat("00", 32)},
+		{[]byte{1}, strings.Repeat("00", 31) + "01"},
+		{[]byte{0, 0, 0xab}, strings.Repeat("00", 29) + "0000ab"},
+		{bytes.Repeat([]byte{0xff}, 31), "00" + strings.Repeat("ff", 31)},
+		{bytes.Repeat([]byte{0xff}, 32), strings.Repeat("ff", 32)},
+	}
+
+	for _, tt := range tests {
+		padded := pad32(tt.in)
+		if got := fmt.Sprintf("%x", padded); got != tt.want {
+			t.Errorf("pad32(%x) is %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("pad32 accepted 33 bytes")
+		}
+	}()
+	pad32(make([]byte, 33))
+}
+
+// checkWidth checks that a 0x-prefixed hex field is exactly n bytes.
+func checkWidth(t *testing.T, name, value string, n int) {
+	t.Helper()
+
+	if !strings.HasPrefix(value, "0x") || len(value) != 2+2*n {
+		t.Errorf("%s is %s, want %d bytes", name, value, n)
+	}
+}
+
+// Every value below has high-order zero bytes: the public key of
+// private key 0x99 has an X coordinate starting with 00, R and S are
+// small integers and the hash starts with two zero bytes.
+func TestSignOutputKeepsLeadingZeros(t *testing.T) {
+	_, pub, err := signer.LoadKeyFromHex("0x" + strings.Repeat("00", 31) + "99")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r btcec.FieldVal
+	r.SetInt(0xab)
+	var s btcec.ModNScalar
+	s.SetInt(0xcd)
+	sig := schnorr.NewSignature(&r, &s)
+
+	hash := make([]byte, 32)
+	hash[2], hash[31] = 0x01, 0x02
+
+	small := strings.Repeat("00", 31)
+	tests := []struct {
+		format    signer.PubKeyFormat
+		layout    signer.SigLayout
+		pubBytes  int
+		signature string
+	}{
+		{signer.PubKeyXOnly, signer.SigLayoutRS, 32, "0x" + small + "ab" + small + "cd"},
+		{signer.PubKeyCompressed, signer.SigLayoutRS, 33, "0x" + small + "ab" + small + "cd"},
+		{signer.PubKeyUncompressed, signer.SigLayoutSR, 65, "0x" + small + "cd" + small + "ab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			out, err := newSignOutput(pub, tt.format, tt.layout, hash, sig)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checkWidth(t, "public key", out.PublicKey, tt.pubBytes)
+			checkWidth(t, "message hash", out.MessageHash, 32)
+			checkWidth(t, "signature", out.Signature, 64)
+			checkWidth(t, "R", out.SignatureR, 32)
+			checkWidth(t, "S", out.SignatureS, 32)
+			checkWidth(t, "address", out.Address, 20)
+
+			if tt.format == signer.PubKeyXOnly && !strings.HasPrefix(out.PublicKey, "0x00e3ae19") {
+				t.Errorf("public key is %s, want the X coordinate with its leading zero", out.PublicKey)
+			}
+			if want := "0x0000" + "01" + strings.Repeat("00", 28) + "02"; out.MessageHash != want {
+				t.Errorf("message hash is %s, want %s", out.MessageHash, want)
+			}
+			if out.SignatureR != "0x"+small+"ab" || out.SignatureS != "0x"+small+"cd" {
+				t.Errorf("R and S are %s and %s", out.SignatureR, out.SignatureS)
+			}
+			if out.Signature != tt.signature {
+				t.Errorf("signature is %s, want %s", out.Signature, tt.signature)
+			}
+		})
+	}
+}
+
+// A real signature whose S is below 2^248 keeps its width and still
+// verifies from the printed output.
+func TestSignOutputSmallS(t *testing.T) {
+	priv, pub, err := signer.LoadKeyFromHex(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// About one signature in 256 has a zero first byte of S.
+	for i := 0; i < 10000; i++ {
+		message := fmt.Sprintf("message %d", i)
+		hash := signer.HashMessage([]byte(message))
+		sig, err := signer.SignDeterministic(priv, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, s, _ := signer.SplitSignature(sig); s[0] != 0 {
+			continue
+		}
+
+		out, err := newSignOutput(pub, signer.PubKeyXOnly, signer.SigLayoutRS, hash, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkWidth(t, "S", out.SignatureS, 32)
+		checkWidth(t, "signature", out.Signature, 64)
+		if !strings.HasPrefix(out.SignatureS, "0x00") || out.Signature[66:68] != "00" {
+			t.Errorf("S lost its leading zero: %s, %s", out.SignatureS, out.Signature)
+		}
+
+		res := cliRun{}.run(t, "verify", "-message", message, "-pubkey", out.PublicKey, "-signature", out.Signature)
+		if res.code != 0 {
+			t.Errorf("printed signature does not verify: exit %d: %s", res.code, res.stderr)
+		}
+		return
+	}
+	t.Fatal("no signature with a small S found")
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+func pubkey(args []string) {
+	flags := flag.NewFlagSet("pubkey", flag.ExitOnError)
+	key := addKeyFlags(flags)
+	pubKeyFormatName := addPubKeyFormatFlag(flags)
+	compressed := flags.Bool("compressed", false, "also print the parity byte and compressed key")
+	parseFlags(flags, args)
+
+	pubKeyFormat := parsePubKeyFormat(*pubKeyFormatName)
+	_, publicKey := key.load()
+
+	serialized, err := signer.SerializePubKey(publicKey, pubKeyFormat)
+	if err != nil {
+		fatal(keyError("Error serializing public key: %w", err))
+	}
+	fmt.Printf("Public key: 0x%x\n", serialized)
+
+	if *compressed {
+		serialized := publicKey.SerializeCompressed()
+		fmt.Printf("Parity: 0x%02x\n", serialized[0])
+		fmt.Printf("Compressed: 0x%x\n", serialized)
+	}
+}
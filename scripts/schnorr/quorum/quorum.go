@@ -0,0 +1,58 @@
+// Package quorum checks off-chain whether the validators that signed a
+// root hold enough voting power between them, the way consensus decides
+// a round.
+package quorum
+
+import (
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// PubKey is a validator's x-only public key.
+type PubKey [schnorr.PubKeyBytesLen]byte
+
+// SignedBy is one validator's signature over a root.
+type SignedBy struct {
+	PublicKey PubKey
+	Signature [schnorr.SignatureSize]byte
+}
+
+// VerifyQuorum verifies each signature over root and sums the voting
+// power of the validators whose signature is valid. It reports whether
+// that total reaches required, along with the total.
+//
+// A validator is counted once however many valid signatures it appears
+// with. Invalid signatures, and signers missing from powers or with zero
+// power, add nothing.
+func VerifyQuorum(root []byte, sigs []SignedBy, powers map[PubKey]*big.Int, required *big.Int) (bool, *big.Int) {
+	total := new(big.Int)
+	counted := make(map[PubKey]bool, len(sigs))
+
+	for _, signed := range sigs {
+		power := powers[signed.PublicKey]
+		if counted[signed.PublicKey] || power == nil || power.Sign() <= 0 {
+			continue
+		}
+		if !verify(root, signed) {
+			continue
+		}
+
+		counted[signed.PublicKey] = true
+		total.Add(total, power)
+	}
+
+	return total.Cmp(required) >= 0, total
+}
+
+func verify(root []byte, signed SignedBy) bool {
+	pub, err := schnorr.ParsePubKey(signed.PublicKey[:])
+	if err != nil {
+		return false
+	}
+	sig, err := schnorr.ParseSignature(signed.Signature[:])
+	if err != nil {
+		return false
+	}
+	return sig.Verify(root, pub)
+}
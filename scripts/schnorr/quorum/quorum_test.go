@@ -0,0 +1,137 @@
+package quorum
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// testRoot is the root the tests' validators sign.
+var testRoot = signer.HashMessage([]byte("quorum root"))
+
+// testValidator returns the private key with every byte set to b and
+// its x-only public key.
+func testValidator(t testing.TB, b byte) (*btcec.PrivateKey, PubKey) {
+	t.Helper()
+
+	priv, err := signer.PrivateKeyFromBytes(bytes.Repeat([]byte{b}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pub PubKey
+	copy(pub[:], signer.XOnlyPubKey(priv.PubKey()))
+	return priv, pub
+}
+
+// signRoot returns priv's signature over root.
+func signRoot(t testing.TB, priv *btcec.PrivateKey, root []byte) SignedBy {
+	t.Helper()
+
+	sig, err := signer.SignDeterministic(priv, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := SignedBy{}
+	copy(signed.PublicKey[:], signer.XOnlyPubKey(priv.PubKey()))
+	copy(signed.Signature[:], sig.Serialize())
+	return signed
+}
+
+// quorumSetup returns signatures over testRoot by four validators with
+// powers 10, 20, 30 and 40, 100 in total.
+func quorumSetup(t *testing.T) ([]SignedBy, map[PubKey]*big.Int) {
+	t.Helper()
+
+	var sigs []SignedBy
+	powers := make(map[PubKey]*big.Int)
+	for i := 1; i <= 4; i++ {
+		priv, pub := testValidator(t, byte(i))
+		sigs = append(sigs, signRoot(t, priv, testRoot))
+		powers[pub] = big.NewInt(int64(10 * i))
+	}
+	return sigs, powers
+}
+
+func TestVerifyQuorumThreshold(t *testing.T) {
+	sigs, powers := quorumSetup(t)
+
+	tests := []struct {
+		name     string
+		sigs     []SignedBy
+		required int64
+		want     bool
+		total    int64
+	}{
+		// Validators 2 and 3 hold 50.
+		{"just below", sigs[1:3], 51, false, 50},
+		{"just at", sigs[1:3], 50, true, 50},
+		{"above", sigs[1:3], 49, true, 50},
+		{"every validator", sigs, 100, true, 100},
+		{"every validator, one short", sigs, 101, false, 100},
+		{"no signatures", nil, 1, false, 0},
+		{"no signatures, nothing required", nil, 0, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, total := VerifyQuorum(testRoot, tt.sigs, powers, big.NewInt(tt.required))
+			if ok != tt.want || total.Int64() != tt.total {
+				t.Errorf("got %v with %s, want %v with %d", ok, total, tt.want, tt.total)
+			}
+		})
+	}
+}
+
+func TestVerifyQuorumExcludes(t *testing.T) {
+	sigs, powers := quorumSetup(t)
+
+	tampered := sigs[3]
+	tampered.Signature[10] ^= 1
+
+	otherRoot := signer.HashMessage([]byte("other root"))
+	priv4, _ := testValidator(t, 4)
+	wrongRoot := signRoot(t, priv4, otherRoot)
+
+	priv5, _ := testValidator(t, 5)
+	unknown := signRoot(t, priv5, testRoot)
+
+	// Validator 6 is registered with zero power, and the all-zero key,
+	// which is not on the curve, with power 50.
+	priv6, pub6 := testValidator(t, 6)
+	zero := signRoot(t, priv6, testRoot)
+	garbageKey := SignedBy{Signature: sigs[0].Signature}
+	extended := map[PubKey]*big.Int{pub6: new(big.Int), garbageKey.PublicKey: big.NewInt(50)}
+	for pub, power := range powers {
+		extended[pub] = power
+	}
+
+	tests := []struct {
+		name   string
+		sigs   []SignedBy
+		powers map[PubKey]*big.Int
+		total  int64
+	}{
+		{"duplicate signer counts once", []SignedBy{sigs[0], sigs[0], sigs[1]}, powers, 30},
+		{"tampered signature", []SignedBy{sigs[0], tampered}, powers, 10},
+		{"signature over another root", []SignedBy{sigs[0], wrongRoot}, powers, 10},
+		// An invalid signature does not use up the signer's one count.
+		{"invalid then valid", []SignedBy{tampered, sigs[3]}, powers, 40},
+		{"unknown signer", []SignedBy{sigs[0], unknown}, powers, 10},
+		{"zero power", []SignedBy{sigs[0], zero}, extended, 10},
+		{"undecodable key", []SignedBy{sigs[0], garbageKey}, extended, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Required is set to the expected total, so an extra
+			// counted signer would still pass but show in the total.
+			ok, total := VerifyQuorum(testRoot, tt.sigs, tt.powers, big.NewInt(tt.total))
+			if !ok || total.Int64() != tt.total {
+				t.Errorf("got %v with %s, want total %d", ok, total, tt.total)
+			}
+		})
+	}
+}
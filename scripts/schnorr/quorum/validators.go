@@ -0,0 +1,113 @@
+package quorum
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+var (
+	ErrValidatorSet  = errors.New("invalid validator set")
+	ErrNotRegistered = errors.New("signer is not a registered validator")
+	ErrZeroPower     = errors.New("signer has zero voting power")
+)
+
+// Validator is a registered validator's key, identity and voting power.
+type Validator struct {
+	PublicKey PubKey
+	Name      string
+	Power     *big.Int
+}
+
+// ValidatorSet is the registered validators by x-only public key.
+type ValidatorSet struct {
+	validators map[PubKey]Validator
+}
+
+// validatorEntry is one element of a validator set file.
+type validatorEntry struct {
+	PublicKey string   `json:"publicKey"`
+	Name      string   `json:"name"`
+	Power     *big.Int `json:"power"`
+}
+
+// ParseValidatorSet decodes a validator set file, a JSON array of
+//
+//	{"publicKey": "0x<x-only key>", "name": "validator-1", "power": 2000000}
+//
+// with the power as a JSON integer. Unknown fields, missing or negative
+// powers and keys listed twice are rejected. A power of zero is
+// allowed: such a validator is registered but Lookup refuses it.
+func ParseValidatorSet(data []byte) (*ValidatorSet, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var entries []validatorEntry
+	if err := decoder.Decode(&entries); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrValidatorSet, err)
+	}
+
+	set := &ValidatorSet{validators: make(map[PubKey]Validator, len(entries))}
+	for i, entry := range entries {
+		pub, err := parsePubKey(entry.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: entry %d: %w", ErrValidatorSet, i, err)
+		}
+		if entry.Power == nil || entry.Power.Sign() < 0 {
+			return nil, fmt.Errorf("%w: entry %d: power must be a non-negative integer", ErrValidatorSet, i)
+		}
+		if _, ok := set.validators[pub]; ok {
+			return nil, fmt.Errorf("%w: entry %d: public key 0x%x is listed twice", ErrValidatorSet, i, pub)
+		}
+
+		set.validators[pub] = Validator{PublicKey: pub, Name: entry.Name, Power: entry.Power}
+	}
+
+	return set, nil
+}
+
+// Lookup returns the validator with key pub. It fails with
+// ErrNotRegistered if there is none and ErrZeroPower if its power is
+// zero.
+func (s *ValidatorSet) Lookup(pub PubKey) (Validator, error) {
+	validator, ok := s.validators[pub]
+	if !ok {
+		return Validator{}, fmt.Errorf("%w: 0x%x", ErrNotRegistered, pub)
+	}
+	if validator.Power.Sign() == 0 {
+		return validator, fmt.Errorf("%w: %q", ErrZeroPower, validator.Name)
+	}
+	return validator, nil
+}
+
+// Powers returns every validator's voting power, for VerifyQuorum.
+func (s *ValidatorSet) Powers() map[PubKey]*big.Int {
+	powers := make(map[PubKey]*big.Int, len(s.validators))
+	for pub, validator := range s.validators {
+		powers[pub] = validator.Power
+	}
+	return powers
+}
+
+func parsePubKey(text string) (PubKey, error) {
+	var pub PubKey
+
+	raw, err := signer.DecodeHex(text)
+	if err != nil {
+		return pub, fmt.Errorf("public key: %w", err)
+	}
+	if len(raw) != len(pub) {
+		return pub, fmt.Errorf("public key must be %d bytes, got %d", len(pub), len(raw))
+	}
+	if _, err := schnorr.ParsePubKey(raw); err != nil {
+		return pub, fmt.Errorf("public key: %w", err)
+	}
+
+	copy(pub[:], raw)
+	return pub, nil
+}
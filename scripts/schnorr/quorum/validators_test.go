@@ -0,0 +1,90 @@
+package quorum
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// validatorSetJSON returns a validator set file with the keys of
+// testValidator(1), (2) and (3) at powers 2000000, 0 and 2^70.
+func validatorSetJSON(t *testing.T) []byte {
+	t.Helper()
+
+	_, one := testValidator(t, 1)
+	_, two := testValidator(t, 2)
+	_, three := testValidator(t, 3)
+	return []byte(fmt.Sprintf(`[
+		{"publicKey": "0x%x", "name": "validator-1", "power": 2000000},
+		{"publicKey": "0x%x", "name": "retired", "power": 0},
+		{"publicKey": "0x%x", "name": "whale", "power": 1180591620717411303424}
+	]`, one, two, three))
+}
+
+func TestValidatorSetLookup(t *testing.T) {
+	set, err := ParseValidatorSet(validatorSetJSON(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, registered := testValidator(t, 1)
+	validator, err := set.Lookup(registered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if validator.PublicKey != registered || validator.Name != "validator-1" || validator.Power.String() != "2000000" {
+		t.Errorf("registered validator is %+v", validator)
+	}
+
+	_, whale := testValidator(t, 3)
+	if validator, err := set.Lookup(whale); err != nil || validator.Power.String() != "1180591620717411303424" {
+		t.Errorf("power past 64 bits: got %+v, %v", validator, err)
+	}
+
+	_, retired := testValidator(t, 2)
+	validator, err = set.Lookup(retired)
+	if !errors.Is(err, ErrZeroPower) {
+		t.Errorf("zero power: got %v, want ErrZeroPower", err)
+	}
+	if validator.Name != "retired" {
+		t.Errorf("zero power validator is %+v, want it returned with the error", validator)
+	}
+
+	_, unregistered := testValidator(t, 4)
+	if _, err := set.Lookup(unregistered); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("unregistered: got %v, want ErrNotRegistered", err)
+	}
+
+	powers := set.Powers()
+	if len(powers) != 3 || powers[registered].String() != "2000000" || powers[retired].Sign() != 0 {
+		t.Errorf("powers are %v", powers)
+	}
+}
+
+func TestParseValidatorSetRejects(t *testing.T) {
+	_, pub := testValidator(t, 1)
+	key := fmt.Sprintf("0x%x", pub)
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not an array", `{}`},
+		{"unknown field", `[{"publicKey": "` + key + `", "name": "a", "power": 1, "stake": 1}]`},
+		{"missing power", `[{"publicKey": "` + key + `", "name": "a"}]`},
+		{"negative power", `[{"publicKey": "` + key + `", "name": "a", "power": -1}]`},
+		{"fractional power", `[{"publicKey": "` + key + `", "name": "a", "power": 1.5}]`},
+		{"string power", `[{"publicKey": "` + key + `", "name": "a", "power": "1"}]`},
+		{"short key", `[{"publicKey": "0x1234", "name": "a", "power": 1}]`},
+		{"key not on the curve", `[{"publicKey": "0x` + fmt.Sprintf("%064x", 5) + `", "name": "a", "power": 1}]`},
+		{"listed twice", `[{"publicKey": "` + key + `", "name": "a", "power": 1}, {"publicKey": "` + key + `", "name": "b", "power": 2}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseValidatorSet([]byte(tt.data)); !errors.Is(err, ErrValidatorSet) {
+				t.Errorf("got %v, want ErrValidatorSet", err)
+			}
+		})
+	}
+}
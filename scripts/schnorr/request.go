@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+// jsonRequest is one line of a -json batch file or serve session. It
+// names either a message, hashed as sign would with an optional nonce,
+// or a digest to sign as is:
+//
+//	{"message": "hello", "nonce": "7"}
+//	{"message": "0x68656c6c6f"}
+//	{"digest": "0x<64 hex digits>"}
+type jsonRequest struct {
+	Message *string `json:"message"`
+	Nonce   *string `json:"nonce"`
+	Digest  *string `json:"digest"`
+}
+
+// parseJSONRequest decodes a request line strictly: unknown fields,
+// wrong types, trailing data and missing or conflicting fields are all
+// errors, reported with the byte offset in the line where possible.
+func parseJSONRequest(line []byte) (*jsonRequest, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.DisallowUnknownFields()
+
+	var r jsonRequest
+	if err := decoder.Decode(&r); err != nil {
+		return nil, jsonError(err, line)
+	}
+	if offset := decoder.InputOffset(); decoder.Decode(&struct{}{}) != io.EOF {
+		return nil, fmt.Errorf("offset %d: unexpected data after the request", offset)
+	}
+
+	switch {
+	case r.Message == nil && r.Digest == nil:
+		return nil, errors.New(`request needs "message" or "digest"`)
+	case r.Message != nil && r.Digest != nil:
+		return nil, errors.New(`request cannot have both "message" and "digest"`)
+	case r.Digest != nil && r.Nonce != nil:
+		return nil, errors.New(`"nonce" cannot be combined with "digest"`)
+	}
+	return &r, nil
+}
+
+// jsonError adds the offset in line of a decoding error to its message.
+func jsonError(err error, line []byte) error {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("offset %d: %w", syntaxErr.Offset, err)
+	case errors.As(err, &typeErr):
+		return fmt.Errorf("offset %d: %q must be a %s, got %s", typeErr.Offset, typeErr.Field, typeErr.Type, typeErr.Value)
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("offset %d: request is truncated", len(line))
+	}
+
+	if offset := unknownFieldOffset(line); offset >= 0 {
+		return fmt.Errorf("offset %d: %w", offset, err)
+	}
+	return err
+}
+
+// unknownFieldOffset returns the offset of the first key in the line's
+// object that jsonRequest does not have, or -1.
+func unknownFieldOffset(line []byte) int64 {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return -1
+	}
+
+	for decoder.More() {
+		// InputOffset is just past the previous value, before any
+		// comma and whitespace ahead of the key.
+		offset := decoder.InputOffset()
+		key, err := decoder.Token()
+		if err != nil {
+			return -1
+		}
+		switch key {
+		case "message", "nonce", "digest":
+		default:
+			return offset + int64(bytes.IndexByte(line[offset:], '"'))
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return -1
+		}
+	}
+	return -1
+}
+
+// jsonDigest parses a -json request line and returns its digest and
+// the line's nonce, nil if it has none. Like batchDigest, messages are
+// hashed with hasher under domain when it is non-nil and a 0x prefix
+// means hex.
+func jsonDigest(hasher signer.Hasher, line []byte, domain []byte) ([]byte, *big.Int, error) {
+	r, err := parseJSONRequest(line)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if r.Digest != nil {
+		if domain != nil {
+			return nil, nil, errors.New(`"digest" cannot be combined with -domain`)
+		}
+		digest, err := signer.DecodeHash(*r.Digest)
+		return digest, nil, err
+	}
+
+	message := []byte(*r.Message)
+	if strings.HasPrefix(*r.Message, "0x") {
+		message, err = signer.DecodeHex(*r.Message)
+		if err != nil {
+			return nil, nil, fmt.Errorf(`"message": %w`, err)
+		}
+	}
+
+	var nonce *big.Int
+	if r.Nonce != nil {
+		var ok bool
+		nonce, ok = new(big.Int).SetString(*r.Nonce, 0)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %q is not a number", signer.ErrInvalidNonce, *r.Nonce)
+		}
+		message, err = signer.EncodeWithNonce(nonce, message)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if domain != nil {
+		return signer.HashWithDomain(hasher, domain, message), nonce, nil
+	}
+	return hasher.Hash(message), nonce, nil
+}
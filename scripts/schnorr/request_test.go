@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+func TestParseJSONRequestRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"missing message", `{"nonce":"7"}`, `request needs "message" or "digest"`},
+		{"empty object", `{}`, `request needs "message" or "digest"`},
+		{"extra field", `{"message":"hi","extra":1}`, `offset 16: json: unknown field "extra"`},
+		{"extra field first", `{ "extra":1,"message":"hi"}`, `offset 2: json: unknown field "extra"`},
+		{"wrong type", `{"message":7}`, `offset 12: "message" must be a string, got number`},
+		{"wrong nonce type", `{"message":"hi","nonce":7}`, `offset 25: "nonce" must be a string, got number`},
+		{"not an object", `["hi"]`, `offset 1: "" must be a main.jsonRequest, got array`},
+		{"trailing data", `{"message":"hi"} {}`, "offset 16: unexpected data after the request"},
+		{"truncated", `{"message":"hi"`, "offset 15: request is truncated"},
+		{"syntax", `{"message":hi}`, "offset 12: invalid character 'h' looking for beginning of value"},
+		{"message and digest", `{"message":"hi","digest":"0x00"}`, `request cannot have both "message" and "digest"`},
+		{"nonce and digest", `{"digest":"0x00","nonce":"1"}`, `"nonce" cannot be combined with "digest"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseJSONRequest([]byte(tt.line))
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("got %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONDigestNonce(t *testing.T) {
+	hasher, err := newKeccak256()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest, nonce, err := jsonDigest(hasher, []byte(`{"message":"hello","nonce":"0x10"}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nonce == nil || nonce.Int64() != 16 {
+		t.Errorf("nonce is %v, want 16", nonce)
+	}
+	encoded, err := signer.EncodeWithNonce(nonce, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := hasher.Hash(encoded); string(digest) != string(want) {
+		t.Errorf("digest is %x, want %x", digest, want)
+	}
+
+	if _, nonce, err := jsonDigest(hasher, []byte(`{"message":"hello"}`), nil); err != nil || nonce != nil {
+		t.Errorf("no nonce: got %v, %v", nonce, err)
+	}
+	if _, _, err := jsonDigest(hasher, []byte(`{"message":"hello","nonce":"seven"}`), nil); !errors.Is(err, signer.ErrInvalidNonce) {
+		t.Errorf("bad nonce: got %v, want ErrInvalidNonce", err)
+	}
+}
+
+func TestSignJSONBatchNonces(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "batch.jsonl")
+	lines := `{"message":"a","nonce":"1"}` + "\n" + `{"message":"b"}` + "\n" + `{"message":"c","nonce":"0x2a"}` + "\n"
+	if err := os.WriteFile(path, []byte(lines), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	res := runCLI(t, "sign", "-batch-file", path, "-json", "-output", "json")
+	if res.code != 0 {
+		t.Fatalf("exit %d: %s", res.code, res.stderr)
+	}
+	replies := decodeReplies(t, res.stdout)
+	if len(replies) != 3 {
+		t.Fatalf("got %d outputs, want 3:\n%s", len(replies), res.stdout)
+	}
+	for i, want := range []string{"1", "", "42"} {
+		if replies[i].Nonce != want {
+			t.Errorf("line %d: nonce %q, want %q", i+1, replies[i].Nonce, want)
+		}
+	}
+}
+
+func TestServeReplyNonce(t *testing.T) {
+	s := newTestServer(newMockSigner(t), 1<<10, 1)
+	s.jsonLines = true
+
+	in := `{"message":"a","nonce":"7"}` + "\n" + `{"message":"b"}` + "\n" + `{"message":"c","extra":1}` + "\n"
+	var out strings.Builder
+	if err := s.handle(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	replies := decodeReplies(t, out.String())
+	if len(replies) != 3 {
+		t.Fatalf("got %d replies, want 3:\n%s", len(replies), out.String())
+	}
+	if replies[0].Nonce != "7" || replies[0].Signature == "" {
+		t.Errorf("first reply: %+v", replies[0])
+	}
+	if replies[1].Nonce != "" || replies[1].Signature == "" {
+		t.Errorf("second reply: %+v", replies[1])
+	}
+	if !strings.Contains(replies[2].Error, `offset 15: json: unknown field "extra"`) {
+		t.Errorf("third reply: %+v", replies[2])
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/TimeleapLabs/go-schnorr/attestation"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+// rotate re-signs the attestation bundles in -dir with the new key
+// after a key rotation and writes them to -out under the same names.
+// Bundles may be JSON or the binary layout and are written back in the
+// same form. A bundle that does not verify under -old-pubkey is skipped
+// and reported, and the command then exits with exitVerify once every
+// other bundle has been written.
+func rotate(args []string) {
+	flags := flag.NewFlagSet("rotate", flag.ExitOnError)
+	key := addKeyFlags(flags)
+	dir := flags.String("dir", "", "directory of attestation bundles signed with the old key")
+	out := flags.String("out", "", "directory to write the re-signed bundles to")
+	oldPublicKeyHex := flags.String("old-pubkey", "", "0x-prefixed public key X coordinate the bundles are signed with")
+	force := flags.Bool("force", false, "overwrite bundles that already exist in -out")
+	parseFlags(flags, args)
+
+	if *dir == "" || *out == "" {
+		fatal(usageError("-dir and -out are required"))
+	}
+	if filepath.Clean(*dir) == filepath.Clean(*out) {
+		fatal(usageError("-out must differ from -dir, so the old bundles are kept"))
+	}
+
+	oldPublicKey, err := signer.ParsePublicKey(*oldPublicKeyHex)
+	if err != nil {
+		fatal(inputError("Error parsing -old-pubkey: %w", err))
+	}
+	oldXOnly := signer.XOnlyPubKey(oldPublicKey)
+
+	entries, err := os.ReadDir(*dir)
+	if err != nil {
+		fatal(inputError("Error reading bundles: %w", err))
+	}
+
+	privateKey, _ := key.load()
+	s := newSigner(privateKey, false)
+	defer zeroSigner(s)
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		fatal(fmt.Errorf("Error creating output directory: %w", err))
+	}
+
+	rotated, skipped := 0, 0
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		name := entry.Name()
+
+		data, err := os.ReadFile(filepath.Join(*dir, name))
+		if err != nil {
+			fatal(inputError("Error reading bundle: %w", err))
+		}
+
+		isJSON := json.Valid(data)
+		var bundle attestation.Attestation
+		if isJSON {
+			err = json.Unmarshal(data, &bundle)
+		} else {
+			err = bundle.UnmarshalBinary(data)
+		}
+		if err != nil {
+			errorf("Skipping %s: %v", name, err)
+			skipped++
+			continue
+		}
+
+		if !bytes.Equal(bundle.PublicKey[:], oldXOnly) {
+			errorf("Skipping %s: signed by 0x%x, not the old key", name, bundle.PublicKey)
+			skipped++
+			continue
+		}
+		if !bundle.Verify() {
+			errorf("Skipping %s: signature does not verify under the old key", name)
+			skipped++
+			continue
+		}
+
+		resigned, err := bundle.Resign(s)
+		if err != nil {
+			fatal(signError("Error re-signing %s: %w", name, err))
+		}
+
+		if isJSON {
+			data, err = json.MarshalIndent(resigned, "", "  ")
+			data = append(data, '\n')
+		} else {
+			data, err = resigned.MarshalBinary()
+		}
+		if err != nil {
+			fatal(fmt.Errorf("Error encoding %s: %w", name, err))
+		}
+
+		if err := writeBundle(filepath.Join(*out, name), data, *force); err != nil {
+			fatal(err)
+		}
+		debugf("Rotated %s", name)
+		rotated++
+	}
+
+	fmt.Printf("Public key: 0x%x\n", signer.XOnlyPubKey(s.PublicKey()))
+	fmt.Printf("Rotated: %d\n", rotated)
+	fmt.Printf("Skipped: %d\n", skipped)
+
+	if skipped > 0 {
+		fatal(verifyError("%d bundles were skipped", skipped))
+	}
+}
+
+func writeBundle(path string, data []byte, force bool) error {
+	mode := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		mode = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(path, mode, 0o644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return usageError("Refusing to overwrite %s, use -force", path)
+		}
+		return fmt.Errorf("Error creating %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("Error writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TimeleapLabs/go-schnorr/attestation"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+// oldBundle returns an attestation of message signed by the private key
+// with every byte set to b, with a nonce and a proof to carry over.
+func oldBundle(t *testing.T, b byte, message string) *attestation.Attestation {
+	t.Helper()
+
+	priv, err := signer.PrivateKeyFromBytes(bytes.Repeat([]byte{b}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := signer.HashMessage([]byte(message))
+	sig, err := signer.SignDeterministic(priv, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := attestation.New(hash, priv.PubKey(), sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Nonce = big.NewInt(7)
+	a.Proof = &attestation.Proof{Index: 2, Siblings: [][32]byte{{0xaa}}}
+	return a
+}
+
+func writeJSONBundle(t *testing.T, path string, a *attestation.Attestation) {
+	t.Helper()
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeBinaryBundle(t *testing.T, path string, a *attestation.Attestation) {
+	t.Helper()
+
+	data, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// oldPubKey is the x-only public key of the private key 0x0202…02.
+func oldPubKey(t *testing.T) string {
+	t.Helper()
+
+	priv, err := signer.PrivateKeyFromBytes(bytes.Repeat([]byte{0x02}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("0x%x", signer.XOnlyPubKey(priv.PubKey()))
+}
+
+func TestRotate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "old")
+	out := filepath.Join(t.TempDir(), "new")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	good := oldBundle(t, 0x02, "good")
+	writeJSONBundle(t, filepath.Join(dir, "good.json"), good)
+	writeBinaryBundle(t, filepath.Join(dir, "good.bin"), oldBundle(t, 0x02, "also good"))
+
+	tampered := oldBundle(t, 0x02, "tampered")
+	tampered.MessageHash[0] ^= 1
+	writeJSONBundle(t, filepath.Join(dir, "tampered.json"), tampered)
+	writeBinaryBundle(t, filepath.Join(dir, "wrong-key.bin"), oldBundle(t, 0x03, "wrong key"))
+	if err := os.WriteFile(filepath.Join(dir, "garbage.bin"), []byte("not a bundle"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// Directories are not bundles and are not reported.
+	if err := os.Mkdir(filepath.Join(dir, "archive"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	res := runCLI(t, "rotate", "-dir", dir, "-out", out, "-old-pubkey", oldPubKey(t))
+	if res.code != exitVerify {
+		t.Fatalf("exit %d, want %d: %s", res.code, exitVerify, res.stderr)
+	}
+	if got := field(t, res.stdout, "Rotated"); got != "2" {
+		t.Errorf("Rotated: %s, want 2", got)
+	}
+	if got := field(t, res.stdout, "Skipped"); got != "3" {
+		t.Errorf("Skipped: %s, want 3", got)
+	}
+	for _, name := range []string{"tampered.json", "wrong-key.bin", "garbage.bin"} {
+		if !strings.Contains(res.stderr, "Skipping "+name) {
+			t.Errorf("%s not reported as skipped:\n%s", name, res.stderr)
+		}
+	}
+
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, " ") != "good.bin good.json" {
+		t.Errorf("-out holds %v, want only the good bundles", names)
+	}
+
+	// The JSON bundle stays JSON, with the same hash, nonce and proof.
+	data, err := os.ReadFile(filepath.Join(out, "good.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !json.Valid(data) {
+		t.Fatalf("good.json is no longer JSON:\n%s", data)
+	}
+	var rotated attestation.Attestation
+	if err := json.Unmarshal(data, &rotated); err != nil {
+		t.Fatal(err)
+	}
+	checkRotated(t, &rotated, good)
+
+	// The binary bundle stays binary.
+	data, err = os.ReadFile(filepath.Join(out, "good.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if json.Valid(data) {
+		t.Fatal("good.bin was written as JSON")
+	}
+	rotated = attestation.Attestation{}
+	if err := rotated.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	checkRotated(t, &rotated, oldBundle(t, 0x02, "also good"))
+
+	// The old bundles are left as they were.
+	if _, err := os.Stat(filepath.Join(dir, "tampered.json")); err != nil {
+		t.Error(err)
+	}
+}
+
+// checkRotated checks that rotated re-signs old's message hash with the
+// test key and keeps its nonce and proof.
+func checkRotated(t *testing.T, rotated, old *attestation.Attestation) {
+	t.Helper()
+
+	if got := fmt.Sprintf("0x%x", rotated.PublicKey); got != testPubKey {
+		t.Errorf("rotated bundle signed by %s, want %s", got, testPubKey)
+	}
+	if !rotated.Verify() {
+		t.Error("rotated bundle does not verify")
+	}
+	if rotated.MessageHash != old.MessageHash {
+		t.Error("rotated bundle signs another message")
+	}
+	if rotated.Nonce == nil || rotated.Nonce.Cmp(old.Nonce) != 0 {
+		t.Errorf("nonce is %v, want %v", rotated.Nonce, old.Nonce)
+	}
+	if rotated.Proof == nil || rotated.Proof.Index != old.Proof.Index || len(rotated.Proof.Siblings) != 1 {
+		t.Errorf("proof is %+v, want %+v", rotated.Proof, old.Proof)
+	}
+}
+
+func TestRotateAllGood(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(t.TempDir(), "new")
+	writeJSONBundle(t, filepath.Join(dir, "a.json"), oldBundle(t, 0x02, "a"))
+
+	args := []string{"rotate", "-dir", dir, "-out", out, "-old-pubkey", oldPubKey(t)}
+	res := runCLI(t, args...)
+	if res.code != 0 {
+		t.Fatalf("exit %d: %s", res.code, res.stderr)
+	}
+	if field(t, res.stdout, "Rotated") != "1" || field(t, res.stdout, "Skipped") != "0" {
+		t.Errorf("unexpected counts:\n%s", res.stdout)
+	}
+
+	if res := runCLI(t, args...); res.code != exitUsage {
+		t.Errorf("existing bundle without -force: exit %d, want %d", res.code, exitUsage)
+	}
+	if res := runCLI(t, append(args, "-force")...); res.code != 0 {
+		t.Errorf("existing bundle with -force: exit %d: %s", res.code, res.stderr)
+	}
+
+	if res := runCLI(t, "rotate", "-dir", dir, "-out", dir, "-old-pubkey", oldPubKey(t)); res.code != exitUsage {
+		t.Errorf("-out same as -dir: exit %d, want %d", res.code, exitUsage)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/TimeleapLabs/go-schnorr/merkle"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// selfTestMessage is the fixed message signed by selftest.
+const selfTestMessage = "unchained selftest"
+
+// selfTest exercises signing, verification and merkle proofs with an
+// ephemeral key, so operators can check a build on a new host without
+// touching a real key. Stages run in order and stop at the first one
+// that fails, which is named in the error.
+func selfTest(args []string) {
+	flags := flag.NewFlagSet("selftest", flag.ExitOnError)
+	parseFlags(flags, args)
+
+	var (
+		priv *btcec.PrivateKey
+		hash []byte
+		sig  *schnorr.Signature
+	)
+	defer func() {
+		if priv != nil {
+			priv.Zero()
+		}
+	}()
+
+	stages := []struct {
+		name string
+		run  func() error
+	}{
+		{"keygen", func() (err error) {
+			priv, err = btcec.NewPrivateKey()
+			return err
+		}},
+		{"sign", func() (err error) {
+			hash = signer.HashMessage([]byte(selfTestMessage))
+			sig, err = signer.SignMessage(priv, hash)
+			return err
+		}},
+		{"verify", func() error {
+			if !signer.VerifyMessage(priv.PubKey(), hash, sig) {
+				return errors.New("valid signature rejected")
+			}
+			if !signer.IsCanonical(sig.Serialize()) {
+				return errors.New("signature is not canonical")
+			}
+			tampered := signer.HashMessage([]byte(selfTestMessage + "!"))
+			if signer.VerifyMessage(priv.PubKey(), tampered, sig) {
+				return errors.New("signature accepted for a different message")
+			}
+			return nil
+		}},
+		{"merkle", func() error {
+			leaves := [][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("d"), []byte("e")}
+			tree, err := merkle.New(leaves)
+			if err != nil {
+				return err
+			}
+			for i, leaf := range leaves {
+				proof, err := tree.Proof(i)
+				if err != nil {
+					return err
+				}
+				if !merkle.VerifyProof(tree.Root(), leaf, proof, i) {
+					return fmt.Errorf("proof for leaf %d rejected", i)
+				}
+				if merkle.VerifyProof(tree.Root(), []byte("x"), proof, i) {
+					return fmt.Errorf("proof for leaf %d accepted a different leaf", i)
+				}
+			}
+			if bytes.Equal(tree.Root(), merkle.HashLeaf(leaves[0])) {
+				return errors.New("root does not depend on the other leaves")
+			}
+			return nil
+		}},
+	}
+
+	for _, stage := range stages {
+		if err := stage.run(); err != nil {
+			fmt.Printf("%s: FAIL\n", stage.name)
+			fatal(fmt.Errorf("Self-test stage %s failed: %w", stage.name, err))
+		}
+		fmt.Printf("%s: ok\n", stage.name)
+	}
+
+	infof("Self-test passed")
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+// Error replies a client may want to act on, such as by retrying a
+// busy server later.
+const (
+	errRequestTooLarge = "request too large"
+	errServerBusy      = "server busy"
+)
+
+type server struct {
+	signer     signer.Signer
+	format     signer.PubKeyFormat
+	layout     signer.SigLayout
+	hash       *hashFlags
+	hashOnly   bool
+	jsonLines  bool
+	domain     []byte
+	stats      *signStats
+	maxMessage int
+	inFlight   chan struct{}
+}
+
+// serveReply is written once per request line. Exactly one of the
+// embedded output or Error is set; with -json the output carries the
+// request's nonce, if it had one.
+type serveReply struct {
+	*signOutput
+	Error string `json:"error,omitempty"`
+}
+
+// serve signs line-delimited requests with a key loaded once at
+// startup. Each line is a message in the same form as -batch-file, or
+// a JSON request with -json, and gets one JSON reply line, in order.
+// Requests are read from stdin, or from every connection to a Unix
+// socket when -socket is set. A client that stops reading its replies
+// stops being read from, so a slow client only holds up itself.
+// SIGTERM and SIGINT finish the requests in flight and exit.
+//
+// A request line longer than -max-message-bytes gets a "request too
+// large" reply and ends its session, and a request that arrives while
+// -max-concurrent others are being signed gets a "server busy" reply
+// instead of waiting. Request buffers use at most -max-conns times
+// -max-message-bytes of memory.
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	key := addKeyFlags(flags)
+	socket := flags.String("socket", "", "listen on this Unix socket instead of stdin")
+	maxConns := flags.Int("max-conns", 16, "maximum concurrent socket connections")
+	maxMessage := flags.Int("max-message-bytes", 1<<20, "maximum request line length in bytes")
+	maxConcurrent := flags.Int("max-concurrent", 8, "maximum requests signed at once across all connections")
+	hashOnly := flags.Bool("hash-only", false, "requests are 32-byte hex digests to sign as-is")
+	jsonLines := flags.Bool("json", false, "requests are JSON objects with a message or digest")
+	domain := flags.String("domain", "", "domain tag to hash every message under")
+	hash := addHashFlags(flags)
+	deterministic := flags.Bool("deterministic", false, "derive the nonce from key and message only (RFC6979)")
+	pubKeyFormatName := addPubKeyFormatFlag(flags)
+	sigLayoutName := addSigLayoutFlag(flags)
+	showStats := flags.Bool("stats", false, "print hash and sign timings to stderr on shutdown")
+	parseFlags(flags, args)
+
+	if *maxConns < 1 {
+		fatal(usageError("-max-conns must be at least 1"))
+	}
+	if *maxMessage < 1 || *maxConcurrent < 1 {
+		fatal(usageError("-max-message-bytes and -max-concurrent must be at least 1"))
+	}
+	if *domain != "" && *hashOnly {
+		fatal(usageError("-domain cannot be combined with -hash-only"))
+	}
+	if *jsonLines && *hashOnly {
+		fatal(usageError("-json cannot be combined with -hash-only"))
+	}
+	if _, err := hash.newHasher(); err != nil {
+		fatal(usageError("Error parsing -hash: %w", err))
+	}
+
+	s := &server{
+		format:     parsePubKeyFormat(*pubKeyFormatName),
+		layout:     parseSigLayout(*sigLayoutName),
+		hash:       hash,
+		hashOnly:   *hashOnly,
+		jsonLines:  *jsonLines,
+		maxMessage: *maxMessage,
+		inFlight:   make(chan struct{}, *maxConcurrent),
+	}
+	if *domain != "" {
+		s.domain = []byte(*domain)
+	}
+	if *showStats {
+		s.stats = &signStats{}
+		defer s.stats.report(os.Stderr)
+	}
+
+	privateKey, _ := key.load()
+	s.signer = newSigner(privateKey, *deterministic)
+	defer zeroSigner(s.signer)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	if *socket == "" {
+		s.serveStdin(ctx, os.Stdin, os.Stdout)
+		return
+	}
+
+	listener, err := net.Listen("unix", *socket)
+	if err != nil {
+		fatal(fmt.Errorf("Error listening: %w", err))
+	}
+	infof("Listening on %s", *socket)
+
+	context.AfterFunc(ctx, func() { listener.Close() })
+
+	var (
+		wg    sync.WaitGroup
+		slots = make(chan struct{}, *maxConns)
+	)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			errorf("Error accepting connection: %v", err)
+			continue
+		}
+
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				conn.Close()
+				<-slots
+				wg.Done()
+			}()
+
+			// Unblock a pending read on shutdown. A request already
+			// read is still answered.
+			stopRead := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+			defer stopRead()
+
+			err := s.handle(ctx, conn, conn)
+			if err != nil && ctx.Err() == nil {
+				errorf("Error serving connection: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	infof("Shut down")
+}
+
+// serveStdin answers requests from in on out until in is exhausted or
+// ctx is done. It returns only once no request is being signed, so the
+// caller can zero the signer.
+func (s *server) serveStdin(ctx context.Context, in *os.File, out io.Writer) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := s.handle(ctx, in, out)
+		if err != nil && ctx.Err() == nil {
+			errorf("Error serving stdin: %v", err)
+		}
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+
+	// Unblock the pending read. A request already read is still
+	// answered.
+	if err := in.SetReadDeadline(time.Now()); err != nil {
+		// A terminal or blocking pipe cannot be interrupted, so wait
+		// for the request being signed instead. Holding every slot
+		// turns any later request away without reaching the signer.
+		for i := 0; i < cap(s.inFlight); i++ {
+			s.inFlight <- struct{}{}
+		}
+		return
+	}
+	<-done
+}
+
+// handle answers requests from r on w until r is exhausted, ctx is
+// done or a reply cannot be written. Malformed requests get an error
+// reply and do not end the session.
+func (s *server) handle(ctx context.Context, r io.Reader, w io.Writer) error {
+	hasher, err := s.hash.newHasher()
+	if err != nil {
+		return err
+	}
+
+	var (
+		scanner = bufio.NewScanner(r)
+		encoder = json.NewEncoder(w)
+	)
+	scanner.Buffer(make([]byte, 0, min(4096, s.maxMessage)), s.maxMessage)
+
+	for ctx.Err() == nil && scanner.Scan() {
+		line := bytes.TrimRight(scanner.Bytes(), "\r")
+		if len(line) == 0 {
+			continue
+		}
+
+		if err := encoder.Encode(s.replyIfIdle(hasher, line)); err != nil {
+			return err
+		}
+	}
+
+	err = scanner.Err()
+	if errors.Is(err, bufio.ErrTooLong) {
+		encoder.Encode(serveReply{Error: errRequestTooLarge})
+	}
+	return err
+}
+
+// replyIfIdle answers the request if fewer than -max-concurrent others
+// are in flight, and with errServerBusy otherwise.
+func (s *server) replyIfIdle(hasher signer.Hasher, line []byte) serveReply {
+	select {
+	case s.inFlight <- struct{}{}:
+		defer func() { <-s.inFlight }()
+		return s.reply(hasher, line)
+	default:
+		return serveReply{Error: errServerBusy}
+	}
+}
+
+func (s *server) reply(hasher signer.Hasher, line []byte) serveReply {
+	start := s.stats.now()
+	var (
+		hash  []byte
+		nonce *big.Int
+		err   error
+	)
+	if s.jsonLines {
+		hash, nonce, err = jsonDigest(hasher, line, s.domain)
+	} else {
+		hash, err = batchDigest(hasher, line, s.hashOnly, s.domain)
+	}
+	if err != nil {
+		return serveReply{Error: err.Error()}
+	}
+
+	signature, err := s.signer.Sign(hash)
+	if err != nil {
+		return serveReply{Error: err.Error()}
+	}
+	s.stats.record(-1, start)
+
+	out, err := newSignOutput(s.signer.PublicKey(), s.format, s.layout, hash, signature)
+	if err != nil {
+		return serveReply{Error: err.Error()}
+	}
+	if nonce != nil {
+		out.Nonce = nonce.String()
+	}
+	return serveReply{signOutput: &out}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// newTestServer returns a server that signs raw message lines with s.
+func newTestServer(s signer.Signer, maxMessage, maxConcurrent int) *server {
+	name, tag := signer.HashKeccak256, ""
+	return &server{
+		signer:     s,
+		format:     signer.PubKeyXOnly,
+		layout:     signer.SigLayoutRS,
+		hash:       &hashFlags{name: &name, tag: &tag},
+		maxMessage: maxMessage,
+		inFlight:   make(chan struct{}, maxConcurrent),
+	}
+}
+
+// decodedReply is a serveReply as a client decodes it. serveReply
+// itself embeds its output by pointer and cannot be unmarshaled into.
+type decodedReply struct {
+	signOutput
+	Error string `json:"error"`
+}
+
+// decodeReplies parses the JSON reply lines written by handle.
+func decodeReplies(t *testing.T, out string) []decodedReply {
+	t.Helper()
+
+	var replies []decodedReply
+	decoder := json.NewDecoder(strings.NewReader(out))
+	for decoder.More() {
+		var reply decodedReply
+		if err := decoder.Decode(&reply); err != nil {
+			t.Fatalf("%v in replies:\n%s", err, out)
+		}
+		replies = append(replies, reply)
+	}
+	return replies
+}
+
+func TestServeRejectsOversizedRequest(t *testing.T) {
+	s := newTestServer(newMockSigner(t), 16, 1)
+
+	in := "short\n" + strings.Repeat("x", 17) + "\nafter\n"
+	var out strings.Builder
+	err := s.handle(context.Background(), strings.NewReader(in), &out)
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("handle returned %v, want bufio.ErrTooLong", err)
+	}
+
+	replies := decodeReplies(t, out.String())
+	if len(replies) != 2 {
+		t.Fatalf("got %d replies, want 2:\n%s", len(replies), out.String())
+	}
+	if replies[0].Error != "" || replies[0].Signature == "" {
+		t.Errorf("short request: %+v", replies[0])
+	}
+	// The session ends after the oversized line, so "after" is not
+	// answered.
+	if replies[1].Error != errRequestTooLarge || replies[1].Signature != "" {
+		t.Errorf("oversized request: %+v, want %q", replies[1], errRequestTooLarge)
+	}
+}
+
+// gatedSigner blocks every Sign call until release is closed, and
+// records how many calls were in progress at once.
+type gatedSigner struct {
+	*mockSigner
+	entered chan struct{}
+	release chan struct{}
+
+	mu        sync.Mutex
+	active    int
+	maxActive int
+}
+
+func (g *gatedSigner) Sign(hash []byte) (*schnorr.Signature, error) {
+	g.mu.Lock()
+	g.active++
+	g.maxActive = max(g.maxActive, g.active)
+	g.mu.Unlock()
+
+	g.entered <- struct{}{}
+	<-g.release
+
+	g.mu.Lock()
+	g.active--
+	g.mu.Unlock()
+	return g.mockSigner.Sign(hash)
+}
+
+func TestServeBoundsConcurrency(t *testing.T) {
+	const maxConcurrent = 2
+	gated := &gatedSigner{
+		mockSigner: newMockSigner(t),
+		entered:    make(chan struct{}, maxConcurrent+1),
+		release:    make(chan struct{}),
+	}
+	s := newTestServer(gated, 1<<10, maxConcurrent)
+
+	// One session per request, as separate socket connections would be.
+	session := func(message string) <-chan string {
+		done := make(chan string, 1)
+		go func() {
+			var out strings.Builder
+			if err := s.handle(context.Background(), strings.NewReader(message+"\n"), &out); err != nil {
+				t.Error(err)
+			}
+			done <- out.String()
+		}()
+		return done
+	}
+
+	var signing []<-chan string
+	for i := 0; i < maxConcurrent; i++ {
+		signing = append(signing, session("slow"))
+		select {
+		case <-gated.entered:
+		case <-time.After(5 * time.Second):
+			t.Fatal("request did not reach the signer")
+		}
+	}
+
+	// Every slot is taken, so the next request is turned away at once
+	// instead of waiting.
+	select {
+	case out := <-session("busy"):
+		if replies := decodeReplies(t, out); len(replies) != 1 || replies[0].Error != errServerBusy {
+			t.Errorf("request over the limit got %+v, want %q", replies, errServerBusy)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("request over the limit waited for a slot")
+	}
+
+	close(gated.release)
+	for i, done := range signing {
+		if replies := decodeReplies(t, <-done); len(replies) != 1 || replies[0].Error != "" {
+			t.Errorf("request %d got %+v", i, replies)
+		}
+	}
+
+	gated.mu.Lock()
+	maxActive := gated.maxActive
+	gated.mu.Unlock()
+	if maxActive != maxConcurrent {
+		t.Errorf("%d requests were signed at once, want %d", maxActive, maxConcurrent)
+	}
+
+	// The slots are free again.
+	if replies := decodeReplies(t, <-session("later")); len(replies) != 1 || replies[0].Error != "" {
+		t.Errorf("request after the others finished got %+v", replies)
+	}
+}
+
+func TestServeStdinWaitsForRequestOnShutdown(t *testing.T) {
+	gated := &gatedSigner{
+		mockSigner: newMockSigner(t),
+		entered:    make(chan struct{}, 1),
+		release:    make(chan struct{}),
+	}
+	s := newTestServer(gated, 1<<10, 1)
+
+	in, client, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	defer client.Close()
+
+	// The client stays connected, so only shutdown ends the read loop.
+	if _, err := client.WriteString("slow\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var out strings.Builder
+	zeroed := make(chan bool, 1)
+	go func() {
+		s.serveStdin(ctx, in, &out)
+		// serve zeroes the signer as soon as serveStdin returns.
+		gated.mu.Lock()
+		idle := gated.active == 0
+		gated.mu.Unlock()
+		zeroSigner(gated)
+		zeroed <- idle
+	}()
+
+	select {
+	case <-gated.entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request did not reach the signer")
+	}
+	cancel()
+
+	select {
+	case <-zeroed:
+		t.Fatal("serveStdin returned while the request was being signed")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(gated.release)
+	select {
+	case idle := <-zeroed:
+		if !idle {
+			t.Error("signer was zeroed while a request was being signed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serveStdin did not return after shutdown")
+	}
+
+	if replies := decodeReplies(t, out.String()); len(replies) != 1 || replies[0].Error != "" {
+		t.Errorf("request in flight at shutdown got %+v, want a signature", replies)
+	}
+	if !gated.zeroed {
+		t.Error("signer was not zeroed")
+	}
+}
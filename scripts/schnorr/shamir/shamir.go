@@ -0,0 +1,235 @@
+// Package shamir splits a private key into k-of-n Shamir shares over the
+// secp256k1 scalar field for offline backup, and reconstructs it from
+// any k of them. Unlike frost, the key is rebuilt in one place; the
+// shares are not meant to sign on their own.
+//
+// A share is written as
+//
+//	k-i-keyid-value-checksum
+//
+// with k the threshold and i the share index in decimal, keyid the
+// first 4 bytes of the x-only public key, value the 32-byte share and
+// checksum the first 4 bytes of sha256(k || i || keyid || value), all
+// in hex. The checksum catches transcription errors and the key id
+// catches shares of different keys being mixed.
+package shamir
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// MaxShares bounds n, as the index is stored in a single byte.
+const MaxShares = 255
+
+var (
+	ErrInvalidThreshold = errors.New("threshold must satisfy 1 <= k <= n <= 255")
+	ErrNotEnoughShares  = errors.New("not enough shares to reach the threshold")
+	ErrDuplicateShare   = errors.New("duplicate share index")
+	ErrMixedShares      = errors.New("shares belong to different keys or splits")
+	ErrShareFormat      = errors.New("malformed share")
+	ErrChecksum         = errors.New("share checksum mismatch")
+	ErrWrongKey         = errors.New("shares do not reconstruct the key they were split from")
+)
+
+// Share is one point of the secret polynomial.
+type Share struct {
+	Threshold uint8
+	Index     uint8
+	KeyID     [4]byte
+	Value     btcec.ModNScalar
+}
+
+// Split splits priv into n shares, any k of which reconstruct it. The
+// polynomial coefficients come from crypto/rand.
+func Split(priv *btcec.PrivateKey, k, n int) ([]*Share, error) {
+	if k < 1 || k > n || n > MaxShares {
+		return nil, ErrInvalidThreshold
+	}
+
+	coefficients := make([]btcec.ModNScalar, k)
+	defer func() {
+		for i := range coefficients {
+			coefficients[i].Zero()
+		}
+	}()
+
+	coefficients[0].Set(&priv.Key)
+	for i := 1; i < k; i++ {
+		random, err := btcec.NewPrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		coefficients[i].Set(&random.Key)
+		random.Zero()
+	}
+
+	id := keyID(priv.PubKey())
+	shares := make([]*Share, n)
+	for i := range shares {
+		var x btcec.ModNScalar
+		x.SetInt(uint32(i + 1))
+
+		// Horner's rule, highest coefficient first.
+		share := &Share{Threshold: uint8(k), Index: uint8(i + 1), KeyID: id}
+		for j := k - 1; j >= 0; j-- {
+			share.Value.Mul(&x).Add(&coefficients[j])
+		}
+		shares[i] = share
+	}
+
+	return shares, nil
+}
+
+// Combine reconstructs the private key from at least Threshold shares of
+// the same split, and checks it against the shares' key id.
+func Combine(shares []*Share) (*btcec.PrivateKey, error) {
+	if len(shares) == 0 {
+		return nil, ErrNotEnoughShares
+	}
+
+	first := shares[0]
+	seen := make(map[uint8]bool, len(shares))
+	for _, share := range shares {
+		if share.Threshold != first.Threshold || share.KeyID != first.KeyID {
+			return nil, ErrMixedShares
+		}
+		if share.Index == 0 {
+			return nil, fmt.Errorf("%w: index 0", ErrShareFormat)
+		}
+		if seen[share.Index] {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateShare, share.Index)
+		}
+		seen[share.Index] = true
+	}
+
+	if len(shares) < int(first.Threshold) {
+		return nil, fmt.Errorf("%w: have %d, need %d", ErrNotEnoughShares, len(shares), first.Threshold)
+	}
+
+	var secret btcec.ModNScalar
+	for _, share := range shares {
+		term := lagrange(share.Index, shares)
+		term.Mul(&share.Value)
+		secret.Add(term)
+	}
+
+	priv := &btcec.PrivateKey{Key: secret}
+	if keyID(priv.PubKey()) != first.KeyID {
+		priv.Zero()
+		return nil, ErrWrongKey
+	}
+
+	return priv, nil
+}
+
+// String encodes the share in the text form described in the package
+// documentation.
+func (s *Share) String() string {
+	value := s.Value.Bytes()
+	checksum := shareChecksum(s.Threshold, s.Index, s.KeyID, value)
+	return fmt.Sprintf("%d-%d-%x-%x-%x", s.Threshold, s.Index, s.KeyID, value, checksum)
+}
+
+// ParseShare decodes a share written by String.
+func ParseShare(text string) (*Share, error) {
+	parts := strings.Split(strings.TrimSpace(text), "-")
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("%w: want 5 dash-separated parts, got %d", ErrShareFormat, len(parts))
+	}
+
+	threshold, err := strconv.ParseUint(parts[0], 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("%w: threshold: %w", ErrShareFormat, err)
+	}
+	index, err := strconv.ParseUint(parts[1], 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("%w: index: %w", ErrShareFormat, err)
+	}
+
+	share := &Share{Threshold: uint8(threshold), Index: uint8(index)}
+
+	if err := decodeFixed(share.KeyID[:], parts[2]); err != nil {
+		return nil, fmt.Errorf("%w: key id: %w", ErrShareFormat, err)
+	}
+
+	var value [32]byte
+	if err := decodeFixed(value[:], parts[3]); err != nil {
+		return nil, fmt.Errorf("%w: value: %w", ErrShareFormat, err)
+	}
+
+	var checksum [4]byte
+	if err := decodeFixed(checksum[:], parts[4]); err != nil {
+		return nil, fmt.Errorf("%w: checksum: %w", ErrShareFormat, err)
+	}
+
+	if shareChecksum(share.Threshold, share.Index, share.KeyID, value) != checksum {
+		return nil, fmt.Errorf("%w: share %d", ErrChecksum, share.Index)
+	}
+
+	if overflow := share.Value.SetBytes(&value); overflow != 0 {
+		return nil, fmt.Errorf("%w: value is not below the curve order", ErrShareFormat)
+	}
+
+	return share, nil
+}
+
+func shareChecksum(threshold, index uint8, keyID [4]byte, value [32]byte) [4]byte {
+	h := sha256.New()
+	h.Write([]byte{threshold, index})
+	h.Write(keyID[:])
+	h.Write(value[:])
+
+	var checksum [4]byte
+	copy(checksum[:], h.Sum(nil))
+	return checksum
+}
+
+func keyID(pub *btcec.PublicKey) [4]byte {
+	var id [4]byte
+	copy(id[:], signer.XOnlyPubKey(pub))
+	return id
+}
+
+// lagrange returns the Lagrange coefficient of share i at zero over the
+// given shares.
+func lagrange(i uint8, shares []*Share) *btcec.ModNScalar {
+	var xi, num, den btcec.ModNScalar
+	xi.SetInt(uint32(i))
+	num.SetInt(1)
+	den.SetInt(1)
+
+	for _, share := range shares {
+		if share.Index == i {
+			continue
+		}
+
+		var xj, diff btcec.ModNScalar
+		xj.SetInt(uint32(share.Index))
+		diff.NegateVal(&xi).Add(&xj)
+
+		num.Mul(&xj)
+		den.Mul(&diff)
+	}
+
+	return num.Mul(den.InverseNonConst())
+}
+
+func decodeFixed(dst []byte, s string) error {
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	if len(decoded) != len(dst) {
+		return fmt.Errorf("want %d bytes, got %d", len(dst), len(decoded))
+	}
+	copy(dst, decoded)
+	return nil
+}
@@ -0,0 +1,202 @@
+package shamir
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+func testKey(t *testing.T, b byte) *btcec.PrivateKey {
+	t.Helper()
+
+	priv, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{b}, 32))
+	return priv
+}
+
+// roundTrip encodes and parses every share, as a backup would.
+func roundTrip(t *testing.T, shares []*Share) []*Share {
+	t.Helper()
+
+	parsed := make([]*Share, len(shares))
+	for i, share := range shares {
+		var err error
+		parsed[i], err = ParseShare(share.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return parsed
+}
+
+func TestCombineEverySubset(t *testing.T) {
+	priv := testKey(t, 0x01)
+	shares, err := Split(priv, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares = roundTrip(t, shares)
+
+	// Every subset of at least 3 of the 5 shares, in any order.
+	for mask := 0; mask < 1<<len(shares); mask++ {
+		var subset []*Share
+		for i := len(shares) - 1; i >= 0; i-- {
+			if mask&(1<<i) != 0 {
+				subset = append(subset, shares[i])
+			}
+		}
+		if len(subset) < 3 {
+			continue
+		}
+
+		got, err := Combine(subset)
+		if err != nil {
+			t.Fatalf("subset %05b: %v", mask, err)
+		}
+		if !bytes.Equal(got.Serialize(), priv.Serialize()) {
+			t.Errorf("subset %05b reconstructs the wrong key", mask)
+		}
+	}
+}
+
+func TestSplitIsRandomized(t *testing.T) {
+	priv := testKey(t, 0x01)
+	a, err := Split(priv, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Split(priv, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a[0].String() == b[0].String() {
+		t.Error("two splits gave the same share")
+	}
+
+	// With k = 1 every share is the key itself.
+	single, err := Split(priv, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, share := range single {
+		if share.Value != priv.Key {
+			t.Errorf("1-of-2 share %d is not the key", share.Index)
+		}
+	}
+}
+
+func TestCombineInsufficientShares(t *testing.T) {
+	shares, err := Split(testKey(t, 0x01), 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range []int{0, 1, 2} {
+		if _, err := Combine(shares[:n]); !errors.Is(err, ErrNotEnoughShares) {
+			t.Errorf("%d shares: got %v, want ErrNotEnoughShares", n, err)
+		}
+	}
+}
+
+func TestCombineRejects(t *testing.T) {
+	shares, err := Split(testKey(t, 0x01), 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := Split(testKey(t, 0x02), 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherThreshold, err := Split(testKey(t, 0x01), 3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A share with a valid checksum but the value of another split
+	// passes the key id check and fails on the reconstructed key.
+	forged := *shares[1]
+	forged.Value = other[1].Value
+
+	tests := []struct {
+		name   string
+		shares []*Share
+		want   error
+	}{
+		{"duplicate index", []*Share{shares[0], shares[0]}, ErrDuplicateShare},
+		{"different keys", []*Share{shares[0], other[1]}, ErrMixedShares},
+		{"different thresholds", []*Share{shares[0], otherThreshold[1], otherThreshold[2]}, ErrMixedShares},
+		{"index 0", []*Share{shares[0], {Threshold: 2, KeyID: shares[0].KeyID}}, ErrShareFormat},
+		{"wrong value", []*Share{shares[0], &forged}, ErrWrongKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Combine(tt.shares); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseShareRejects(t *testing.T) {
+	shares, err := Split(testKey(t, 0x01), 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := shares[0].String()
+	parts := strings.Split(text, "-")
+
+	// replace returns text with part i set to s.
+	replace := func(i int, s string) string {
+		changed := append([]string(nil), parts...)
+		changed[i] = s
+		return strings.Join(changed, "-")
+	}
+	// typo changes the first hex digit of part i.
+	typo := func(i int) string {
+		digit := "1"
+		if parts[i][0] == '1' {
+			digit = "2"
+		}
+		return replace(i, digit+parts[i][1:])
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want error
+	}{
+		{"typo in value", typo(3), ErrChecksum},
+		{"typo in key id", typo(2), ErrChecksum},
+		{"typo in checksum", typo(4), ErrChecksum},
+		{"wrong index", replace(1, "2"), ErrChecksum},
+		{"wrong threshold", replace(0, "3"), ErrChecksum},
+		{"missing part", strings.Join(parts[:4], "-"), ErrShareFormat},
+		{"short value", replace(3, parts[3][2:]), ErrShareFormat},
+		{"not hex", replace(3, "zz"+parts[3][2:]), ErrShareFormat},
+		{"index too large", replace(1, "256"), ErrShareFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseShare(tt.text); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseShare("  " + text + "\n"); err != nil {
+		t.Errorf("surrounding whitespace: %v", err)
+	}
+}
+
+func TestSplitRejects(t *testing.T) {
+	priv := testKey(t, 0x01)
+	for _, kn := range [][2]int{{0, 3}, {4, 3}, {2, 256}} {
+		if _, err := Split(priv, kn[0], kn[1]); !errors.Is(err, ErrInvalidThreshold) {
+			t.Errorf("%d of %d: got %v, want ErrInvalidThreshold", kn[0], kn[1], err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+func sign(args []string) {
+	flags := flag.NewFlagSet("sign", flag.ExitOnError)
+	input := addInputFlags(flags)
+	input.now = time.Now
+	output := flags.String("output", "text", "output format: text or json")
+	pubKeyFormatName := addPubKeyFormatFlag(flags)
+	sigLayoutName := addSigLayoutFlag(flags)
+	key := addKeyFlags(flags)
+	deterministic := flags.Bool("deterministic", false, "derive the nonce from key and message only (RFC6979)")
+	batchFile := flags.String("batch-file", "", "sign every line of a file, hex (0x-prefixed) or raw")
+	tweakHex := flags.String("tweak", "", "sign with the key tweaked by this hex commitment (BIP341)")
+	typedDataFile := flags.String("eip712", "", "sign the EIP-712 digest of a JSON typed data file")
+	workers := flags.Int("workers", 1, "hash and sign -batch-file lines on this many goroutines")
+	showStats := flags.Bool("stats", false, "print hash and sign timings for -batch-file to stderr")
+	jsonLines := flags.Bool("json", false, "-batch-file lines are JSON requests with a message or digest")
+	parseFlags(flags, args)
+
+	if *output != "text" && *output != "json" {
+		fatal(usageError("Unknown output format %q", *output))
+	}
+	pubKeyFormat := parsePubKeyFormat(*pubKeyFormatName)
+	sigLayout := parseSigLayout(*sigLayoutName)
+	if *workers < 1 {
+		fatal(usageError("-workers must be at least 1"))
+	}
+
+	if *showStats && *batchFile == "" {
+		fatal(usageError("-stats is only used with -batch-file"))
+	}
+	if *jsonLines && (*batchFile == "" || *input.hashOnly) {
+		fatal(usageError("-json is only used with -batch-file and cannot be combined with -hash-only"))
+	}
+
+	var stats *signStats
+	if *showStats {
+		stats = &signStats{}
+	}
+
+	var (
+		hashes [][]byte
+		nonces []*big.Int
+	)
+
+	switch {
+	case *typedDataFile != "":
+		if input.sources() > 0 || *batchFile != "" || *input.domain != "" || *input.nonce != "" || *input.timestamp != "" || *input.canonical || !input.hash.isDefault() {
+			fatal(usageError("-eip712 cannot be combined with other message sources, -domain, -nonce, -timestamp, -canonical-json or -hash"))
+		}
+		hash, err := readTypedData(*typedDataFile)
+		if err != nil {
+			fatal(inputError("Error reading typed data: %w", err))
+		}
+		hashes = [][]byte{hash}
+	case *batchFile != "":
+		if input.sources() > 0 {
+			fatal(usageError("-batch-file cannot be combined with -message, -message-file, -stdin or -fields"))
+		}
+		if *input.nonce != "" || *input.timestamp != "" || *input.canonical {
+			fatal(usageError("-batch-file cannot be combined with -nonce, -timestamp or -canonical-json"))
+		}
+		domain, err := input.domainTag()
+		if err != nil {
+			fatal(inputError("Error reading batch file: %w", err))
+		}
+		hashes, nonces, err = readBatch(*batchFile, *workers, input.hash.newHasher, *input.hashOnly, *jsonLines, domain, stats)
+		if err != nil {
+			fatal(inputError("Error reading batch file: %w", err))
+		}
+	default:
+		hash, err := input.digest()
+		if err != nil {
+			fatal(fmt.Errorf("Error reading message: %w", err))
+		}
+		hashes = [][]byte{hash}
+	}
+
+	privateKey, _ := key.load()
+
+	if *tweakHex != "" {
+		tweak, err := signer.DecodeHex(*tweakHex)
+		if err != nil {
+			fatal(inputError("Error decoding tweak: %w", err))
+		}
+
+		tweaked, err := signer.TweakPrivKey(privateKey, tweak)
+		if err != nil {
+			fatal(keyError("Error tweaking key: %w", err))
+		}
+		privateKey.Zero()
+		privateKey = tweaked
+	}
+
+	s := newSigner(privateKey, *deterministic)
+	defer zeroSigner(s)
+
+	signatures, err := signAll(s, hashes, *workers, stats)
+	if err != nil {
+		fatal(signError("Error signing message: %w", err))
+	}
+
+	for i, hash := range hashes {
+		out, err := newSignOutput(s.PublicKey(), pubKeyFormat, sigLayout, hash, signatures[i])
+		if err != nil {
+			fatal(fmt.Errorf("Error encoding signature: %w", err))
+		}
+		if nonces != nil && nonces[i] != nil {
+			out.Nonce = nonces[i].String()
+		} else if nonce, _ := input.nonceValue(); nonce != nil {
+			out.Nonce = nonce.String()
+		}
+		if timestamp, _ := input.timestampValue(); timestamp != nil {
+			out.Timestamp = strconv.FormatInt(timestamp.Unix(), 10)
+		}
+		out.print(*output)
+	}
+
+	if stats != nil {
+		stats.report(os.Stderr)
+	}
+}
@@ -0,0 +1,63 @@
+package signer
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// Address is a 20-byte EVM address.
+type Address [20]byte
+
+// EthereumAddress returns the address an ECDSA signer holding the same
+// private key would have: the last 20 bytes of keccak256(X || Y) of the
+// uncompressed public key.
+func EthereumAddress(pub *btcec.PublicKey) Address {
+	var address Address
+	uncompressed := pub.SerializeUncompressed()
+	copy(address[:], HashMessage(uncompressed[1:])[12:])
+	return address
+}
+
+// Hex returns the EIP-55 checksummed hex form of the address.
+func (a Address) Hex() string {
+	lower := hex.EncodeToString(a[:])
+	hash := HashMessage([]byte(lower))
+
+	checksummed := []byte(lower)
+	for i, c := range checksummed {
+		nibble := hash[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if c >= 'a' && nibble&0xf >= 8 {
+			checksummed[i] = c - 'a' + 'A'
+		}
+	}
+
+	return "0x" + string(checksummed)
+}
+
+// String implements fmt.Stringer.
+func (a Address) String() string {
+	return a.Hex()
+}
+
+// ParseAddress decodes a 0x-prefixed 20-byte hex address. The checksum
+// is not enforced.
+func ParseAddress(hexStr string) (Address, error) {
+	var address Address
+
+	decoded, err := DecodeHex(hexStr)
+	if err != nil {
+		return address, err
+	}
+
+	if len(decoded) != len(address) {
+		return address, fmt.Errorf("%w: address must be %d bytes, got %d", ErrDecodeMessage, len(address), len(decoded))
+	}
+
+	copy(address[:], decoded)
+	return address, nil
+}
@@ -0,0 +1,53 @@
+package signer
+
+import "testing"
+
+// The addresses of private keys 1 and 2 are widely published, for
+// example as the first accounts of the Ethereum test suites.
+func TestEthereumAddressKnownKeys(t *testing.T) {
+	tests := []struct {
+		key     byte
+		address string
+	}{
+		{1, "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf"},
+		{2, "0x2B5AD5c4795c026514f8317c7a215E218DcCD6cF"},
+	}
+
+	for _, tt := range tests {
+		keyBytes := make([]byte, 32)
+		keyBytes[31] = tt.key
+
+		priv, err := PrivateKeyFromBytes(keyBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := EthereumAddress(priv.PubKey()).Hex(); got != tt.address {
+			t.Errorf("key %d: address %s, want %s", tt.key, got, tt.address)
+		}
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	const checksummed = "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf"
+
+	address, err := ParseAddress(checksummed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address.String() != checksummed {
+		t.Errorf("round trip gave %s", address)
+	}
+
+	// The checksum is not enforced.
+	lower, err := ParseAddress("0x7e5f4552091a69125d5dfcb7b8c2659029395bdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lower != address {
+		t.Error("lower case address parsed differently")
+	}
+
+	if _, err := ParseAddress("0x7e5f"); err == nil {
+		t.Error("short address accepted")
+	}
+}
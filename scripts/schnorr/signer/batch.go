@@ -0,0 +1,188 @@
+package signer
+
+import (
+	"crypto/rand"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// BatchVerify checks many signatures at once and returns whether all of
+// them are valid along with the indices of the invalid ones. msgs are
+// 32-byte digests. Mismatched slice lengths report false with no
+// indices.
+//
+// It uses the BIP340 batch equation with random weights a_i, a_0 = 1:
+//
+//	(sum a_i*s_i)*G == sum a_i*R_i + sum (a_i*e_i)*P_i
+//
+// The right hand side is evaluated as a single multi scalar
+// multiplication and terms for the same public key are merged, which
+// makes a batch from a stable validator set much cheaper than verifying
+// one by one. If the batch equation fails each signature is checked on
+// its own to find the culprits.
+func BatchVerify(pubs []*btcec.PublicKey, msgs [][]byte, sigs []*schnorr.Signature) (bool, []int) {
+	if len(pubs) != len(msgs) || len(pubs) != len(sigs) {
+		return false, nil
+	}
+
+	if len(sigs) == 0 {
+		return true, nil
+	}
+
+	if batchEquationHolds(pubs, msgs, sigs) {
+		return true, nil
+	}
+
+	var failures []int
+	for i := range sigs {
+		if len(msgs[i]) != 32 || !sigs[i].Verify(msgs[i], pubs[i]) {
+			failures = append(failures, i)
+		}
+	}
+
+	return len(failures) == 0, failures
+}
+
+func batchEquationHolds(pubs []*btcec.PublicKey, msgs [][]byte, sigs []*schnorr.Signature) bool {
+	var (
+		sSum   btcec.ModNScalar
+		terms  = make([]*msmTerm, 0, 2*len(sigs))
+		keys   = map[[32]byte]*msmTerm{}
+		weight = map[*msmTerm]*btcec.ModNScalar{}
+	)
+
+	for i, sig := range sigs {
+		if len(msgs[i]) != 32 {
+			return false
+		}
+
+		rBytes, sBytes, err := SplitSignature(sig)
+		if err != nil {
+			return false
+		}
+
+		var s btcec.ModNScalar
+		if overflow := s.SetBytes(&sBytes); overflow != 0 {
+			return false
+		}
+
+		r, err := schnorr.ParsePubKey(rBytes[:])
+		if err != nil {
+			return false
+		}
+
+		var pubX [32]byte
+		copy(pubX[:], schnorr.SerializePubKey(pubs[i]))
+
+		var e btcec.ModNScalar
+		e.SetByteSlice(chainhash.TaggedHash(chainhash.TagBIP0340Challenge, rBytes[:], pubX[:], msgs[i])[:])
+
+		a, err := batchWeight(i)
+		if err != nil {
+			return false
+		}
+
+		s.Mul(a)
+		sSum.Add(&s)
+
+		terms = append(terms, newMSMTerm(r, a))
+
+		term, ok := keys[pubX]
+		if !ok {
+			even, err := schnorr.ParsePubKey(pubX[:])
+			if err != nil {
+				return false
+			}
+			term = newMSMTerm(even, new(btcec.ModNScalar))
+			keys[pubX] = term
+			weight[term] = new(btcec.ModNScalar)
+			terms = append(terms, term)
+		}
+		weight[term].Add(e.Mul(a))
+	}
+
+	for term, w := range weight {
+		term.scalar = w.Bytes()
+	}
+
+	var lhs btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(&sSum, &lhs)
+	rhs := multiScalarMult(terms)
+
+	return pointsEqual(&lhs, &rhs)
+}
+
+// msmTerm is one k*P term of a multi scalar multiplication, with P, 2P,
+// ..., 15P precomputed for 4-bit windows.
+type msmTerm struct {
+	table  [15]btcec.JacobianPoint
+	scalar [32]byte
+}
+
+func newMSMTerm(point *btcec.PublicKey, scalar *btcec.ModNScalar) *msmTerm {
+	term := &msmTerm{scalar: scalar.Bytes()}
+	point.AsJacobian(&term.table[0])
+	for i := 1; i < len(term.table); i++ {
+		btcec.AddNonConst(&term.table[i-1], &term.table[0], &term.table[i])
+	}
+	return term
+}
+
+// multiScalarMult computes sum k_i*P_i with Straus' method: all terms
+// share the same 256 doublings and each only pays one addition per
+// nonzero 4-bit window of its scalar.
+func multiScalarMult(terms []*msmTerm) btcec.JacobianPoint {
+	var acc btcec.JacobianPoint
+
+	for nibble := 0; nibble < 64; nibble++ {
+		if nibble > 0 {
+			for i := 0; i < 4; i++ {
+				btcec.DoubleNonConst(&acc, &acc)
+			}
+		}
+
+		for _, term := range terms {
+			b := term.scalar[nibble/2]
+			if nibble%2 == 0 {
+				b >>= 4
+			}
+			if b &= 0x0f; b != 0 {
+				btcec.AddNonConst(&acc, &term.table[b-1], &acc)
+			}
+		}
+	}
+
+	return acc
+}
+
+func pointsEqual(a, b *btcec.JacobianPoint) bool {
+	if a.Z.IsZero() || b.Z.IsZero() {
+		return a.Z.IsZero() && b.Z.IsZero()
+	}
+
+	a.ToAffine()
+	b.ToAffine()
+	return a.X.Equals(&b.X) && a.Y.Equals(&b.Y)
+}
+
+// batchWeight returns 1 for the first signature and a random nonzero
+// 128-bit scalar for the others, which is enough to make a forged batch
+// pass with negligible probability while halving the work on R.
+func batchWeight(i int) (*btcec.ModNScalar, error) {
+	a := new(btcec.ModNScalar)
+	if i == 0 {
+		return a.SetInt(1), nil
+	}
+
+	var buf [32]byte
+	for a.IsZero() {
+		if _, err := rand.Read(buf[16:]); err != nil {
+			return nil, err
+		}
+		a.SetBytes(&buf)
+	}
+
+	return a, nil
+}
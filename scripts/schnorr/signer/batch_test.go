@@ -0,0 +1,133 @@
+package signer
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// signedBatch returns n signatures over distinct messages, made round
+// robin by the given number of distinct keys.
+func signedBatch(t testing.TB, n, keys int) ([]*btcec.PublicKey, [][]byte, []*schnorr.Signature) {
+	t.Helper()
+
+	privs := make([]*btcec.PrivateKey, keys)
+	for i := range privs {
+		priv, err := btcec.NewPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs[i] = priv
+	}
+
+	pubs := make([]*btcec.PublicKey, n)
+	msgs := make([][]byte, n)
+	sigs := make([]*schnorr.Signature, n)
+	for i := range sigs {
+		priv := privs[i%keys]
+		msgs[i] = HashMessage([]byte(fmt.Sprintf("message %d", i)))
+		sig, err := SignMessage(priv, msgs[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubs[i], sigs[i] = priv.PubKey(), sig
+	}
+	return pubs, msgs, sigs
+}
+
+func TestBatchVerify(t *testing.T) {
+	pubs, msgs, sigs := signedBatch(t, 64, 8)
+
+	if ok, failures := BatchVerify(pubs, msgs, sigs); !ok || failures != nil {
+		t.Fatalf("valid batch: ok=%v failures=%v", ok, failures)
+	}
+
+	// Swap in signatures over other messages at a few positions.
+	bad := append([]*schnorr.Signature(nil), sigs...)
+	bad[3], bad[40] = sigs[40], sigs[3]
+	bad[17] = sigs[18]
+
+	ok, failures := BatchVerify(pubs, msgs, bad)
+	if ok {
+		t.Fatal("batch with invalid signatures verified")
+	}
+	if want := []int{3, 17, 40}; !reflect.DeepEqual(failures, want) {
+		t.Errorf("failures %v, want %v", failures, want)
+	}
+}
+
+func TestBatchVerifyEdgeCases(t *testing.T) {
+	pubs, msgs, sigs := signedBatch(t, 2, 1)
+
+	if ok, failures := BatchVerify(nil, nil, nil); !ok || failures != nil {
+		t.Errorf("empty batch: ok=%v failures=%v", ok, failures)
+	}
+	if ok, _ := BatchVerify(pubs, msgs[:1], sigs); ok {
+		t.Error("mismatched lengths verified")
+	}
+
+	short := [][]byte{msgs[0], msgs[1][:31]}
+	if ok, failures := BatchVerify(pubs, short, sigs); ok || !reflect.DeepEqual(failures, []int{1}) {
+		t.Errorf("short message: ok=%v failures=%v", ok, failures)
+	}
+}
+
+// The batch equation merges terms for the same key, so a batch from a
+// stable validator set is where it pays off.
+const benchBatchSize, benchBatchKeys = 256, 21
+
+func BenchmarkSignMessage(b *testing.B) {
+	priv := testKey(b, 0x01)
+	msg := HashMessage([]byte("benchmark"))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := SignMessage(priv, msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	priv := testKey(b, 0x01)
+	msg := HashMessage([]byte("benchmark"))
+	sig, err := SignMessage(priv, msg)
+	if err != nil {
+		b.Fatal(err)
+	}
+	pub := priv.PubKey()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !VerifyMessage(pub, msg, sig) {
+			b.Fatal("signature does not verify")
+		}
+	}
+}
+
+func BenchmarkVerifyEach256(b *testing.B) {
+	pubs, msgs, sigs := signedBatch(b, benchBatchSize, benchBatchKeys)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range sigs {
+			if !VerifyMessage(pubs[j], msgs[j], sigs[j]) {
+				b.Fatal("signature does not verify")
+			}
+		}
+	}
+}
+
+func BenchmarkBatchVerify256(b *testing.B) {
+	pubs, msgs, sigs := signedBatch(b, benchBatchSize, benchBatchKeys)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ok, _ := BatchVerify(pubs, msgs, sigs); !ok {
+			b.Fatal("batch does not verify")
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// CanonicalJSON returns v encoded as JSON with object keys sorted, no
+// insignificant whitespace and no HTML escaping, so equal values give
+// the same bytes however their keys were ordered. v is first encoded
+// with encoding/json, which writes a []byte as a base64 string, so
+// pass JSON text as json.RawMessage(data) to canonicalize it. Numbers
+// are written as they appear in the encoding. An object with the same
+// key twice is rejected, since decoders disagree on which value wins.
+// Errors wrap ErrDecodeMessage.
+func CanonicalJSON(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecodeMessage, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var out bytes.Buffer
+	if err := writeCanonical(&out, decoder); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecodeMessage, err)
+	}
+	return out.Bytes(), nil
+}
+
+// writeCanonical copies the next JSON value from decoder to out in
+// canonical form.
+func writeCanonical(out *bytes.Buffer, decoder *json.Decoder) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		if token == '[' {
+			out.WriteByte('[')
+			for i := 0; decoder.More(); i++ {
+				if i > 0 {
+					out.WriteByte(',')
+				}
+				if err := writeCanonical(out, decoder); err != nil {
+					return err
+				}
+			}
+			out.WriteByte(']')
+			_, err := decoder.Token()
+			return err
+		}
+		return writeCanonicalObject(out, decoder)
+	case string:
+		return writeString(out, token)
+	case json.Number:
+		out.WriteString(token.String())
+	case bool:
+		fmt.Fprint(out, token)
+	case nil:
+		out.WriteString("null")
+	}
+	return nil
+}
+
+// writeCanonicalObject writes the members of the object whose opening
+// brace was just read, sorted by key.
+func writeCanonicalObject(out *bytes.Buffer, decoder *json.Decoder) error {
+	members := make(map[string][]byte)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key := token.(string)
+		if _, ok := members[key]; ok {
+			return fmt.Errorf("key %q appears twice", key)
+		}
+
+		var value bytes.Buffer
+		if err := writeCanonical(&value, decoder); err != nil {
+			return err
+		}
+		members[key] = value.Bytes()
+	}
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(members))
+	for key := range members {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		if err := writeString(out, key); err != nil {
+			return err
+		}
+		out.WriteByte(':')
+		out.Write(members[key])
+	}
+	out.WriteByte('}')
+	return nil
+}
+
+func writeString(out *bytes.Buffer, s string) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(s); err != nil {
+		return err
+	}
+	// Encode ends every value with a newline.
+	out.Truncate(out.Len() - 1)
+	return nil
+}
@@ -0,0 +1,98 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"sorted keys", `{"b": 1, "a": 2}`, `{"a":2,"b":1}`},
+		{"nested", `{"z": {"y": [3, {"b": true, "a": null}], "x": "s"}, "a": []}`, `{"a":[],"z":{"x":"s","y":[3,{"a":null,"b":true}]}}`},
+		{"whitespace", " \n{ \"a\" :\t[ 1 , 2 ] }\n", `{"a":[1,2]}`},
+		{"numbers as written", `{"price": 1.50, "big": 123456789012345678901234567890, "exp": 1e3}`, `{"big":123456789012345678901234567890,"exp":1e3,"price":1.50}`},
+		{"no HTML escaping", `{"q": "<a & b>"}`, `{"q":"<a & b>"}`},
+		{"escapes normalized", `{"s": "A\/\n"}`, `{"s":"A/\n"}`},
+		{"byte order of keys", `{"b": 0, "B": 0, "é": 0, "a": 0}`, `{"B":0,"a":0,"b":0,"é":0}`},
+		{"scalar", `"plain"`, `"plain"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CanonicalJSON(json.RawMessage(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			again, err := CanonicalJSON(json.RawMessage(got))
+			if err != nil || !bytes.Equal(again, got) {
+				t.Errorf("canonical form is not stable: %s, %v", again, err)
+			}
+		})
+	}
+}
+
+func TestCanonicalJSONOrderIndependent(t *testing.T) {
+	first := `{"feed": "BTC/USD", "price": 65000, "meta": {"round": 12, "source": "a"}}`
+	second := `{"meta": {"source": "a", "round": 12}, "price": 65000, "feed": "BTC/USD"}`
+
+	// A Go value with the same content encodes the same as the text.
+	type meta struct {
+		Source string `json:"source"`
+		Round  int    `json:"round"`
+	}
+	value := map[string]any{"price": 65000, "feed": "BTC/USD", "meta": meta{"a", 12}}
+
+	var encodings [][]byte
+	for _, v := range []any{json.RawMessage(first), json.RawMessage(second), value} {
+		encoded, err := CanonicalJSON(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encodings = append(encodings, encoded)
+	}
+	for i, encoded := range encodings[1:] {
+		if !bytes.Equal(encoded, encodings[0]) {
+			t.Fatalf("encoding %d is %s, want %s", i+1, encoded, encodings[0])
+		}
+	}
+
+	priv := testKey(t, 0x01)
+	sigs := make([][]byte, 2)
+	for i, encoded := range encodings[:2] {
+		sig, err := SignDeterministic(priv, HashMessage(encoded))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sigs[i] = sig.Serialize()
+	}
+	if !bytes.Equal(sigs[0], sigs[1]) {
+		t.Error("two orderings of the same object gave different signatures")
+	}
+
+	// Without canonicalizing, the orderings hash differently.
+	if bytes.Equal(HashMessage([]byte(first)), HashMessage([]byte(second))) {
+		t.Error("raw orderings hash the same")
+	}
+}
+
+func TestCanonicalJSONRejects(t *testing.T) {
+	for name, v := range map[string]any{
+		"duplicate key":        json.RawMessage(`{"a": 1, "a": 2}`),
+		"nested duplicate key": json.RawMessage(`{"x": [{"a": 1, "a": 1}]}`),
+		"invalid JSON":         json.RawMessage(`{"a": }`),
+		"unencodable":          make(chan int),
+	} {
+		if _, err := CanonicalJSON(v); !errors.Is(err, ErrDecodeMessage) {
+			t.Errorf("%s: got %v, want ErrDecodeMessage", name, err)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package signer
+
+import "errors"
+
+// Sentinel errors returned, possibly wrapped, by this package. Use
+// errors.Is to branch on them.
+var (
+	ErrInvalidKey       = errors.New("invalid key")
+	ErrDecodeMessage    = errors.New("could not decode message")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrSignFailed       = errors.New("signing failed")
+	ErrUnknownFormat    = errors.New("unknown public key format")
+	ErrUnknownLayout    = errors.New("unknown signature layout")
+	ErrInvalidNonce     = errors.New("invalid nonce")
+	ErrInvalidTimestamp = errors.New("invalid timestamp")
+	ErrUnknownHash      = errors.New("unknown hash function")
+	ErrNoKeys           = errors.New("no public keys given")
+	ErrTooManyKeys      = errors.New("too many public keys")
+	ErrNoMatch          = errors.New("no public key matches the signature")
+)
@@ -0,0 +1,66 @@
+package signer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	priv := testKey(t, 0x01)
+
+	tests := []struct {
+		name string
+		err  func() error
+		want error
+	}{
+		{"malformed hex key", func() error {
+			_, _, err := LoadKeyFromHex("0xnot-hex")
+			return err
+		}, ErrInvalidKey},
+		{"short key", func() error {
+			_, _, err := LoadKeyFromHex("0x0102")
+			return err
+		}, ErrInvalidKey},
+		{"malformed public key", func() error {
+			_, err := ParsePublicKey("0x1234")
+			return err
+		}, ErrInvalidKey},
+		{"public key off the curve", func() error {
+			_, err := ParsePublicKey(strings.Repeat("ff", 32))
+			return err
+		}, ErrInvalidKey},
+		{"message not hex", func() error {
+			_, err := DecodeHex("0xzz")
+			return err
+		}, ErrDecodeMessage},
+		{"hash too short", func() error {
+			_, err := DecodeHash("0x" + strings.Repeat("ab", 31))
+			return err
+		}, ErrDecodeMessage},
+		{"signature not hex", func() error {
+			_, err := ParseSignature("0xzz")
+			return err
+		}, ErrInvalidSignature},
+		{"signature too short", func() error {
+			_, err := ParseSignature(strings.Repeat("01", 63))
+			return err
+		}, ErrInvalidSignature},
+		{"signing a message that is not a digest", func() error {
+			_, err := SignMessage(priv, []byte("not 32 bytes"))
+			return err
+		}, ErrSignFailed},
+		{"deterministic signing a message that is not a digest", func() error {
+			_, err := SignDeterministic(priv, make([]byte, 33))
+			return err
+		}, ErrSignFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.err(); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
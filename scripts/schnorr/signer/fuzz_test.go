@@ -0,0 +1,116 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+func FuzzSignVerify(f *testing.F) {
+	f.Add([]byte("Hello, world!"))
+	f.Add([]byte{})
+	f.Add(bytes.Repeat([]byte{0xff}, 1<<16))
+
+	priv := testKey(f, 0x01)
+	pub := priv.PubKey()
+
+	f.Fuzz(func(t *testing.T, msg []byte) {
+		hash := HashMessage(msg)
+		sig, err := SignMessage(priv, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyMessage(pub, hash, sig) {
+			t.Fatal("signature does not verify")
+		}
+
+		serialized := sig.Serialize()
+		for _, bit := range []int{0, 255, 256, 511} {
+			flipped := append([]byte(nil), serialized...)
+			flipped[bit/8] ^= 1 << (bit % 8)
+			if verifiesSerialized(pub, hash, flipped) {
+				t.Fatalf("signature with bit %d flipped verifies", bit)
+			}
+		}
+
+		other := append([]byte(nil), hash...)
+		other[0] ^= 1
+		if VerifyMessage(pub, other, sig) {
+			t.Fatal("signature verifies over a flipped hash")
+		}
+	})
+}
+
+// verifiesSerialized reports whether sig parses and verifies.
+func verifiesSerialized(pub *btcec.PublicKey, hash, sig []byte) bool {
+	parsed, err := ParseSignature(hex.EncodeToString(sig))
+	return err == nil && VerifyMessage(pub, hash, parsed)
+}
+
+func FuzzParseSignature(f *testing.F) {
+	sig, err := SignDeterministic(testKey(f, 0x01), HashMessage([]byte("Hello, world!")))
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add("0x" + hex.EncodeToString(sig.Serialize()))
+	f.Add(strings.Repeat("ff", 64))
+	f.Add("0x")
+	f.Add("zz")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		parsed, err := ParseSignature(s)
+		if err != nil {
+			return
+		}
+		raw, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+		if err != nil {
+			t.Fatalf("ParseSignature accepted %q, which is not hex", s)
+		}
+		// ParseSignature reduces an s past the curve order, so only a
+		// canonical signature serializes back to its input.
+		if IsCanonical(raw) && !bytes.Equal(parsed.Serialize(), raw) {
+			t.Fatalf("%q serializes back to %x", s, parsed.Serialize())
+		}
+	})
+}
+
+// FuzzDecoders feeds the same string to every hex decoder for keys,
+// messages, signatures and addresses. Malformed input must give an
+// error, never a panic or a value of the wrong size.
+func FuzzDecoders(f *testing.F) {
+	f.Add("0x" + hex.EncodeToString([]byte("Hello, world!")))
+	f.Add(strings.Repeat("01", 32))
+	f.Add("0x1b84c5567b126440995d3ed5aaba0565d71e1834604819ff9c17f5e9d5dd078f")
+	f.Add("0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf")
+	f.Add(" 0x00 ")
+	f.Add("0x0")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		if hash, err := DecodeHash(s); err == nil && len(hash) != 32 {
+			t.Fatalf("DecodeHash gave %d bytes", len(hash))
+		}
+		if priv, pub, err := LoadKeyFromHex(s); err == nil {
+			if priv.Key.IsZero() || !priv.PubKey().IsEqual(pub) {
+				t.Fatalf("LoadKeyFromHex(%q) gave an unusable key", s)
+			}
+		}
+		if pub, err := ParsePublicKey(s); err == nil {
+			if !strings.EqualFold(hex.EncodeToString(XOnlyPubKey(pub)), strings.TrimPrefix(s, "0x")) {
+				t.Fatalf("ParsePublicKey(%q) gave another key", s)
+			}
+		}
+		if address, err := ParseAddress(s); err == nil {
+			if decoded, _ := DecodeHex(s); !bytes.Equal(address[:], decoded) {
+				t.Fatalf("ParseAddress(%q) gave %s", s, address.Hex())
+			}
+		}
+
+		// ParseSignature has FuzzParseSignature; here it only must not
+		// panic.
+		ParseSignature(s)
+	})
+}
@@ -0,0 +1,86 @@
+package signer
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// Hasher turns a message into the 32-byte digest that gets signed.
+type Hasher interface {
+	Hash(msg []byte) []byte
+}
+
+// Names accepted by NewHasher.
+const (
+	HashKeccak256 = "keccak256"
+	HashSHA256    = "sha256"
+	HashBIP340    = "bip340"
+)
+
+// SHA256Hasher hashes messages with SHA-256.
+type SHA256Hasher struct{}
+
+// Hash returns the SHA-256 digest of msg.
+func (SHA256Hasher) Hash(msg []byte) []byte {
+	digest := sha256.Sum256(msg)
+	return digest[:]
+}
+
+// Tags BIP340 and BIP341 derive their nonces, challenges and tweaks
+// under, for protocols that need to match them.
+const (
+	TagAux       = "BIP0340/aux"
+	TagNonce     = "BIP0340/nonce"
+	TagChallenge = "BIP0340/challenge"
+	TagTapTweak  = "TapTweak"
+)
+
+// TaggedHash returns the BIP340 tagged hash
+// sha256(sha256(tag) || sha256(tag) || msgs...) of the concatenated msgs.
+func TaggedHash(tag string, msgs ...[]byte) [32]byte {
+	return *chainhash.TaggedHash([]byte(tag), msgs...)
+}
+
+// TaggedHasher hashes messages with the BIP340 tagged hash
+// sha256(sha256(Tag) || sha256(Tag) || msg).
+type TaggedHasher struct {
+	Tag []byte
+}
+
+// Hash returns the tagged hash of msg.
+func (h TaggedHasher) Hash(msg []byte) []byte {
+	hash := TaggedHash(string(h.Tag), msg)
+	return hash[:]
+}
+
+// NewHasher returns the hasher registered under name. tag is only used,
+// and then required, by bip340. The returned hasher may keep state and
+// is not safe for concurrent use.
+func NewHasher(name string, tag []byte) (Hasher, error) {
+	switch name {
+	case HashKeccak256:
+		return NewMessageHasher(), nil
+	case HashSHA256:
+		return SHA256Hasher{}, nil
+	case HashBIP340:
+		if len(tag) == 0 {
+			return nil, errors.New("bip340 hashing needs a tag")
+		}
+		return TaggedHasher{Tag: tag}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownHash, name)
+	}
+}
+
+// HashWithDomain is HashMessageWithDomain with h in place of keccak256:
+// h(h(domain) || msg).
+func HashWithDomain(h Hasher, domain, msg []byte) []byte {
+	tag := h.Hash(domain)
+	tagged := make([]byte, 0, len(tag)+len(msg))
+	tagged = append(tagged, tag...)
+	tagged = append(tagged, msg...)
+	return h.Hash(tagged)
+}
@@ -0,0 +1,105 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// The digests of "abc" were computed independently of this package.
+func TestHasherDigests(t *testing.T) {
+	tests := []struct {
+		name   string
+		tag    string
+		digest string
+	}{
+		{HashKeccak256, "", "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45"},
+		{HashSHA256, "", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{HashBIP340, TagChallenge, "770a5b7e7c304bbcc3ea107343ff951dd404312ef418db0c3b94e2ebfbb50087"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewHasher(tt.name, []byte(tt.tag))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Twice, since the keccak256 hasher reuses its state.
+			for i := 0; i < 2; i++ {
+				if got := hex.EncodeToString(h.Hash([]byte("abc"))); got != tt.digest {
+					t.Errorf("digest %s, want %s", got, tt.digest)
+				}
+			}
+		})
+	}
+}
+
+// TaggedHash must be sha256(sha256(tag) || sha256(tag) || msgs...) for
+// every tag BIP340 and BIP341 use, written out here without chainhash.
+func TestTaggedHash(t *testing.T) {
+	for _, tag := range []string{TagAux, TagNonce, TagChallenge, TagTapTweak} {
+		t.Run(tag, func(t *testing.T) {
+			tagHash := sha256.Sum256([]byte(tag))
+			want := sha256.Sum256(bytes.Join([][]byte{tagHash[:], tagHash[:], []byte("unchained")}, nil))
+
+			if got := TaggedHash(tag, []byte("unchained")); got != want {
+				t.Errorf("got %x, want %x", got, want)
+			}
+			// Several messages hash as their concatenation.
+			if got := TaggedHash(tag, []byte("un"), nil, []byte("chained")); got != want {
+				t.Errorf("split message: got %x, want %x", got, want)
+			}
+			if got := (TaggedHasher{Tag: []byte(tag)}).Hash([]byte("unchained")); !bytes.Equal(got, want[:]) {
+				t.Errorf("TaggedHasher: got %x, want %x", got, want)
+			}
+		})
+	}
+
+	if TaggedHash(TagAux, []byte("unchained")) == TaggedHash(TagNonce, []byte("unchained")) {
+		t.Error("two tags hash the same message the same")
+	}
+}
+
+// The default hasher must keep signing exactly what HashMessage did
+// before hashes were configurable.
+func TestKeccak256IsDefault(t *testing.T) {
+	h, err := NewHasher(HashKeccak256, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("backward compatible")
+	if hex.EncodeToString(h.Hash(msg)) != hex.EncodeToString(HashMessage(msg)) {
+		t.Error("keccak256 hasher differs from HashMessage")
+	}
+}
+
+func TestHashWithDomainDigests(t *testing.T) {
+	tests := []struct {
+		name   string
+		digest string
+	}{
+		{HashKeccak256, "f1e11f1519f2997c3671d3b5d6681ea6afd63b0fd18cf8b7643145f56118b77f"},
+		{HashSHA256, "5ebe0d7d26c046de5067b8b7019e0d86eb7cfb07e668925a6e77a6b253de932e"},
+	}
+
+	for _, tt := range tests {
+		h, err := NewHasher(tt.name, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(HashWithDomain(h, []byte("oracle"), []byte("abc"))); got != tt.digest {
+			t.Errorf("%s: digest %s, want %s", tt.name, got, tt.digest)
+		}
+	}
+}
+
+func TestNewHasherRejects(t *testing.T) {
+	if _, err := NewHasher("md5", nil); !errors.Is(err, ErrUnknownHash) {
+		t.Errorf("unknown hash: got %v, want ErrUnknownHash", err)
+	}
+	if _, err := NewHasher(HashBIP340, nil); err == nil {
+		t.Error("bip340 without a tag succeeded")
+	}
+}
@@ -0,0 +1,56 @@
+package signer
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// LoadKeyFromHex decodes a hex encoded private key, with or without
+// a 0x prefix, and returns it along with its public key. The decoded
+// bytes are zeroed before returning; the string itself cannot be.
+func LoadKeyFromHex(hexStr string) (*btcec.PrivateKey, *btcec.PublicKey, error) {
+	keyBytes, err := hex.DecodeString(strings.TrimPrefix(hexStr, "0x"))
+	if err != nil {
+		clear(keyBytes)
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+
+	return loadKey(keyBytes)
+}
+
+// loadKey parses keyBytes as a private key and zeroes them, whether or
+// not they parse.
+func loadKey(keyBytes []byte) (*btcec.PrivateKey, *btcec.PublicKey, error) {
+	defer clear(keyBytes)
+
+	privateKey, err := PrivateKeyFromBytes(keyBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return privateKey, privateKey.PubKey(), nil
+}
+
+// PrivateKeyFromBytes parses a 32-byte big-endian private key scalar.
+// Unlike btcec.PrivKeyFromBytes it rejects keys that are zero or not
+// below the curve order instead of silently reducing them.
+func PrivateKeyFromBytes(keyBytes []byte) (*btcec.PrivateKey, error) {
+	if len(keyBytes) != btcec.PrivKeyBytesLen {
+		return nil, fmt.Errorf("%w: private key must be %d bytes, got %d", ErrInvalidKey, btcec.PrivKeyBytesLen, len(keyBytes))
+	}
+
+	var scalar btcec.ModNScalar
+	defer scalar.Zero()
+	if overflow := scalar.SetByteSlice(keyBytes); overflow {
+		return nil, fmt.Errorf("%w: private key is not below the curve order", ErrInvalidKey)
+	}
+
+	if scalar.IsZero() {
+		return nil, fmt.Errorf("%w: private key is zero", ErrInvalidKey)
+	}
+
+	return btcec.PrivKeyFromScalar(&scalar), nil
+}
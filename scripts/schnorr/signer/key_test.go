@@ -0,0 +1,94 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// The secp256k1 curve order n.
+const curveOrder = "fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141"
+
+func TestLoadKeyFromHexRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{"zero", strings.Repeat("00", 32), "private key is zero"},
+		{"curve order", curveOrder, "not below the curve order"},
+		{"above curve order", "fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364142", "not below the curve order"},
+		{"all ones", strings.Repeat("ff", 32), "not below the curve order"},
+		{"short", strings.Repeat("01", 31), "must be 32 bytes, got 31"},
+		{"long", strings.Repeat("01", 33), "must be 32 bytes, got 33"},
+		{"empty", "", "must be 32 bytes, got 0"},
+		{"not hex", strings.Repeat("zz", 32), "invalid byte"},
+		{"odd length", strings.Repeat("1", 63), "odd length"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := LoadKeyFromHex(tt.key)
+			if !errors.Is(err, ErrInvalidKey) {
+				t.Fatalf("got %v, want ErrInvalidKey", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadKeyFromHexAccepts(t *testing.T) {
+	tests := []string{
+		strings.Repeat("00", 31) + "01",
+		"0x" + strings.Repeat("01", 32),
+		// n - 1, the largest valid key.
+		"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140",
+	}
+
+	for _, key := range tests {
+		priv, pub, err := LoadKeyFromHex(key)
+		if err != nil {
+			t.Errorf("%s: %v", key, err)
+			continue
+		}
+		if !priv.PubKey().IsEqual(pub) {
+			t.Errorf("%s: returned public key does not match the private key", key)
+		}
+	}
+}
+
+func TestLoadKeyZeroesBuffer(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		valid bool
+	}{
+		{"valid", strings.Repeat("01", 32), true},
+		{"above curve order", strings.Repeat("ff", 32), false},
+		{"short", strings.Repeat("01", 31), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := hex.DecodeString(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			priv, _, err := loadKey(buf)
+			if (err == nil) != tt.valid {
+				t.Fatalf("got error %v, want valid=%v", err, tt.valid)
+			}
+			if !bytes.Equal(buf, make([]byte, len(buf))) {
+				t.Errorf("buffer is %x after loading, want zeros", buf)
+			}
+			if tt.valid && hex.EncodeToString(priv.Serialize()) != tt.key {
+				t.Error("zeroing the buffer changed the loaded key")
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package signer
+
+import (
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// Signer produces BIP340 signatures over 32-byte digests without
+// exposing the private key, so keys held in an HSM or a remote KMS can
+// stand in for an in-memory key.
+type Signer interface {
+	Sign(hash []byte) (*schnorr.Signature, error)
+	PublicKey() *btcec.PublicKey
+}
+
+// KeySigner is a Signer backed by an in-memory private key.
+type KeySigner struct {
+	privateKey    *btcec.PrivateKey
+	publicKey     *btcec.PublicKey
+	deterministic bool
+}
+
+// NewKeySigner returns a Signer that signs with SignMessage.
+func NewKeySigner(priv *btcec.PrivateKey) *KeySigner {
+	return &KeySigner{privateKey: priv, publicKey: priv.PubKey()}
+}
+
+// NewDeterministicSigner returns a Signer that signs with
+// SignDeterministic.
+func NewDeterministicSigner(priv *btcec.PrivateKey) *KeySigner {
+	return &KeySigner{privateKey: priv, publicKey: priv.PubKey(), deterministic: true}
+}
+
+// Sign signs hash, which must already be a 32-byte digest.
+func (s *KeySigner) Sign(hash []byte) (*schnorr.Signature, error) {
+	if s.deterministic {
+		return SignDeterministic(s.privateKey, hash)
+	}
+	return SignMessage(s.privateKey, hash)
+}
+
+// PublicKey returns the public key matching the signing key.
+func (s *KeySigner) PublicKey() *btcec.PublicKey {
+	return s.publicKey
+}
+
+// Zero overwrites the private key. The signer must not be used after.
+// Go may have left other copies behind, so this narrows rather than
+// closes the window in which the key sits in memory.
+func (s *KeySigner) Zero() {
+	s.privateKey.Zero()
+}
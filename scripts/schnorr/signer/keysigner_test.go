@@ -0,0 +1,39 @@
+package signer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestKeySigner(t *testing.T) {
+	priv := testKey(t, 0x42)
+	hash := bytes.Repeat([]byte{0x07}, 32)
+
+	for _, s := range []*KeySigner{NewKeySigner(priv), NewDeterministicSigner(priv)} {
+		if !s.PublicKey().IsEqual(priv.PubKey()) {
+			t.Error("public key does not match the private key")
+		}
+		sig, err := s.Sign(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sig.Verify(hash, s.PublicKey()) {
+			t.Error("signature does not verify")
+		}
+	}
+
+	first, _ := NewDeterministicSigner(priv).Sign(hash)
+	second, _ := NewDeterministicSigner(priv).Sign(hash)
+	if !first.IsEqual(second) {
+		t.Error("deterministic signer gave two signatures for one hash")
+	}
+}
+
+func TestKeySignerZero(t *testing.T) {
+	priv := testKey(t, 0x42)
+	s := NewKeySigner(priv)
+	s.Zero()
+	if !priv.Key.IsZero() {
+		t.Error("private key is not zero after Zero")
+	}
+}
@@ -0,0 +1,63 @@
+package signer
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// SigLayout selects the byte order of a serialized signature.
+type SigLayout string
+
+const (
+	// SigLayoutRS is R's X coordinate followed by s, the BIP340
+	// serialization. It is the order the on-chain verifier takes, as
+	// SchnorrSignature.Signature is (uint256 rx, uint256 s).
+	SigLayoutRS SigLayout = "r-s"
+	// SigLayoutSR is s followed by R's X coordinate.
+	SigLayoutSR SigLayout = "s-r"
+)
+
+// ParseSigLayout validates a layout name such as a flag value.
+func ParseSigLayout(name string) (SigLayout, error) {
+	switch layout := SigLayout(name); layout {
+	case SigLayoutRS, SigLayoutSR:
+		return layout, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownLayout, name)
+	}
+}
+
+// SerializeSignature encodes sig as 64 bytes in the given layout.
+func SerializeSignature(sig *schnorr.Signature, layout SigLayout) ([]byte, error) {
+	r, s, err := SplitSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	switch layout {
+	case SigLayoutRS:
+		return append(r[:], s[:]...), nil
+	case SigLayoutSR:
+		return append(s[:], r[:]...), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownLayout, layout)
+	}
+}
+
+// ToRS reorders a 64-byte signature serialized in layout into the
+// R || s order schnorr.ParseSignature and IsCanonical expect.
+func ToRS(sig []byte, layout SigLayout) ([]byte, error) {
+	if len(sig) != schnorr.SignatureSize {
+		return nil, fmt.Errorf("%w: signature must be %d bytes, got %d", ErrInvalidSignature, schnorr.SignatureSize, len(sig))
+	}
+
+	switch layout {
+	case SigLayoutRS:
+		return append([]byte(nil), sig...), nil
+	case SigLayoutSR:
+		return append(append([]byte(nil), sig[32:]...), sig[:32]...), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownLayout, layout)
+	}
+}
@@ -0,0 +1,84 @@
+package signer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+func TestSerializeSignatureLayouts(t *testing.T) {
+	priv := testKey(t, 0x01)
+	msg := HashMessage([]byte("layout"))
+	sig, err := SignDeterministic(priv, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bip340 := sig.Serialize()
+	r, s := bip340[:32], bip340[32:]
+
+	tests := []struct {
+		layout SigLayout
+		want   []byte
+	}{
+		{SigLayoutRS, bip340},
+		{SigLayoutSR, append(append([]byte(nil), s...), r...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.layout), func(t *testing.T) {
+			got, err := SerializeSignature(sig, tt.layout)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("serialized %x, want %x", got, tt.want)
+			}
+
+			rs, err := ToRS(got, tt.layout)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(rs, bip340) {
+				t.Errorf("ToRS gave %x, want %x", rs, bip340)
+			}
+			parsed, err := schnorr.ParseSignature(rs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyMessage(priv.PubKey(), msg, parsed) {
+				t.Error("round-tripped signature does not verify")
+			}
+		})
+	}
+
+	// s-r bytes read as r-s are a different, invalid signature.
+	sr, err := SerializeSignature(sig, SigLayoutSR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed, err := schnorr.ParseSignature(sr); err == nil && VerifyMessage(priv.PubKey(), msg, parsed) {
+		t.Error("s-r signature verifies as r-s")
+	}
+}
+
+func TestSigLayoutRejects(t *testing.T) {
+	sig, err := SignDeterministic(testKey(t, 0x01), HashMessage([]byte("layout")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParseSigLayout("rs"); !errors.Is(err, ErrUnknownLayout) {
+		t.Errorf("ParseSigLayout: got %v, want ErrUnknownLayout", err)
+	}
+	if _, err := SerializeSignature(sig, "rs"); !errors.Is(err, ErrUnknownLayout) {
+		t.Errorf("SerializeSignature: got %v, want ErrUnknownLayout", err)
+	}
+	if _, err := ToRS(sig.Serialize(), "rs"); !errors.Is(err, ErrUnknownLayout) {
+		t.Errorf("ToRS: got %v, want ErrUnknownLayout", err)
+	}
+	if _, err := ToRS(sig.Serialize()[:63], SigLayoutRS); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("short signature: got %v, want ErrInvalidSignature", err)
+	}
+}
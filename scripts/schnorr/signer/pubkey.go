@@ -0,0 +1,53 @@
+package signer
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// PubKeyFormat selects a public key encoding.
+type PubKeyFormat string
+
+const (
+	// PubKeyXOnly is the 32-byte BIP340 x-only key the contract expects.
+	PubKeyXOnly PubKeyFormat = "xonly"
+	// PubKeyCompressed is the 33-byte SEC1 key with a parity prefix.
+	PubKeyCompressed PubKeyFormat = "compressed"
+	// PubKeyUncompressed is the 65-byte SEC1 key, 0x04 || X || Y.
+	PubKeyUncompressed PubKeyFormat = "uncompressed"
+)
+
+// XOnlyPubKey returns the X coordinate of pub as exactly 32 big-endian
+// bytes. Unlike X().Bytes(), leading zero bytes are kept, so keys with a
+// small X still fit the contract's fixed-width uint256.
+func XOnlyPubKey(pub *btcec.PublicKey) []byte {
+	var x [32]byte
+	pub.X().FillBytes(x[:])
+	return x[:]
+}
+
+// SerializePubKey encodes pub in the given format. Coordinates are
+// always left-padded to 32 bytes.
+func SerializePubKey(pub *btcec.PublicKey, format PubKeyFormat) ([]byte, error) {
+	switch format {
+	case PubKeyXOnly:
+		return XOnlyPubKey(pub), nil
+	case PubKeyCompressed:
+		return pub.SerializeCompressed(), nil
+	case PubKeyUncompressed:
+		return pub.SerializeUncompressed(), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+// ParsePubKeyFormat validates a format name such as a flag value.
+func ParsePubKeyFormat(name string) (PubKeyFormat, error) {
+	switch format := PubKeyFormat(name); format {
+	case PubKeyXOnly, PubKeyCompressed, PubKeyUncompressed:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownFormat, name)
+	}
+}
@@ -0,0 +1,86 @@
+package signer
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// Private key 0x99's public key has an X coordinate whose first byte
+// is zero, so an unpadded encoding is 31 bytes.
+const (
+	smallXKey = "0x0000000000000000000000000000000000000000000000000000000000000099"
+	smallX    = "00e3ae1974566ca06cc516d47e0fb165a674a3dabcfca15e722f0e3450f45889"
+)
+
+func TestXOnlyPubKeyKeepsLeadingZeros(t *testing.T) {
+	_, pub, err := LoadKeyFromHex(smallXKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if unpadded := len(pub.X().Bytes()); unpadded != 31 {
+		t.Fatalf("test key's X is %d bytes unpadded, want 31", unpadded)
+	}
+
+	x := XOnlyPubKey(pub)
+	if got := hex.EncodeToString(x); got != smallX {
+		t.Errorf("x-only key is %s, want %s", got, smallX)
+	}
+
+	// The padded key parses back to the same point.
+	parsed, err := ParsePublicKey(smallX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(XOnlyPubKey(parsed)); got != smallX {
+		t.Errorf("padded key parses back to X %s, want %s", got, smallX)
+	}
+}
+
+func TestSerializePubKeyFormats(t *testing.T) {
+	_, pub, err := LoadKeyFromHex(smallXKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		format PubKeyFormat
+		size   int
+		prefix string
+	}{
+		{PubKeyXOnly, 32, "00"},
+		{PubKeyCompressed, 33, ""},
+		{PubKeyUncompressed, 65, "04"},
+	}
+
+	for _, tt := range tests {
+		encoded, err := SerializePubKey(pub, tt.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(encoded) != tt.size {
+			t.Errorf("%s: %d bytes, want %d", tt.format, len(encoded), tt.size)
+		}
+		if tt.prefix != "" && hex.EncodeToString(encoded[:1]) != tt.prefix {
+			t.Errorf("%s: starts with %x, want %s", tt.format, encoded[:1], tt.prefix)
+		}
+	}
+
+	// X stays padded inside the SEC1 encodings too.
+	compressed, _ := SerializePubKey(pub, PubKeyCompressed)
+	if got := hex.EncodeToString(compressed[1:]); got != smallX {
+		t.Errorf("compressed X is %s, want %s", got, smallX)
+	}
+	uncompressed, _ := SerializePubKey(pub, PubKeyUncompressed)
+	if got := hex.EncodeToString(uncompressed[1:33]); got != smallX {
+		t.Errorf("uncompressed X is %s, want %s", got, smallX)
+	}
+
+	if _, err := SerializePubKey(pub, "hybrid"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("unknown format: got %v, want ErrUnknownFormat", err)
+	}
+	if _, err := ParsePubKeyFormat("hybrid"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("ParsePubKeyFormat: got %v, want ErrUnknownFormat", err)
+	}
+}
@@ -0,0 +1,106 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// ErrTransient marks a signing failure that may succeed when retried,
+// such as a timeout talking to a remote backend. Backends wrap it so a
+// RetryingSigner retries them by default.
+var ErrTransient = errors.New("transient signing failure")
+
+// RetryingSigner wraps a Signer and retries Sign on retryable errors
+// with exponential backoff and jitter. Any other error is returned
+// right away.
+type RetryingSigner struct {
+	signer    Signer
+	attempts  int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	retryable []error
+}
+
+// NewRetryingSigner returns a Signer that calls s up to attempts times.
+// The delay before retry n is drawn from [d/2, d) with d = baseDelay *
+// 2^(n-1), capped at maxDelay. An error is retried if it matches one of
+// retryable with errors.Is, or ErrTransient if none are given.
+func NewRetryingSigner(s Signer, attempts int, baseDelay, maxDelay time.Duration, retryable ...error) *RetryingSigner {
+	if len(retryable) == 0 {
+		retryable = []error{ErrTransient}
+	}
+
+	return &RetryingSigner{
+		signer:    s,
+		attempts:  max(attempts, 1),
+		baseDelay: baseDelay,
+		maxDelay:  maxDelay,
+		retryable: retryable,
+	}
+}
+
+// Sign signs hash with the wrapped Signer, retrying as configured.
+func (s *RetryingSigner) Sign(hash []byte) (*schnorr.Signature, error) {
+	var err error
+	for attempt := 1; ; attempt++ {
+		var sig *schnorr.Signature
+		sig, err = s.signer.Sign(hash)
+		if err == nil {
+			return sig, nil
+		}
+
+		if !s.isRetryable(err) {
+			return nil, err
+		}
+		if attempt == s.attempts {
+			break
+		}
+
+		time.Sleep(s.backoff(attempt))
+	}
+
+	return nil, fmt.Errorf("giving up after %d attempts: %w", s.attempts, err)
+}
+
+// PublicKey returns the wrapped Signer's public key.
+func (s *RetryingSigner) PublicKey() *btcec.PublicKey {
+	return s.signer.PublicKey()
+}
+
+// Zero zeroes the wrapped Signer if it holds a key in memory.
+func (s *RetryingSigner) Zero() {
+	if z, ok := s.signer.(interface{ Zero() }); ok {
+		z.Zero()
+	}
+}
+
+func (s *RetryingSigner) isRetryable(err error) bool {
+	for _, target := range s.retryable {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the jittered delay before the retry following attempt.
+func (s *RetryingSigner) backoff(attempt int) time.Duration {
+	delay := s.baseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if s.maxDelay > 0 && delay >= s.maxDelay {
+			delay = s.maxDelay
+			break
+		}
+	}
+
+	if delay <= 1 {
+		return delay
+	}
+	return delay/2 + rand.N(delay/2)
+}
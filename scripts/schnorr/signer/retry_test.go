@@ -0,0 +1,118 @@
+package signer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// flakySigner fails its first failures calls with err, then signs with
+// the wrapped KeySigner.
+type flakySigner struct {
+	*KeySigner
+	failures int
+	err      error
+	calls    int
+}
+
+func (s *flakySigner) Sign(hash []byte) (*schnorr.Signature, error) {
+	s.calls++
+	if s.calls <= s.failures {
+		return nil, s.err
+	}
+	return s.KeySigner.Sign(hash)
+}
+
+func TestRetryingSignerSucceedsOnThirdAttempt(t *testing.T) {
+	flaky := &flakySigner{KeySigner: NewKeySigner(testKey(t, 0x01)), failures: 2, err: ErrTransient}
+	s := NewRetryingSigner(flaky, 5, time.Millisecond, 10*time.Millisecond)
+
+	hash := bytes.Repeat([]byte{0x07}, 32)
+	sig, err := s.Sign(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flaky.calls != 3 {
+		t.Errorf("signer was called %d times, want 3", flaky.calls)
+	}
+	if !sig.Verify(hash, s.PublicKey()) {
+		t.Error("signature does not verify")
+	}
+}
+
+func TestRetryingSignerFailsFast(t *testing.T) {
+	badInput := errors.New("bad input")
+	flaky := &flakySigner{KeySigner: NewKeySigner(testKey(t, 0x01)), failures: 100, err: badInput}
+	// A long delay, so a retry would show up as a slow test.
+	s := NewRetryingSigner(flaky, 5, time.Hour, time.Hour)
+
+	if _, err := s.Sign(make([]byte, 32)); err != badInput {
+		t.Errorf("got %v, want the signer's error unwrapped", err)
+	}
+	if flaky.calls != 1 {
+		t.Errorf("signer was called %d times, want 1", flaky.calls)
+	}
+}
+
+func TestRetryingSignerGivesUp(t *testing.T) {
+	flaky := &flakySigner{KeySigner: NewKeySigner(testKey(t, 0x01)), failures: 100, err: ErrTransient}
+	s := NewRetryingSigner(flaky, 3, time.Millisecond, time.Millisecond)
+
+	_, err := s.Sign(make([]byte, 32))
+	if !errors.Is(err, ErrTransient) {
+		t.Errorf("got %v, want ErrTransient", err)
+	}
+	if flaky.calls != 3 {
+		t.Errorf("signer was called %d times, want 3", flaky.calls)
+	}
+}
+
+func TestRetryingSignerCustomRetryable(t *testing.T) {
+	busy := errors.New("device busy")
+	flaky := &flakySigner{KeySigner: NewKeySigner(testKey(t, 0x01)), failures: 1, err: busy}
+	s := NewRetryingSigner(flaky, 2, time.Millisecond, time.Millisecond, busy)
+
+	if _, err := s.Sign(make([]byte, 32)); err != nil {
+		t.Fatal(err)
+	}
+	if flaky.calls != 2 {
+		t.Errorf("signer was called %d times, want 2", flaky.calls)
+	}
+
+	// ErrTransient is only the default.
+	flaky = &flakySigner{KeySigner: NewKeySigner(testKey(t, 0x01)), failures: 1, err: ErrTransient}
+	s = NewRetryingSigner(flaky, 2, time.Millisecond, time.Millisecond, busy)
+	if _, err := s.Sign(make([]byte, 32)); !errors.Is(err, ErrTransient) || flaky.calls != 1 {
+		t.Errorf("got %v after %d calls, want ErrTransient after 1", err, flaky.calls)
+	}
+}
+
+func TestRetryingSignerBackoff(t *testing.T) {
+	s := NewRetryingSigner(nil, 10, 10*time.Millisecond, 50*time.Millisecond)
+
+	for attempt, ceiling := range map[int]time.Duration{
+		1: 10 * time.Millisecond,
+		2: 20 * time.Millisecond,
+		3: 40 * time.Millisecond,
+		4: 50 * time.Millisecond,
+		8: 50 * time.Millisecond,
+	} {
+		for i := 0; i < 100; i++ {
+			if delay := s.backoff(attempt); delay < ceiling/2 || delay >= ceiling {
+				t.Fatalf("attempt %d: delay %s outside [%s, %s)", attempt, delay, ceiling/2, ceiling)
+			}
+		}
+	}
+}
+
+func TestRetryingSignerZero(t *testing.T) {
+	priv := testKey(t, 0x01)
+	s := NewRetryingSigner(NewKeySigner(priv), 1, 0, 0)
+	s.Zero()
+	if !priv.Key.IsZero() {
+		t.Error("Zero did not reach the wrapped signer")
+	}
+}
@@ -0,0 +1,173 @@
+package signer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/wealdtech/go-merkletree/keccak256"
+	"golang.org/x/crypto/sha3"
+)
+
+// HashMessage returns the keccak256 digest of msg.
+func HashMessage(msg []byte) []byte {
+	return keccak256.New().Hash(msg)
+}
+
+// HashMessageWithDomain returns keccak256(keccak256(domain) || msg).
+// Hashing the tag first gives it a fixed width, so no domain and message
+// pair can collide with another; in Solidity this is
+// keccak256(abi.encodePacked(keccak256(bytes(domain)), msg)). Use a
+// distinct domain per message kind so a signature made for one cannot
+// be replayed as another.
+func HashMessageWithDomain(domain, msg []byte) []byte {
+	return HashWithDomain(NewMessageHasher(), domain, msg)
+}
+
+// EncodeWithNonce returns nonce || msg with the nonce as a 32-byte
+// big-endian uint256, the same bytes as
+// abi.encodePacked(uint256(nonce), msg). Hashing the result instead of
+// msg binds a signature to one nonce, so an increasing nonce keeps old
+// signatures over the same message from being replayed.
+func EncodeWithNonce(nonce *big.Int, msg []byte) ([]byte, error) {
+	if nonce.Sign() < 0 || nonce.BitLen() > 256 {
+		return nil, fmt.Errorf("%w: %s does not fit in a uint256", ErrInvalidNonce, nonce)
+	}
+
+	encoded := make([]byte, 32, 32+len(msg))
+	nonce.FillBytes(encoded)
+	return append(encoded, msg...), nil
+}
+
+// EncodeWithTimestamp returns EncodeFields(ts, msg) with ts the unix
+// time t in whole seconds as an 8-byte big-endian integer, the same
+// bytes as abi.encodePacked(uint256(8), uint64(ts), uint256(len(msg)),
+// msg). Hashing the result binds a signature to when it was made, and a
+// verifier needs the timestamp to re-derive the signed bytes.
+func EncodeWithTimestamp(t time.Time, msg []byte) ([]byte, error) {
+	if t.Unix() < 0 {
+		return nil, fmt.Errorf("%w: %s is before 1970", ErrInvalidTimestamp, t)
+	}
+
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(t.Unix()))
+	return EncodeFields(ts[:], msg), nil
+}
+
+// EncodeFields prefixes every field with its length as a 32-byte
+// big-endian uint256 and concatenates the results, the same bytes as
+// abi.encodePacked(uint256(len(a)), a, uint256(len(b)), b, ...). Unlike
+// plain concatenation, different splits of the same bytes encode
+// differently.
+func EncodeFields(fields ...[]byte) []byte {
+	size := 0
+	for _, field := range fields {
+		size += 32 + len(field)
+	}
+
+	encoded := make([]byte, 0, size)
+	for _, field := range fields {
+		var length [32]byte
+		new(big.Int).SetInt64(int64(len(field))).FillBytes(length[:])
+		encoded = append(encoded, length[:]...)
+		encoded = append(encoded, field...)
+	}
+
+	return encoded
+}
+
+// HashFields returns the keccak256 digest of EncodeFields(fields...).
+func HashFields(fields ...[]byte) []byte {
+	return HashMessage(EncodeFields(fields...))
+}
+
+// MessageHasher hashes messages like HashMessage but reuses a single
+// keccak256 state between calls. It is not safe for concurrent use.
+type MessageHasher struct {
+	state hash.Hash
+}
+
+// NewMessageHasher returns a reusable keccak256 message hasher.
+func NewMessageHasher() *MessageHasher {
+	return &MessageHasher{state: sha3.NewLegacyKeccak256()}
+}
+
+// Hash returns the keccak256 digest of msg.
+func (h *MessageHasher) Hash(msg []byte) []byte {
+	h.state.Reset()
+	h.state.Write(msg)
+	return h.state.Sum(nil)
+}
+
+// DecodeHex decodes a hex string with an optional 0x prefix.
+func DecodeHex(hexStr string) ([]byte, error) {
+	decoded, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(hexStr), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecodeMessage, err)
+	}
+
+	return decoded, nil
+}
+
+// DecodeHash decodes a hex encoded, already hashed 32-byte digest.
+func DecodeHash(hexStr string) ([]byte, error) {
+	hash, err := DecodeHex(hexStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("%w: hash must be 32 bytes, got %d", ErrDecodeMessage, len(hash))
+	}
+
+	return hash, nil
+}
+
+// SignMessage signs msg, which must already be a 32-byte digest. Fresh
+// auxiliary randomness from crypto/rand is mixed into the nonce as
+// recommended by BIP340, so signatures differ between calls.
+func SignMessage(priv *btcec.PrivateKey, msg []byte) (*schnorr.Signature, error) {
+	var aux [32]byte
+	if _, err := rand.Read(aux[:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSignFailed, err)
+	}
+
+	return sign(priv, msg, schnorr.CustomNonce(aux))
+}
+
+// SignMessageCtx is SignMessage for callers that thread a context
+// through every signing call. Local signing does not block, so ctx is
+// only checked before signing.
+func SignMessageCtx(ctx context.Context, priv *btcec.PrivateKey, msg []byte) (*schnorr.Signature, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return SignMessage(priv, msg)
+}
+
+// SignDeterministic signs msg with an RFC6979 nonce derived from the
+// private key and the message only, so the same inputs always give the
+// same signature. This is meant for fixtures and reproducible tests.
+// Without fresh randomness the nonce is more exposed to fault and side
+// channel attacks, so prefer SignMessage for production signing.
+func SignDeterministic(priv *btcec.PrivateKey, msg []byte) (*schnorr.Signature, error) {
+	return sign(priv, msg)
+}
+
+func sign(priv *btcec.PrivateKey, msg []byte, opts ...schnorr.SignOption) (*schnorr.Signature, error) {
+	signature, err := schnorr.Sign(priv, msg, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSignFailed, err)
+	}
+
+	return signature, nil
+}
@@ -0,0 +1,198 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// testKey returns the private key with every byte set to b.
+func testKey(t testing.TB, b byte) *btcec.PrivateKey {
+	t.Helper()
+
+	priv, err := PrivateKeyFromBytes(bytes.Repeat([]byte{b}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+// SignDeterministic derives its nonce from the key and message alone.
+// That is what makes fixtures possible, and also why it is only for
+// tests: without fresh randomness a fault or side channel during
+// signing has a fixed nonce to work on, and the same message is always
+// signed with the same nonce.
+func TestSignDeterministicIsReproducible(t *testing.T) {
+	priv := testKey(t, 0x01)
+	msg := HashMessage([]byte("reproducible"))
+
+	first, err := SignDeterministic(priv, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		again, err := SignDeterministic(priv, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(first.Serialize(), again.Serialize()) {
+			t.Fatalf("signature %d differs: %x != %x", i, again.Serialize(), first.Serialize())
+		}
+	}
+	if !VerifyMessage(priv.PubKey(), msg, first) {
+		t.Error("deterministic signature does not verify")
+	}
+
+	other, err := SignDeterministic(priv, HashMessage([]byte("something else")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first.Serialize()[:32], other.Serialize()[:32]) {
+		t.Error("two messages were signed with the same nonce")
+	}
+
+	otherKey, err := SignDeterministic(testKey(t, 0x02), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first.Serialize(), otherKey.Serialize()) {
+		t.Error("two keys gave the same signature")
+	}
+}
+
+func TestSignMessageIsRandomized(t *testing.T) {
+	priv := testKey(t, 0x01)
+	msg := HashMessage([]byte("randomized"))
+
+	a, err := SignMessage(priv, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := SignMessage(priv, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(a.Serialize(), b.Serialize()) {
+		t.Error("SignMessage gave the same signature twice")
+	}
+	if !VerifyMessage(priv.PubKey(), msg, a) || !VerifyMessage(priv.PubKey(), msg, b) {
+		t.Error("randomized signature does not verify")
+	}
+}
+
+func TestDeterministicSigner(t *testing.T) {
+	priv := testKey(t, 0x01)
+	msg := HashMessage([]byte("signer"))
+
+	want, err := SignDeterministic(priv, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewDeterministicSigner(priv).Sign(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Serialize(), want.Serialize()) {
+		t.Error("NewDeterministicSigner does not sign like SignDeterministic")
+	}
+}
+
+func TestEncodeWithNonce(t *testing.T) {
+	encoded, err := EncodeWithNonce(big.NewInt(0x0102), []byte("msg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "0000000000000000000000000000000000000000000000000000000000000102" + hex.EncodeToString([]byte("msg"))
+	if got := hex.EncodeToString(encoded); got != want {
+		t.Errorf("encoded %s, want %s", got, want)
+	}
+
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	if _, err := EncodeWithNonce(maxUint256, nil); err != nil {
+		t.Errorf("max uint256: %v", err)
+	}
+	for _, nonce := range []*big.Int{big.NewInt(-1), new(big.Int).Add(maxUint256, big.NewInt(1))} {
+		if _, err := EncodeWithNonce(nonce, nil); !errors.Is(err, ErrInvalidNonce) {
+			t.Errorf("nonce %s: got %v, want ErrInvalidNonce", nonce, err)
+		}
+	}
+}
+
+func TestNonceChangesSignature(t *testing.T) {
+	priv := testKey(t, 0x01)
+	msg := []byte("same message")
+
+	hashes := make([][]byte, 2)
+	for i, nonce := range []int64{1, 2} {
+		encoded, err := EncodeWithNonce(big.NewInt(nonce), msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes[i] = HashMessage(encoded)
+	}
+
+	// Deterministic signing, so the signatures can only differ because
+	// the nonce changed the signed bytes.
+	first, err := SignDeterministic(priv, hashes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := SignDeterministic(priv, hashes[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first.Serialize(), second.Serialize()) {
+		t.Error("two nonces gave the same signature")
+	}
+
+	// A signature for nonce 1 cannot be replayed at nonce 2.
+	if VerifyMessage(priv.PubKey(), hashes[1], first) {
+		t.Error("signature for nonce 1 verifies for nonce 2")
+	}
+}
+
+// Every split below concatenates to "abc", so only the length prefixes
+// tell them apart.
+func TestHashFieldsSplits(t *testing.T) {
+	splits := [][][]byte{
+		{[]byte("abc")},
+		{[]byte("a"), []byte("bc")},
+		{[]byte("ab"), []byte("c")},
+		{[]byte("a"), []byte("b"), []byte("c")},
+		{[]byte("abc"), {}},
+		{{}, []byte("abc")},
+	}
+
+	seen := make(map[string]int)
+	for i, fields := range splits {
+		hash := hex.EncodeToString(HashFields(fields...))
+		if j, ok := seen[hash]; ok {
+			t.Errorf("splits %d and %d hash the same", j, i)
+		}
+		seen[hash] = i
+	}
+
+	// Computed independently as keccak256(uint256(1) || "a" ||
+	// uint256(2) || "bc").
+	want := "770ba333d4c2394f45d61bcface950e3214cb5803c35ba2ec4bc944c53e06b38"
+	if got := hex.EncodeToString(HashFields([]byte("a"), []byte("bc"))); got != want {
+		t.Errorf("HashFields(a, bc) is %s, want %s", got, want)
+	}
+}
+
+func TestEncodeFields(t *testing.T) {
+	encoded := EncodeFields([]byte("a"), []byte("bc"))
+	want := strings.Repeat("00", 31) + "01" + "61" + strings.Repeat("00", 31) + "02" + "6263"
+	if got := hex.EncodeToString(encoded); got != want {
+		t.Errorf("encoded %s, want %s", got, want)
+	}
+	if len(EncodeFields()) != 0 {
+		t.Error("no fields encode to something")
+	}
+}
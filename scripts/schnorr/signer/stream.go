@@ -0,0 +1,59 @@
+package signer
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"golang.org/x/crypto/sha3"
+)
+
+// DigestReader returns the digest the Hasher from NewHasher(name, tag)
+// gives for everything read from r, under domain if it is not nil as
+// in HashWithDomain. r is hashed in chunks as it is read, so the
+// message is never held in memory.
+func DigestReader(name string, tag, domain []byte, r io.Reader) ([]byte, error) {
+	h, err := newStreamHash(name, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if domain != nil {
+		h.Write(domain)
+		domainHash := h.Sum(nil)
+
+		h, _ = newStreamHash(name, tag)
+		h.Write(domainHash)
+	}
+
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
+
+// newStreamHash returns the incremental form of the hash NewHasher
+// registers under name.
+func newStreamHash(name string, tag []byte) (hash.Hash, error) {
+	switch name {
+	case HashKeccak256:
+		return sha3.NewLegacyKeccak256(), nil
+	case HashSHA256:
+		return sha256.New(), nil
+	case HashBIP340:
+		if len(tag) == 0 {
+			return nil, errors.New("bip340 hashing needs a tag")
+		}
+		tagHash := chainhash.HashB(tag)
+		h := sha256.New()
+		h.Write(tagHash)
+		h.Write(tagHash)
+		return h, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownHash, name)
+	}
+}
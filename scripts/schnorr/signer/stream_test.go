@@ -0,0 +1,78 @@
+package signer
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDigestReaderMatchesHasher(t *testing.T) {
+	// Larger than io.Copy's buffer, so the large message is hashed in
+	// several chunks.
+	large := make([]byte, 1<<20+7)
+	rand.New(rand.NewSource(1)).Read(large)
+	messages := map[string][]byte{
+		"empty": {},
+		"short": []byte("abc"),
+		"large": large,
+	}
+
+	hashes := []struct {
+		name string
+		tag  string
+	}{
+		{HashKeccak256, ""},
+		{HashSHA256, ""},
+		{HashBIP340, TagChallenge},
+	}
+
+	for _, hash := range hashes {
+		h, err := NewHasher(hash.name, []byte(hash.tag))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, msg := range messages {
+			for _, domain := range [][]byte{nil, []byte("feeds")} {
+				want := h.Hash(msg)
+				if domain != nil {
+					want = HashWithDomain(h, domain, msg)
+				}
+
+				got, err := DigestReader(hash.name, []byte(hash.tag), domain, bytes.NewReader(msg))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("%s %s domain %q: streamed 0x%x, want 0x%x", hash.name, name, domain, got, want)
+				}
+
+				if name == "large" {
+					continue
+				}
+				got, err = DigestReader(hash.name, []byte(hash.tag), domain, iotest.OneByteReader(bytes.NewReader(msg)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("%s %s domain %q: one byte at a time 0x%x, want 0x%x", hash.name, name, domain, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestDigestReaderRejects(t *testing.T) {
+	if _, err := DigestReader("md5", nil, nil, bytes.NewReader(nil)); !errors.Is(err, ErrUnknownHash) {
+		t.Errorf("unknown hash: got %v, want ErrUnknownHash", err)
+	}
+	if _, err := DigestReader(HashBIP340, nil, nil, bytes.NewReader(nil)); err == nil {
+		t.Error("bip340 without a tag was accepted")
+	}
+
+	readErr := errors.New("disk on fire")
+	if _, err := DigestReader(HashKeccak256, nil, nil, iotest.ErrReader(readErr)); !errors.Is(err, readErr) {
+		t.Errorf("read error: got %v, want %v", err, readErr)
+	}
+}
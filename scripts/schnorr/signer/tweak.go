@@ -0,0 +1,74 @@
+package signer
+
+import (
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+var errTweakOverflow = errors.New("tweak hash is not below the curve order")
+
+// tweakScalar computes the BIP341 tweak t = hashTapTweak(P || tweak).
+func tweakScalar(pub *btcec.PublicKey, tweak []byte) (*btcec.ModNScalar, error) {
+	hash := TaggedHash(TagTapTweak, schnorr.SerializePubKey(pub), tweak)
+
+	t := new(btcec.ModNScalar)
+	if overflow := t.SetByteSlice(hash[:]); overflow {
+		return nil, errTweakOverflow
+	}
+
+	return t, nil
+}
+
+// TweakPubKey returns Q = P + t*G where P is the even-Y lift of pub and
+// t is the BIP341 tagged hash of P and tweak. Signatures made with the
+// matching TweakPrivKey verify against Q with plain schnorr.Verify.
+func TweakPubKey(pub *btcec.PublicKey, tweak []byte) (*btcec.PublicKey, error) {
+	// Round-trip through the x-only encoding to get the even-Y point.
+	even, err := schnorr.ParsePubKey(schnorr.SerializePubKey(pub))
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := tweakScalar(even, tweak)
+	if err != nil {
+		return nil, err
+	}
+
+	var p, tG, q btcec.JacobianPoint
+	even.AsJacobian(&p)
+	btcec.ScalarBaseMultNonConst(t, &tG)
+	btcec.AddNonConst(&p, &tG, &q)
+
+	if q == (btcec.JacobianPoint{}) {
+		return nil, errors.New("tweaked key is the point at infinity")
+	}
+
+	q.ToAffine()
+	return btcec.NewPublicKey(&q.X, &q.Y), nil
+}
+
+// TweakPrivKey returns the private key matching TweakPubKey, that is
+// d + t with d negated first if its public key has an odd Y.
+func TweakPrivKey(priv *btcec.PrivateKey, tweak []byte) (*btcec.PrivateKey, error) {
+	pub := priv.PubKey()
+
+	t, err := tweakScalar(pub, tweak)
+	if err != nil {
+		return nil, err
+	}
+
+	var d btcec.ModNScalar
+	d.Set(&priv.Key)
+	if pub.SerializeCompressed()[0] == 0x03 {
+		d.Negate()
+	}
+
+	d.Add(t)
+	if d.IsZero() {
+		return nil, errors.New("tweaked key is zero")
+	}
+
+	return btcec.PrivKeyFromScalar(&d), nil
+}
@@ -0,0 +1,103 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// Key path spends from the BIP341 wallet test vectors: the internal key,
+// the script tree's merkle root (none for the first) and the output key.
+func TestTweakPubKeyBIP341Vectors(t *testing.T) {
+	tests := []struct {
+		internal, merkleRoot, tweaked string
+	}{
+		{
+			"d6889cb081036e0faefa3a35157ad71086b123b2b144b649798b494c300a961d",
+			"",
+			"53a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343",
+		},
+		{
+			"187791b6f712a8ea41c8ecdd0ee77fab3e85263b37e1ec18a3651926b3a6cf27",
+			"5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21",
+			"147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3",
+		},
+	}
+
+	for _, tt := range tests {
+		internal, err := ParsePublicKey(tt.internal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root, err := hex.DecodeString(tt.merkleRoot)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tweaked, err := TweakPubKey(internal, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(schnorr.SerializePubKey(tweaked)); got != tt.tweaked {
+			t.Errorf("internal key %s: tweaked key %s, want %s", tt.internal, got, tt.tweaked)
+		}
+	}
+}
+
+func TestTweakRoundTrip(t *testing.T) {
+	tweak := HashMessage([]byte("merkle root"))
+	msg := HashMessage([]byte("signed under the tweaked key"))
+
+	for i := 0; i < 16; i++ {
+		priv, err := btcec.NewPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tweakedPriv, err := TweakPrivKey(priv, tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tweakedPub, err := TweakPubKey(priv.PubKey(), tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Compare as x-only keys, which is what BIP340 verifies against.
+		derived := schnorr.SerializePubKey(tweakedPriv.PubKey())
+		if !bytes.Equal(derived, schnorr.SerializePubKey(tweakedPub)) {
+			t.Fatalf("key %d: tweaked private key derives %x, tweaked public key is %x",
+				i, derived, schnorr.SerializePubKey(tweakedPub))
+		}
+
+		sig, err := SignMessage(tweakedPriv, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sig.Verify(msg, tweakedPub) {
+			t.Errorf("key %d: signature by the tweaked key does not verify", i)
+		}
+		if sig.Verify(msg, priv.PubKey()) {
+			t.Errorf("key %d: signature by the tweaked key verifies under the untweaked key", i)
+		}
+	}
+}
+
+func TestTweakDependsOnCommitment(t *testing.T) {
+	priv := testKey(t, 0x01)
+
+	a, err := TweakPubKey(priv.PubKey(), []byte("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := TweakPubKey(priv.PubKey(), []byte("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.IsEqual(b) {
+		t.Error("two commitments gave the same tweaked key")
+	}
+}
@@ -0,0 +1,113 @@
+package signer
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// VerifyMessage reports whether sig is a valid signature of the
+// 32-byte digest msg by pub.
+func VerifyMessage(pub *btcec.PublicKey, msg []byte, sig *schnorr.Signature) bool {
+	return sig.Verify(msg, pub)
+}
+
+// MaxKeySet bounds the key set FindSigner tries, since every key costs a
+// full signature verification.
+const MaxKeySet = 4096
+
+// FindSigner returns the index of the key in pubs that produced sig over
+// msg. Schnorr signatures do not commit to a recoverable key, so every
+// key is tried in turn.
+func FindSigner(pubs []*btcec.PublicKey, msg []byte, sig *schnorr.Signature) (int, error) {
+	if len(pubs) == 0 {
+		return -1, ErrNoKeys
+	}
+	if len(pubs) > MaxKeySet {
+		return -1, fmt.Errorf("%w: got %d, at most %d are allowed", ErrTooManyKeys, len(pubs), MaxKeySet)
+	}
+
+	for i, pub := range pubs {
+		if sig.Verify(msg, pub) {
+			return i, nil
+		}
+	}
+
+	return -1, ErrNoMatch
+}
+
+// ParsePublicKey decodes a hex encoded x-only public key.
+func ParsePublicKey(hexStr string) (*btcec.PublicKey, error) {
+	keyBytes, err := hex.DecodeString(strings.TrimPrefix(hexStr, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+
+	if len(keyBytes) != schnorr.PubKeyBytesLen {
+		return nil, fmt.Errorf("%w: public key must be %d bytes, got %d", ErrInvalidKey, schnorr.PubKeyBytesLen, len(keyBytes))
+	}
+
+	publicKey, err := schnorr.ParsePubKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+
+	return publicKey, nil
+}
+
+// ParseSignature decodes a hex encoded 64-byte schnorr signature.
+func ParseSignature(hexStr string) (*schnorr.Signature, error) {
+	sigBytes, err := hex.DecodeString(strings.TrimPrefix(hexStr, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	if len(sigBytes) != schnorr.SignatureSize {
+		return nil, fmt.Errorf("%w: signature must be %d bytes, got %d", ErrInvalidSignature, schnorr.SignatureSize, len(sigBytes))
+	}
+
+	signature, err := schnorr.ParseSignature(sigBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	return signature, nil
+}
+
+// IsCanonical reports whether the 64-byte signature sig is in the form
+// the on-chain verifier accepts: R's X coordinate below the field prime
+// and on the curve, and s below the curve order. schnorr.ParseSignature
+// silently reduces an s of n or more, so a signature that verifies here
+// can still be rejected on chain unless it is canonical.
+//
+// BIP340 has no low-s rule like ECDSA: negating s does not give another
+// valid signature, so s anywhere below n is canonical.
+func IsCanonical(sig []byte) bool {
+	if len(sig) != schnorr.SignatureSize {
+		return false
+	}
+
+	if _, err := schnorr.ParsePubKey(sig[:32]); err != nil {
+		return false
+	}
+
+	var s btcec.ModNScalar
+	overflow := s.SetByteSlice(sig[32:])
+	return !overflow
+}
+
+// SplitSignature returns the R and s halves of sig, checking that it
+// serializes to exactly 64 bytes first.
+func SplitSignature(sig *schnorr.Signature) (r, s [32]byte, err error) {
+	serialized := sig.Serialize()
+	if len(serialized) != schnorr.SignatureSize {
+		return r, s, fmt.Errorf("%w: signature serialized to %d bytes, want %d", ErrInvalidSignature, len(serialized), schnorr.SignatureSize)
+	}
+
+	copy(r[:], serialized[:32])
+	copy(s[:], serialized[32:])
+	return r, s, nil
+}
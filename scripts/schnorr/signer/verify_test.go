@@ -0,0 +1,62 @@
+package signer
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// The secp256k1 field prime p.
+const fieldPrime = "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f"
+
+func TestIsCanonical(t *testing.T) {
+	priv := testKey(t, 0x01)
+	sig, err := SignDeterministic(priv, HashMessage([]byte("canonical")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := hex.EncodeToString(sig.Serialize())
+	r, s := valid[:64], valid[64:]
+
+	tests := []struct {
+		name      string
+		sig       string
+		canonical bool
+	}{
+		{"signed", valid, true},
+		{"s is zero", r + strings.Repeat("00", 32), true},
+		{"s is n-1", r + "fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140", true},
+		// schnorr.ParseSignature would reduce these mod n.
+		{"s is n", r + curveOrder, false},
+		{"s is all ones", r + strings.Repeat("ff", 32), false},
+		{"r is p", fieldPrime + s, false},
+		// No secp256k1 point has X = 0, since 7 is not a square mod p.
+		{"r not on the curve", strings.Repeat("00", 32) + s, false},
+		{"short", valid[:126], false},
+		{"long", valid + "00", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := hex.DecodeString(tt.sig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := IsCanonical(raw); got != tt.canonical {
+				t.Errorf("IsCanonical = %v, want %v", got, tt.canonical)
+			}
+		})
+	}
+}
+
+// ParseSignature reduces an s of n or more instead of rejecting it,
+// which is why IsCanonical has to look at the raw bytes.
+func TestParseSignatureReducesS(t *testing.T) {
+	sig, err := ParseSignature(strings.Repeat("11", 32) + curveOrder)
+	if err != nil {
+		t.Fatalf("ParseSignature rejected s = n: %v", err)
+	}
+	if sig.Serialize()[63] != 0 {
+		t.Errorf("s = n parsed as %x, want it reduced to zero", sig.Serialize()[32:])
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/TimeleapLabs/go-schnorr/keystore"
+	"github.com/TimeleapLabs/go-schnorr/shamir"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+// splitKey prints k-of-n Shamir shares of the signing key for offline
+// backup. Each share carries its index, the key id and a checksum, see
+// the shamir package.
+func splitKey(args []string) {
+	flags := flag.NewFlagSet("split-key", flag.ExitOnError)
+	key := addKeyFlags(flags)
+	threshold := flags.Int("threshold", 0, "number of shares needed to reconstruct the key")
+	shares := flags.Int("shares", 0, "number of shares to produce")
+	parseFlags(flags, args)
+
+	if *threshold < 1 || *shares < *threshold || *shares > shamir.MaxShares {
+		fatal(usageError("-threshold and -shares must satisfy 1 <= threshold <= shares <= %d", shamir.MaxShares))
+	}
+
+	privateKey, publicKey := key.load()
+	defer privateKey.Zero()
+
+	split, err := shamir.Split(privateKey, *threshold, *shares)
+	if err != nil {
+		fatal(keyError("Error splitting key: %w", err))
+	}
+
+	fmt.Printf("Public key: 0x%x\n", signer.XOnlyPubKey(publicKey))
+	for _, share := range split {
+		fmt.Printf("Share %d: %s\n", share.Index, share)
+	}
+}
+
+// combineKey reconstructs a key from shares read one per line from -in
+// or stdin, so they stay out of the shell history. Blank lines and lines
+// starting with # are skipped, and "Share N: " prefixes as printed by
+// split-key are accepted.
+func combineKey(args []string) {
+	flags := flag.NewFlagSet("combine-key", flag.ExitOnError)
+	in := flags.String("in", "", "file with one share per line, stdin if empty")
+	keystorePath := flags.String("keystore", "", "write the key to an encrypted keystore instead of printing it")
+	parseFlags(flags, args)
+
+	var r io.Reader = os.Stdin
+	if *in != "" {
+		file, err := os.Open(*in)
+		if err != nil {
+			fatal(inputError("Error opening shares: %w", err))
+		}
+		defer file.Close()
+		r = file
+	}
+
+	shares, err := readShares(r)
+	if err != nil {
+		fatal(inputError("Error reading shares: %w", err))
+	}
+
+	privateKey, err := shamir.Combine(shares)
+	if err != nil {
+		fatal(keyError("Error combining shares: %w", err))
+	}
+	defer privateKey.Zero()
+
+	publicKeyHex := fmt.Sprintf("0x%x", signer.XOnlyPubKey(privateKey.PubKey()))
+
+	if *keystorePath != "" {
+		passphrase, err := readNewPassphrase()
+		if err != nil {
+			fatal(keyError("Error reading passphrase: %w", err))
+		}
+
+		err = keystore.SaveKeystore(*keystorePath, privateKey, passphrase)
+		clear(passphrase)
+		if err != nil {
+			fatal(fmt.Errorf("Error writing keystore: %w", err))
+		}
+
+		fmt.Printf("Public key: %s\n", publicKeyHex)
+		infof("Keystore written to %s", *keystorePath)
+		return
+	}
+
+	fmt.Printf("Private key: %x\n", privateKey.Serialize())
+	fmt.Printf("Public key: %s\n", publicKeyHex)
+}
+
+func readShares(r io.Reader) ([]*shamir.Share, error) {
+	var shares []*shamir.Share
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, share, ok := strings.Cut(line, ": "); ok {
+			line = share
+		}
+
+		share, err := shamir.ParseShare(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		shares = append(shares, share)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(shares) == 0 {
+		return nil, errors.New("no shares given")
+	}
+	return shares, nil
+}
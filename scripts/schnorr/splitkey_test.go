@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitAndCombineKey(t *testing.T) {
+	res := runCLI(t, "split-key", "-threshold", "2", "-shares", "3")
+	if res.code != 0 {
+		t.Fatalf("split-key exit %d: %s", res.code, res.stderr)
+	}
+
+	var shares []string
+	for _, line := range strings.Split(strings.TrimSpace(res.stdout), "\n") {
+		if strings.HasPrefix(line, "Share ") {
+			shares = append(shares, line)
+		}
+	}
+	if len(shares) != 3 {
+		t.Fatalf("split-key printed %d shares, want 3:\n%s", len(shares), res.stdout)
+	}
+
+	// The last and first share, with split-key's prefixes and a comment.
+	stdin := "# backup\n" + shares[2] + "\n\n" + shares[0] + "\n"
+	res = cliRun{stdin: stdin}.run(t, "combine-key")
+	if res.code != 0 {
+		t.Fatalf("combine-key exit %d: %s", res.code, res.stderr)
+	}
+	if want := "Private key: " + strings.TrimPrefix(testKeyHex, "0x") + "\n"; !strings.Contains(res.stdout, want) {
+		t.Errorf("combine-key printed\n%s\nwant %q", res.stdout, want)
+	}
+	if !strings.Contains(res.stdout, "Public key: "+testPubKey) {
+		t.Errorf("combine-key printed the wrong public key:\n%s", res.stdout)
+	}
+
+	res = cliRun{stdin: shares[0] + "\n"}.run(t, "combine-key")
+	if res.code != exitKey {
+		t.Errorf("one share: exit %d, want %d", res.code, exitKey)
+	}
+
+	// A transcription error in the last hex digit of the checksum.
+	corrupted := shares[1]
+	last := corrupted[len(corrupted)-1]
+	swap := byte('0')
+	if last == '0' {
+		swap = '1'
+	}
+	corrupted = corrupted[:len(corrupted)-1] + string(swap)
+	res = cliRun{stdin: shares[0] + "\n" + corrupted + "\n"}.run(t, "combine-key")
+	if res.code != exitInput || !strings.Contains(res.stderr, "checksum") {
+		t.Errorf("corrupted share: exit %d, want %d with a checksum error: %s", res.code, exitInput, res.stderr)
+	}
+}
+
+func TestSplitKeyRejectsThreshold(t *testing.T) {
+	for _, args := range [][]string{
+		{"-threshold", "0", "-shares", "3"},
+		{"-threshold", "4", "-shares", "3"},
+		{"-threshold", "2", "-shares", "256"},
+	} {
+		if res := runCLI(t, append([]string{"split-key"}, args...)...); res.code != exitUsage {
+			t.Errorf("%v: exit %d, want %d", args, res.code, exitUsage)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// signStats collects hash+sign timings for -stats. A nil *signStats
+// records nothing and never reads the clock, so the path without -stats
+// pays only a nil check.
+type signStats struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	busy      []interval
+}
+
+type interval struct {
+	start, end time.Time
+}
+
+// now returns the start time of a timed section, or the zero time when
+// stats are off.
+func (st *signStats) now() time.Time {
+	if st == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// record adds the time since start to signature i. A signature timed in
+// more than one section, such as hashing and then signing a batch line,
+// gets their sum. A negative i starts a new signature.
+func (st *signStats) record(i int, start time.Time) {
+	if st == nil {
+		return
+	}
+	end := time.Now()
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if i < 0 {
+		i = len(st.latencies)
+	}
+	if i >= len(st.latencies) {
+		st.latencies = append(st.latencies, make([]time.Duration, i+1-len(st.latencies))...)
+	}
+	st.latencies[i] += end.Sub(start)
+	st.busy = append(st.busy, interval{start, end})
+}
+
+// report writes the signature count, the mean, median and p99 latency,
+// and the throughput over the wall-clock time during which at least one
+// section was running, which leaves out idle time such as waiting for
+// a passphrase or for requests.
+func (st *signStats) report(w io.Writer) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	fmt.Fprintf(w, "Signatures: %d\n", len(st.latencies))
+	if len(st.latencies) == 0 {
+		return
+	}
+
+	sorted := slices.Clone(st.latencies)
+	slices.Sort(sorted)
+
+	var total time.Duration
+	for _, latency := range sorted {
+		total += latency
+	}
+
+	fmt.Fprintf(w, "Mean latency: %s\n", total/time.Duration(len(sorted)))
+	fmt.Fprintf(w, "Median latency: %s\n", percentile(sorted, 50))
+	fmt.Fprintf(w, "P99 latency: %s\n", percentile(sorted, 99))
+
+	if busy := busyTime(st.busy); busy > 0 {
+		fmt.Fprintf(w, "Throughput: %.1f signatures/s\n", float64(len(sorted))/busy.Seconds())
+	}
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// busyTime returns the length of the union of intervals.
+func busyTime(intervals []interval) time.Duration {
+	intervals = slices.Clone(intervals)
+	slices.SortFunc(intervals, func(a, b interval) int { return a.start.Compare(b.start) })
+
+	var (
+		busy    time.Duration
+		current interval
+	)
+	for i, next := range intervals {
+		switch {
+		case i == 0:
+			current = next
+		case next.start.After(current.end):
+			busy += current.end.Sub(current.start)
+			current = next
+		case next.end.After(current.end):
+			current.end = next.end
+		}
+	}
+	if len(intervals) > 0 {
+		busy += current.end.Sub(current.start)
+	}
+
+	return busy
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseStats reads a -stats report into its values by label. Lines that
+// are not part of the report, such as log output, are skipped.
+func parseStats(t *testing.T, report string) map[string]string {
+	t.Helper()
+
+	values := make(map[string]string)
+	for _, line := range strings.Split(report, "\n") {
+		label, value, ok := strings.Cut(line, ": ")
+		switch label {
+		case "Signatures", "Mean latency", "Median latency", "P99 latency", "Throughput":
+			if ok {
+				values[label] = value
+			}
+		}
+	}
+	return values
+}
+
+// checkStats checks that the report has every value for n signatures
+// and that each is a positive number.
+func checkStats(t *testing.T, report string, n int) {
+	t.Helper()
+
+	values := parseStats(t, report)
+	if got := values["Signatures"]; got != strconv.Itoa(n) {
+		t.Errorf("Signatures is %q, want %d\n%s", got, n, report)
+	}
+	for _, label := range []string{"Mean latency", "Median latency", "P99 latency"} {
+		d, err := time.ParseDuration(values[label])
+		if err != nil || d <= 0 {
+			t.Errorf("%s is %q, want a positive duration", label, values[label])
+		}
+	}
+	throughput, ok := strings.CutSuffix(values["Throughput"], " signatures/s")
+	if rate, err := strconv.ParseFloat(throughput, 64); !ok || err != nil || rate <= 0 {
+		t.Errorf("Throughput is %q, want a positive rate", values["Throughput"])
+	}
+}
+
+func TestStatsReport(t *testing.T) {
+	base := time.Unix(0, 0)
+	st := &signStats{}
+	for i := 1; i <= 100; i++ {
+		st.latencies = append(st.latencies, time.Duration(i)*time.Millisecond)
+	}
+	// Two overlapping sections and a separate one: 3s busy in total.
+	st.busy = []interval{
+		{base, base.Add(time.Second)},
+		{base.Add(500 * time.Millisecond), base.Add(2 * time.Second)},
+		{base.Add(5 * time.Second), base.Add(6 * time.Second)},
+	}
+
+	var out strings.Builder
+	st.report(&out)
+
+	want := "Signatures: 100\n" +
+		"Mean latency: 50.5ms\n" +
+		"Median latency: 50ms\n" +
+		"P99 latency: 99ms\n" +
+		"Throughput: 33.3 signatures/s\n"
+	if out.String() != want {
+		t.Errorf("report is\n%s\nwant\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	(&signStats{}).report(&out)
+	if out.String() != "Signatures: 0\n" {
+		t.Errorf("empty report is %q", out.String())
+	}
+}
+
+func TestStatsRecordSumsSections(t *testing.T) {
+	st := &signStats{}
+	start := time.Now().Add(-time.Second)
+	st.record(1, start)
+	st.record(1, start)
+	st.record(-1, start)
+
+	if len(st.latencies) != 3 || st.latencies[0] != 0 {
+		t.Fatalf("latencies are %v, want 3 with the first unset", st.latencies)
+	}
+	if st.latencies[1] < 2*time.Second || st.latencies[2] < time.Second {
+		t.Errorf("latencies are %v, want the two sections of 1 summed", st.latencies)
+	}
+}
+
+func TestNilStatsRecordsNothing(t *testing.T) {
+	var st *signStats
+	if start := st.now(); !start.IsZero() {
+		t.Error("nil stats read the clock")
+	}
+	st.record(0, time.Time{})
+}
+
+func TestSignBatchStats(t *testing.T) {
+	path := writeBatchFile(t, 20)
+
+	res := runCLI(t, "sign", "-batch-file", path, "-output", "json", "-workers", "2", "-stats")
+	if res.code != 0 {
+		t.Fatalf("exit %d: %s", res.code, res.stderr)
+	}
+	checkStats(t, res.stderr, 20)
+	if strings.Contains(res.stdout, "latency") {
+		t.Errorf("stats on stdout:\n%s", res.stdout)
+	}
+
+	res = runCLI(t, "sign", "-batch-file", path)
+	if res.code != 0 {
+		t.Fatalf("exit %d: %s", res.code, res.stderr)
+	}
+	if values := parseStats(t, res.stderr); len(values) != 0 {
+		t.Errorf("stats without -stats:\n%s", res.stderr)
+	}
+
+	if res := runCLI(t, "sign", "-message", "hello", "-stats"); res.code != exitUsage {
+		t.Errorf("-stats without -batch-file: exit %d, want %d", res.code, exitUsage)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/TimeleapLabs/go-schnorr/eip712"
+)
+
+// validate checks a typed data payload the way sign -eip712 would read
+// it, without signing, and exits non-zero if anything is wrong.
+func validate(args []string) {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	typedDataFile := flags.String("eip712", "", "JSON typed data file to check")
+	parseFlags(flags, args)
+
+	if *typedDataFile == "" {
+		fatal(usageError("-eip712 is required"))
+	}
+
+	data, err := os.ReadFile(*typedDataFile)
+	if err != nil {
+		fatal(inputError("Error reading typed data: %w", err))
+	}
+
+	typed, err := eip712.ParseTypedData(data)
+	if err != nil {
+		fatal(inputError("Error parsing typed data: %w", err))
+	}
+
+	problems := typed.Validate()
+	for _, problem := range problems {
+		fmt.Printf("Problem: %v\n", problem)
+	}
+
+	if len(problems) > 0 {
+		fatal(inputError("Payload has %d problem(s)", len(problems)))
+	}
+
+	infof("Payload is valid")
+}
@@ -0,0 +1,76 @@
+package vectors
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// readFixtures reads fixtures.json from disk rather than the embedded
+// copy, so the test checks what is committed.
+func readFixtures(t *testing.T) ([]byte, []Fixture) {
+	t.Helper()
+
+	data, err := os.ReadFile("fixtures.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fixtures []Fixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatal(err)
+	}
+	return data, fixtures
+}
+
+func TestCommittedFixturesVerify(t *testing.T) {
+	_, fixtures := readFixtures(t)
+	if len(fixtures) != len(Vectors) {
+		t.Fatalf("%d committed fixtures for %d vectors", len(fixtures), len(Vectors))
+	}
+
+	for _, f := range fixtures {
+		t.Run(f.Name, func(t *testing.T) {
+			if err := f.Verify(); err != nil {
+				t.Error(err)
+			}
+
+			// A fixture whose signature no longer matches must fail.
+			tampered := f
+			tampered.Signature = f.Signature[:len(f.Signature)-1] + flipHexDigit(f.Signature[len(f.Signature)-1])
+			if tampered.Verify() == nil {
+				t.Error("fixture with a tampered signature verifies")
+			}
+		})
+	}
+}
+
+func TestCommittedFixturesAreCurrent(t *testing.T) {
+	committed, _ := readFixtures(t)
+
+	fixtures, err := Fixtures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := MarshalFixtures(fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(generated, committed) {
+		t.Error("fixtures.json differs from the generated fixtures, regenerate it with go run ./genfixtures")
+	}
+	if !bytes.Equal(committed, fixturesJSON) {
+		t.Error("embedded fixtures differ from fixtures.json")
+	}
+	if err := CheckFixtures(); err != nil {
+		t.Error(err)
+	}
+}
+
+func flipHexDigit(c byte) string {
+	if c == '0' {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,92 @@
+package vectors
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// TaggedHashVector is one signer.TaggedHash input and its expected
+// digest.
+type TaggedHashVector struct {
+	Tag     string
+	Message string
+	Digest  string
+}
+
+// TaggedHashVectors cover the BIP340 and BIP341 reference tags, each
+// over an empty and a short message.
+var TaggedHashVectors = []TaggedHashVector{
+	{signer.TagAux, "", "0x07fab5f97e680abb8389d1fa164281e124439468f5bd699fcbd1ae86e6405d69"},
+	{signer.TagAux, "unchained", "0xd05e05c2ddfc4916b310737698ad3d406cf71cbdaf69baed73b6b09c6640ea2a"},
+	{signer.TagNonce, "", "0x5301f1001a8be6253a3583927793565cef360de8bac2bdcbf37b195e699435a8"},
+	{signer.TagNonce, "unchained", "0x26f98ddb71ecaa799e4393233aa3c02f0773d435f7bebd787d791bf4308f1f9b"},
+	{signer.TagChallenge, "", "0xc216d352f5818b7b4beacd4ae0a26fe888080823d2a598856661bcd54f1b3713"},
+	{signer.TagChallenge, "unchained", "0x6bd0bde974e8d8f411bc70c8883745f53492074f11b224f8ef8bb16b1beaf423"},
+	{signer.TagTapTweak, "", "0x8aa4229474ab0100b2d6f0687f031d1fc9d8eef92a042ad97d279bff456b15e4"},
+	{signer.TagTapTweak, "unchained", "0xbdf8a5be8f115cf63eff688f3e4301cfbbd1f4fd40984523ea0d03726fb7ac66"},
+}
+
+// CheckTaggedHashes recomputes the tagged hash vectors, then checks
+// that the challenge signer.TaggedHash derives for every signing vector
+// is the one its signature was made with.
+func CheckTaggedHashes() error {
+	for _, v := range TaggedHashVectors {
+		hash := signer.TaggedHash(v.Tag, []byte(v.Message))
+		if got := fmt.Sprintf("0x%x", hash); got != v.Digest {
+			return fmt.Errorf("tagged hash %s(%q) is %s, want %s", v.Tag, v.Message, got, v.Digest)
+		}
+	}
+
+	for _, v := range Vectors {
+		if err := checkChallenge(v); err != nil {
+			return fmt.Errorf("%s: %w", v.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// checkChallenge recovers R = s*G - e*P from the vector's signature with
+// e = TaggedHash(TagChallenge, r || P || m), and checks that R has
+// x coordinate r and an even y, as BIP340 verification does.
+func checkChallenge(v Vector) error {
+	publicKey, err := signer.DecodeHex(v.PublicKey)
+	if err != nil {
+		return err
+	}
+	digest, err := signer.DecodeHash(v.Digest)
+	if err != nil {
+		return err
+	}
+	sig, err := signer.DecodeHex(v.Signature)
+	if err != nil {
+		return err
+	}
+
+	pub, err := schnorr.ParsePubKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	var e, s btcec.ModNScalar
+	challenge := signer.TaggedHash(signer.TagChallenge, sig[:32], publicKey, digest)
+	e.SetBytes(&challenge)
+	s.SetByteSlice(sig[32:])
+
+	var p, sG, eP, r btcec.JacobianPoint
+	pub.AsJacobian(&p)
+	btcec.ScalarBaseMultNonConst(&s, &sG)
+	btcec.ScalarMultNonConst(e.Negate(), &p, &eP)
+	btcec.AddNonConst(&sG, &eP, &r)
+	r.ToAffine()
+
+	x := r.X.Bytes()
+	if r.Y.IsOdd() || !bytes.Equal(x[:], sig[:32]) {
+		return fmt.Errorf("signature was not made with the %s tagged hash", signer.TagChallenge)
+	}
+	return nil
+}
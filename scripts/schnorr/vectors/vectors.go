@@ -0,0 +1,471 @@
+// Package vectors pins the exact bytes the signer produces for a few
+// fixed inputs, so that a change to hashing, nonce derivation or
+// serialization that would break compatibility with the on-chain
+// verifier is caught. Signatures use deterministic nonces.
+package vectors
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing/iotest"
+
+	"github.com/TimeleapLabs/go-schnorr/aggsig"
+	"github.com/TimeleapLabs/go-schnorr/eip712"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// Vector is one fixed signing input and its expected outputs. Digest is
+// the Hash (keccak256 when empty) of Message, or of the length-prefixed
+// Fields when set, prefixed with Nonce and tagged with Domain when those
+// are set or, for typed data vectors, the EIP-712 digest built by the
+// vector's typed func.
+type Vector struct {
+	Name       string
+	PrivateKey string
+	Hash       string
+	HashTag    string
+	Domain     string
+	Nonce      int64
+	HasNonce   bool
+	Message    string
+	Fields     []string
+	PublicKey  string
+	Digest     string
+	Signature  string
+
+	typed func() ([]byte, error)
+}
+
+// fixturesJSON is the committed output of genfixtures.
+//
+//go:embed fixtures.json
+var fixturesJSON []byte
+
+var Vectors = []Vector{
+	{
+		Name:       "keccak256 hello world, key 1",
+		PrivateKey: "0x0000000000000000000000000000000000000000000000000000000000000001",
+		Message:    "Hello, world!",
+		PublicKey:  "0x79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+		Digest:     "0xb6e16d27ac5ab427a7f68900ac5559ce272dc6c37c82b3e052246c82244c50e4",
+		Signature:  "0xc70f93a7d43c96a685431009a4883ac0e33d1411f5e95241cea1409e894469a08f5aa7846d1388cc6fcc93e537e30dffd62481cc39b9ba77dd49017bc9026b9f",
+	},
+	{
+		Name:       "keccak256 hello world, odd Y key",
+		PrivateKey: "0x0101010101010101010101010101010101010101010101010101010101010101",
+		Message:    "Hello, world!",
+		PublicKey:  "0x1b84c5567b126440995d3ed5aaba0565d71e1834604819ff9c17f5e9d5dd078f",
+		Digest:     "0xb6e16d27ac5ab427a7f68900ac5559ce272dc6c37c82b3e052246c82244c50e4",
+		Signature:  "0x2bdef73249eb5dee4f3a211ce0453671680ae47878fcfaa433c8a24e3e90233fa780dd382e130d7c60aab17fae07cc9e73da7a34084ebf42a61c7ad859c1e2d4",
+	},
+	{
+		Name:       "keccak256 empty message",
+		PrivateKey: "0xb7e151628aed2a6abf7158809cf4f3c762e7160f38b4da56a784d9045190cfef",
+		Message:    "",
+		PublicKey:  "0xdff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+		Digest:     "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470",
+		Signature:  "0x5fc0492201570e5d1c009ae4222f7ed4117eef460d2b6c9f0f25b34c5ebedf7cec90140f771d37acec9210a33892cf9441527d9fe845063966e86fb2630628ea",
+	},
+	{
+		Name:       "keccak256 hello world, key with a leading zero X byte",
+		PrivateKey: "0x0000000000000000000000000000000000000000000000000000000000000099",
+		Message:    "Hello, world!",
+		PublicKey:  "0x00e3ae1974566ca06cc516d47e0fb165a674a3dabcfca15e722f0e3450f45889",
+		Digest:     "0xb6e16d27ac5ab427a7f68900ac5559ce272dc6c37c82b3e052246c82244c50e4",
+		Signature:  "0x29c9ae0df6cb6dca84f3992560c2e8900b7b6461dcc340902befe635181f3ef84f679970994a0af7d76cc729995bb8ba43d3a11729852521022eb8b2a8fe8124",
+	},
+	{
+		Name:       "hello world under domain oracle",
+		PrivateKey: "0x0101010101010101010101010101010101010101010101010101010101010101",
+		Domain:     "oracle",
+		Message:    "Hello, world!",
+		PublicKey:  "0x1b84c5567b126440995d3ed5aaba0565d71e1834604819ff9c17f5e9d5dd078f",
+		Digest:     "0x402f65f3a42c13c4bbb9481da1f717d599844e7544de08407b4a3790e980850e",
+		Signature:  "0x2a37e3e373c2dbd9fb84bf7c35453d0205d930dd21a892ab8f42415c0fb8a19cdeed6faf82c1b519843e2722493194952f6130826a354cff97cf2b1c9e158c3d",
+	},
+	{
+		Name:       "hello world under domain vote",
+		PrivateKey: "0x0101010101010101010101010101010101010101010101010101010101010101",
+		Domain:     "vote",
+		Message:    "Hello, world!",
+		PublicKey:  "0x1b84c5567b126440995d3ed5aaba0565d71e1834604819ff9c17f5e9d5dd078f",
+		Digest:     "0x4124dd4b5a0b8e6c165dd206e4090e6c19d7937232a20680b053e9f27adca4ef",
+		Signature:  "0xe39d70bcf0c4d02687225c3b9baa14f035180665ee6cd1d21664423ad871c70978fc6a54892e2c54dfc1ee9728120e826169af2aee61d333fd0d2732715f6861",
+	},
+	{
+		Name:       "sha256 hello world",
+		PrivateKey: "0x0101010101010101010101010101010101010101010101010101010101010101",
+		Hash:       signer.HashSHA256,
+		Message:    "Hello, world!",
+		PublicKey:  "0x1b84c5567b126440995d3ed5aaba0565d71e1834604819ff9c17f5e9d5dd078f",
+		Digest:     "0x315f5bdb76d078c43b8ac0064e4a0164612b1fce77c869345bfc94c75894edd3",
+		Signature:  "0xb70f845a14ea897bfd71efe39140c5123d45240f8d97e2fd1cdfcf69c18f4588e85b64be478f884e5d19ad26c405e5b53e6c6c7cf22f5db390a852eb37f47b80",
+	},
+	{
+		Name:       "bip340 tagged hello world",
+		PrivateKey: "0x0101010101010101010101010101010101010101010101010101010101010101",
+		Hash:       signer.HashBIP340,
+		HashTag:    "Unchained/message",
+		Message:    "Hello, world!",
+		PublicKey:  "0x1b84c5567b126440995d3ed5aaba0565d71e1834604819ff9c17f5e9d5dd078f",
+		Digest:     "0x6537b1e6302e52eac6b3801f7358c9b27c49ed578a6fc080c43cfc31ccc8caf9",
+		Signature:  "0xc5e78298545451580f7e230dbe9bf722f7627db3159019387b9feb368d324c5be273a152a22b24cade37d844ea670db84e937360a63ec2cd242e071dd023c420",
+	},
+	{
+		Name:       "hello world with nonce 1",
+		PrivateKey: "0x0101010101010101010101010101010101010101010101010101010101010101",
+		Nonce:      1,
+		HasNonce:   true,
+		Message:    "Hello, world!",
+		PublicKey:  "0x1b84c5567b126440995d3ed5aaba0565d71e1834604819ff9c17f5e9d5dd078f",
+		Digest:     "0x1fd996895005978510c1e6d12e805ad922e9e9ba79dae1a72085aaeed605d347",
+		Signature:  "0x9e63fddada8512fffb64d4658d4d6a2d8ec35d09852ecd15426c56cf0d7f91eb44807d2ed942d0d8abe03c0c9100bcd766f18955f1759ace14a8960cd2d283bc",
+	},
+	{
+		Name:       "hello world with nonce 2",
+		PrivateKey: "0x0101010101010101010101010101010101010101010101010101010101010101",
+		Nonce:      2,
+		HasNonce:   true,
+		Message:    "Hello, world!",
+		PublicKey:  "0x1b84c5567b126440995d3ed5aaba0565d71e1834604819ff9c17f5e9d5dd078f",
+		Digest:     "0x368708c62de92efdae0dc1d621a0e18cee73bcc7bf9cc82942db8ffe3dca709a",
+		Signature:  "0xebf0e730be4849157ace7bd106c700e111aa54fd382678be52977997376c1959483c0fa4bc96f05a55b8ad82739a2ba5d7b0157ae3324e777b7fec1fdf9af821",
+	},
+	{
+		Name:       "fields ab, c",
+		PrivateKey: "0x0101010101010101010101010101010101010101010101010101010101010101",
+		Fields:     []string{"ab", "c"},
+		PublicKey:  "0x1b84c5567b126440995d3ed5aaba0565d71e1834604819ff9c17f5e9d5dd078f",
+		Digest:     "0xcad61d9c047b25c8f5cd7998ad0cb580fc5ecdd587c0809df49c68b518fee8b6",
+		Signature:  "0x6d327dc7f4b34daded131c2e91e86a27273351f5ce60dc415514b6b69ff4f628a862d5a4708dc32203c433f0cc0b6f571df14d16e3b3d5fa987e3237c876511a",
+	},
+	{
+		Name:       "fields a, bc, same bytes split differently",
+		PrivateKey: "0x0101010101010101010101010101010101010101010101010101010101010101",
+		Fields:     []string{"a", "bc"},
+		PublicKey:  "0x1b84c5567b126440995d3ed5aaba0565d71e1834604819ff9c17f5e9d5dd078f",
+		Digest:     "0x770ba333d4c2394f45d61bcface950e3214cb5803c35ba2ec4bc944c53e06b38",
+		Signature:  "0xf344de7394f2beb5b04330e749a2ee3222edc5382f98a3a21010fec347e92f068dceb20da20c4aa0689143d444143328f3efafdbcad5f9f6dc2a4a777dc7f586",
+	},
+	{
+		Name:       "EIP-712 NftPrices",
+		PrivateKey: "0x0101010101010101010101010101010101010101010101010101010101010101",
+		PublicKey:  "0x1b84c5567b126440995d3ed5aaba0565d71e1834604819ff9c17f5e9d5dd078f",
+		Digest:     "0xf99e9fb0dd40df6bfc85f1acf769a99530f238771ab42773e60f5f77e41167c4",
+		Signature:  nftPricesSignature,
+		typed:      nftPricesDigest,
+	},
+}
+
+// nftPricesDigest hashes nfts [1, 2] priced at [1e18, 16] with nonce 0
+// for a ProofOfStake deployed at the first hardhat address.
+func nftPricesDigest() ([]byte, error) {
+	contract, err := signer.ParseAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	if err != nil {
+		return nil, err
+	}
+
+	separator, err := eip712.Domain{
+		Name:              "Unchained Proof of Stake",
+		Version:           "1.0.0",
+		ChainID:           big.NewInt(31337),
+		VerifyingContract: contract,
+	}.Separator()
+	if err != nil {
+		return nil, err
+	}
+
+	nfts, prices, nonce := nftPricesPayload()
+	return eip712.HashNftPrices(separator, nfts, prices, nonce)
+}
+
+func nftPricesPayload() (nfts, prices []*big.Int, nonce *big.Int) {
+	oneEther := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	return []*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{oneEther, big.NewInt(16)}, big.NewInt(0)
+}
+
+// Fixture is the computed form of a Vector as written to fixtures.json:
+// the exact bytes that were hashed, the digest and the signature. Typed
+// data vectors have no message and name eip712 as their hash.
+type Fixture struct {
+	Name       string `json:"name"`
+	PrivateKey string `json:"privateKey"`
+	PublicKey  string `json:"publicKey"`
+	Hash       string `json:"hash"`
+	HashTag    string `json:"hashTag,omitempty"`
+	Domain     string `json:"domain,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Digest     string `json:"digest"`
+	Signature  string `json:"signature"`
+}
+
+// Compute derives the vector's public key, digest and signature from
+// its inputs, without comparing them to the pinned values.
+func (v Vector) Compute() (Fixture, error) {
+	priv, pub, err := signer.LoadKeyFromHex(v.PrivateKey)
+	if err != nil {
+		return Fixture{}, err
+	}
+	defer priv.Zero()
+
+	message := []byte(v.Message)
+	if v.Fields != nil {
+		fields := make([][]byte, len(v.Fields))
+		for i, field := range v.Fields {
+			fields[i] = []byte(field)
+		}
+		message = signer.EncodeFields(fields...)
+	}
+	if v.HasNonce {
+		message, err = signer.EncodeWithNonce(big.NewInt(v.Nonce), message)
+		if err != nil {
+			return Fixture{}, err
+		}
+	}
+
+	hashName := v.Hash
+	if hashName == "" {
+		hashName = signer.HashKeccak256
+	}
+	hasher, err := signer.NewHasher(hashName, []byte(v.HashTag))
+	if err != nil {
+		return Fixture{}, err
+	}
+
+	digest := hasher.Hash(message)
+	if v.Domain != "" {
+		digest = signer.HashWithDomain(hasher, []byte(v.Domain), message)
+	}
+
+	f := Fixture{
+		Name:       v.Name,
+		PrivateKey: v.PrivateKey,
+		PublicKey:  fmt.Sprintf("0x%x", signer.XOnlyPubKey(pub)),
+		Hash:       hashName,
+		HashTag:    v.HashTag,
+		Domain:     v.Domain,
+		Message:    fmt.Sprintf("0x%x", message),
+	}
+
+	if v.typed != nil {
+		digest, err = v.typed()
+		if err != nil {
+			return Fixture{}, err
+		}
+		f.Hash, f.HashTag, f.Domain, f.Message = "eip712", "", "", ""
+	}
+
+	signature, err := signer.SignDeterministic(priv, digest)
+	if err != nil {
+		return Fixture{}, err
+	}
+
+	f.Digest = fmt.Sprintf("0x%x", digest)
+	f.Signature = fmt.Sprintf("0x%x", signature.Serialize())
+	return f, nil
+}
+
+// Check recomputes the vector and returns an error describing the first
+// field that does not match.
+func (v Vector) Check() error {
+	f, err := v.Compute()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case f.PublicKey != v.PublicKey:
+		return fmt.Errorf("public key is %s, want %s", f.PublicKey, v.PublicKey)
+	case f.Digest != v.Digest:
+		return fmt.Errorf("digest is %s, want %s", f.Digest, v.Digest)
+	case f.Signature != v.Signature:
+		return fmt.Errorf("signature is %s, want %s", f.Signature, v.Signature)
+	}
+
+	if f.Message != "" {
+		if err := f.checkStreamed(); err != nil {
+			return err
+		}
+	}
+
+	return f.Verify()
+}
+
+// Verify checks that the fixture's signature is valid for its digest
+// and public key.
+func (f Fixture) Verify() error {
+	pub, err := signer.ParsePublicKey(f.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	digest, err := signer.DecodeHash(f.Digest)
+	if err != nil {
+		return err
+	}
+
+	signature, err := signer.ParseSignature(f.Signature)
+	if err != nil {
+		return err
+	}
+
+	if !signer.VerifyMessage(pub, digest, signature) {
+		return fmt.Errorf("signature does not verify")
+	}
+
+	return nil
+}
+
+// checkStreamed hashes the fixture's message with signer.DigestReader
+// and checks that it gives the same digest as hashing it all at once.
+func (f Fixture) checkStreamed() error {
+	message, err := signer.DecodeHex(f.Message)
+	if err != nil {
+		return err
+	}
+
+	var domain []byte
+	if f.Domain != "" {
+		domain = []byte(f.Domain)
+	}
+
+	streamed, err := signer.DigestReader(f.Hash, []byte(f.HashTag), domain, iotest.OneByteReader(bytes.NewReader(message)))
+	if err != nil {
+		return err
+	}
+
+	if got := fmt.Sprintf("0x%x", streamed); got != f.Digest {
+		return fmt.Errorf("streamed digest is %s, want %s", got, f.Digest)
+	}
+	return nil
+}
+
+// Fixtures computes the fixture of every vector, in order.
+func Fixtures() ([]Fixture, error) {
+	fixtures := make([]Fixture, len(Vectors))
+	for i, v := range Vectors {
+		f, err := v.Compute()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Name, err)
+		}
+		fixtures[i] = f
+	}
+	return fixtures, nil
+}
+
+// MarshalFixtures encodes fixtures the way fixtures.json is written.
+func MarshalFixtures(fixtures []Fixture) ([]byte, error) {
+	data, err := json.MarshalIndent(fixtures, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// CheckFixtures checks that every committed fixture verifies and that
+// regenerating them gives the committed file byte for byte, so an
+// intentional format change shows up as a diff of fixtures.json.
+func CheckFixtures() error {
+	var committed []Fixture
+	if err := json.Unmarshal(fixturesJSON, &committed); err != nil {
+		return fmt.Errorf("fixtures.json: %w", err)
+	}
+
+	for _, f := range committed {
+		if err := f.Verify(); err != nil {
+			return fmt.Errorf("fixture %q: %w", f.Name, err)
+		}
+	}
+
+	fixtures, err := Fixtures()
+	if err != nil {
+		return err
+	}
+
+	generated, err := MarshalFixtures(fixtures)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(generated, fixturesJSON) {
+		return fmt.Errorf("fixtures.json is stale, regenerate it with go run ./genfixtures")
+	}
+
+	return nil
+}
+
+// Weighted aggregate vector: keys 1, 2 and 3 with weights 5, 1 and 100.
+const weightedAggregate = "0x034c3fc05f251c7d3f09e80d0ce5b77ad63f7be40579f91fe678020271949c64c7"
+
+// CheckWeightedAggregate recomputes the pinned weighted aggregate key.
+func CheckWeightedAggregate() error {
+	weights := []*big.Int{big.NewInt(5), big.NewInt(1), big.NewInt(100)}
+	keys := make([]*btcec.PublicKey, len(weights))
+	for i := range keys {
+		var k btcec.ModNScalar
+		k.SetInt(uint32(i + 1))
+		keys[i] = (&btcec.PrivateKey{Key: k}).PubKey()
+	}
+
+	agg, err := aggsig.AggregateWeightedPublicKeys(keys, weights)
+	if err != nil {
+		return err
+	}
+
+	if got := fmt.Sprintf("0x%x", agg.SerializeCompressed()); got != weightedAggregate {
+		return fmt.Errorf("weighted aggregate is %s, want %s", got, weightedAggregate)
+	}
+	return nil
+}
+
+// NftPrices calldata vector: setNftPrices with the EIP-712 NftPrices
+// vector's payload and signature.
+const (
+	nftPricesSignature = "0xc87ef9c77e6a7498255f1037e69d24bd1249e533ad50d1d58db896fa57aff5dae1689fd89fc63594ce16f173dae65b83b9f43c9a505324ac027c688adba28837"
+	nftPricesCalldata  = "0x556935eb" +
+		"0000000000000000000000000000000000000000000000000000000000000060" +
+		"c87ef9c77e6a7498255f1037e69d24bd1249e533ad50d1d58db896fa57aff5da" +
+		"e1689fd89fc63594ce16f173dae65b83b9f43c9a505324ac027c688adba28837" +
+		"0000000000000000000000000000000000000000000000000000000000000060" +
+		"00000000000000000000000000000000000000000000000000000000000000c0" +
+		"0000000000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000de0b6b3a7640000" +
+		"0000000000000000000000000000000000000000000000000000000000000010"
+)
+
+// CheckNftPricesCalldata re-encodes the pinned setNftPrices calldata.
+func CheckNftPricesCalldata() error {
+	sig, err := signer.DecodeHex(nftPricesSignature)
+	if err != nil {
+		return err
+	}
+
+	nfts, prices, nonce := nftPricesPayload()
+	calldata, err := eip712.EncodeSetNftPrices(nfts, prices, nonce, sig)
+	if err != nil {
+		return err
+	}
+
+	if got := fmt.Sprintf("0x%x", calldata); got != nftPricesCalldata {
+		return fmt.Errorf("setNftPrices calldata is %s, want %s", got, nftPricesCalldata)
+	}
+	return nil
+}
+
+// CheckAll checks every vector.
+func CheckAll() error {
+	for _, v := range Vectors {
+		if err := v.Check(); err != nil {
+			return fmt.Errorf("%s: %w", v.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package vectors
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+func TestVectors(t *testing.T) {
+	for _, v := range Vectors {
+		t.Run(v.Name, func(t *testing.T) {
+			if err := v.Check(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestVectorsSignAndVerify checks the pinned values directly against the
+// signer package rather than through Compute: the key derives the
+// pinned public key, signing the pinned digest gives the pinned
+// signature, and the signature verifies only as pinned.
+func TestVectorsSignAndVerify(t *testing.T) {
+	for _, v := range Vectors {
+		t.Run(v.Name, func(t *testing.T) {
+			priv, pub, err := signer.LoadKeyFromHex(v.PrivateKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pinnedPub, err := signer.ParsePublicKey(v.PublicKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			digest, err := signer.DecodeHash(v.Digest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pinnedSig, err := signer.DecodeHex(v.Signature)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := signer.XOnlyPubKey(pub); !bytes.Equal(got, signer.XOnlyPubKey(pinnedPub)) {
+				t.Errorf("public key is 0x%x, want %s", got, v.PublicKey)
+			}
+
+			sig, err := signer.SignDeterministic(priv, digest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sig.Serialize(); !bytes.Equal(got, pinnedSig) {
+				t.Errorf("signature is 0x%x, want %s", got, v.Signature)
+			}
+
+			parsed, err := signer.ParseSignature(v.Signature)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !signer.VerifyMessage(pinnedPub, digest, parsed) {
+				t.Error("pinned signature does not verify")
+			}
+
+			// Any flipped bit in the digest or signature must fail.
+			for _, bit := range []int{0, 100, 255} {
+				flipped := append([]byte(nil), digest...)
+				flipped[bit/8] ^= 1 << (bit % 8)
+				if signer.VerifyMessage(pinnedPub, flipped, parsed) {
+					t.Errorf("signature verifies with digest bit %d flipped", bit)
+				}
+			}
+			for _, bit := range []int{0, 300, 511} {
+				flipped := append([]byte(nil), pinnedSig...)
+				flipped[bit/8] ^= 1 << (bit % 8)
+				if sig, err := signer.ParseSignature(hex.EncodeToString(flipped)); err == nil && signer.VerifyMessage(pinnedPub, digest, sig) {
+					t.Errorf("signature verifies with signature bit %d flipped", bit)
+				}
+			}
+		})
+	}
+}
+
+// The tagged hash vectors are recomputed here from crypto/sha256 alone,
+// so a wrong pinned digest cannot pass by agreeing with signer.
+func TestTaggedHashVectors(t *testing.T) {
+	for _, v := range TaggedHashVectors {
+		t.Run(fmt.Sprintf("%s/%q", v.Tag, v.Message), func(t *testing.T) {
+			tagHash := sha256.Sum256([]byte(v.Tag))
+			want := sha256.Sum256(bytes.Join([][]byte{tagHash[:], tagHash[:], []byte(v.Message)}, nil))
+			if got := "0x" + hex.EncodeToString(want[:]); got != v.Digest {
+				t.Errorf("pinned %s, computed %s", v.Digest, got)
+			}
+		})
+	}
+
+	if err := CheckTaggedHashes(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckChallengeRejects(t *testing.T) {
+	v := Vectors[0]
+	sig, err := signer.DecodeHex(v.Signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig[63] ^= 1
+	v.Signature = "0x" + hex.EncodeToString(sig)
+
+	if err := checkChallenge(v); err == nil {
+		t.Error("a tampered signature passed the challenge check")
+	}
+}
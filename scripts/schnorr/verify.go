@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/TimeleapLabs/go-schnorr/quorum"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+func verify(args []string) {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	publicKeyHex := flags.String("pubkey", "", "0x-prefixed public key X coordinate")
+	input := addInputFlags(flags)
+	input.now = time.Now
+	signatureHex := flags.String("signature", "", "0x-prefixed 64-byte signature")
+	requireCanonical := flags.Bool("require-canonical", false, "reject signatures the on-chain verifier would not accept, see signer.IsCanonical")
+	preimageOf := flags.String("verify-preimage", "", "0x-prefixed hash the signature covers; also check that the message hashes to it")
+	sigLayoutName := addSigLayoutFlag(flags)
+	validatorsFile := flags.String("validators", "", "JSON validator set file; also check that the signer is a validator with voting power")
+	parseFlags(flags, args)
+
+	var validators *quorum.ValidatorSet
+	if *validatorsFile != "" {
+		data, err := os.ReadFile(*validatorsFile)
+		if err != nil {
+			fatal(inputError("Error reading validator set: %w", err))
+		}
+		validators, err = quorum.ParseValidatorSet(data)
+		if err != nil {
+			fatal(inputError("Error parsing validator set: %w", err))
+		}
+	}
+
+	if layout := parseSigLayout(*sigLayoutName); layout != signer.SigLayoutRS {
+		raw, err := signer.DecodeHex(*signatureHex)
+		if err != nil {
+			fatal(inputError("Error parsing signature: %w", err))
+		}
+		rs, err := signer.ToRS(raw, layout)
+		if err != nil {
+			fatal(inputError("Error parsing signature: %w", err))
+		}
+		*signatureHex = fmt.Sprintf("0x%x", rs)
+	}
+
+	publicKey, err := signer.ParsePublicKey(*publicKeyHex)
+	if err != nil {
+		fatal(inputError("Error parsing public key: %w", err))
+	}
+
+	signature, err := signer.ParseSignature(*signatureHex)
+	if err != nil {
+		fatal(inputError("Error parsing signature: %w", err))
+	}
+
+	if *requireCanonical {
+		raw, err := signer.DecodeHex(*signatureHex)
+		if err != nil {
+			fatal(inputError("Error parsing signature: %w", err))
+		}
+		if !signer.IsCanonical(raw) {
+			fatal(verifyError("Signature is not canonical"))
+		}
+	}
+
+	hash, err := input.digest()
+	if err != nil {
+		fatal(fmt.Errorf("Error reading message: %w", err))
+	}
+
+	switch {
+	case *preimageOf != "":
+		verifyPreimage(publicKey, signature, *preimageOf, hash)
+	case signer.VerifyMessage(publicKey, hash, signature):
+		infof("Signature is valid")
+	default:
+		fatal(verifyError("Signature is invalid"))
+	}
+
+	if validators != nil {
+		verifyValidator(validators, publicKey)
+	}
+}
+
+// verifyValidator prints the registered identity and voting power of
+// the signer of an already verified signature, and fails with
+// exitVerify if it is not in the set or has no voting power.
+func verifyValidator(validators *quorum.ValidatorSet, publicKey *btcec.PublicKey) {
+	var pub quorum.PubKey
+	copy(pub[:], signer.XOnlyPubKey(publicKey))
+
+	validator, err := validators.Lookup(pub)
+	if err != nil {
+		fatal(verifyError("Signer is not accepted: %w", err))
+	}
+
+	fmt.Printf("Validator: %s\n", validator.Name)
+	fmt.Printf("Power: %s\n", validator.Power)
+	infof("Signer is a registered validator")
+}
+
+// verifyPreimage checks the signature against the claimed signed hash
+// first and only then that the message hashes to it, so a valid
+// signature paired with the wrong message exits with exitPreimage
+// rather than exitVerify.
+func verifyPreimage(publicKey *btcec.PublicKey, signature *schnorr.Signature, signedHashHex string, hash []byte) {
+	signedHash, err := signer.DecodeHash(signedHashHex)
+	if err != nil {
+		fatal(inputError("Error parsing -verify-preimage hash: %w", err))
+	}
+
+	if !signer.VerifyMessage(publicKey, signedHash, signature) {
+		fatal(verifyError("Signature is invalid"))
+	}
+
+	if !bytes.Equal(hash, signedHash) {
+		fatal(codedError(exitPreimage, "Message hashes to 0x%x, not the signed hash 0x%x", hash, signedHash))
+	}
+
+	infof("Signature and preimage are valid")
+}
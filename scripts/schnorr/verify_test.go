@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TimeleapLabs/go-schnorr/attestation"
+)
+
+func TestVerifyRequireCanonical(t *testing.T) {
+	res := runCLI(t, "sign", "-message", "hello", "-deterministic")
+	if res.code != 0 {
+		t.Fatalf("sign: exit %d: %s", res.code, res.stderr)
+	}
+	_, signature, _ := strings.Cut(res.stdout, "Signature: ")
+	signature = strings.TrimSpace(signature)
+
+	verify := func(sig string) cliResult {
+		return cliRun{}.run(t, "verify", "-message", "hello", "-pubkey", testPubKey, "-signature", sig, "-require-canonical")
+	}
+
+	if res := verify(signature); res.code != 0 {
+		t.Errorf("canonical signature: exit %d: %s", res.code, res.stderr)
+	}
+
+	nonCanonical := signature[:66] + strings.Repeat("f", 64)
+	res = verify(nonCanonical)
+	if res.code != exitVerify || !strings.Contains(res.stderr, "not canonical") {
+		t.Errorf("s past the curve order: exit %d, want %d: %s", res.code, exitVerify, res.stderr)
+	}
+}
+
+// signJSON signs message with the test key and returns its sign output.
+func signJSON(t *testing.T, message string, extra ...string) signOutput {
+	t.Helper()
+
+	res := runCLI(t, append([]string{"sign", "-message", message, "-output", "json"}, extra...)...)
+	if res.code != 0 {
+		t.Fatalf("sign: exit %d: %s", res.code, res.stderr)
+	}
+	var out signOutput
+	if err := json.Unmarshal([]byte(res.stdout), &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestVerifyPreimage(t *testing.T) {
+	hello := signJSON(t, "hello")
+	other := signJSON(t, "other")
+	domained := signJSON(t, "hello", "-domain", "feeds")
+
+	tests := []struct {
+		name      string
+		message   string
+		signature string
+		hash      string
+		extra     []string
+		want      int
+	}{
+		{"valid", "hello", hello.Signature, hello.MessageHash, nil, 0},
+		{"wrong preimage", "other", hello.Signature, hello.MessageHash, nil, exitPreimage},
+		{"invalid signature", "hello", other.Signature, hello.MessageHash, nil, exitVerify},
+		// The signature is checked first, so a wrong signature paired
+		// with the wrong message is reported as an invalid signature.
+		{"both wrong", "other", other.Signature, hello.MessageHash, nil, exitVerify},
+		{"valid signature for the claimed hash only", "hello", other.Signature, other.MessageHash, nil, exitPreimage},
+		{"valid under domain", "hello", domained.Signature, domained.MessageHash, []string{"-domain", "feeds"}, 0},
+		{"missing domain", "hello", domained.Signature, domained.MessageHash, nil, exitPreimage},
+		{"malformed hash", "hello", hello.Signature, "0x1234", nil, exitInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"verify", "-message", tt.message, "-pubkey", testPubKey,
+				"-signature", tt.signature, "-verify-preimage", tt.hash}, tt.extra...)
+			res := cliRun{}.run(t, args...)
+			if res.code != tt.want {
+				t.Errorf("exit %d, want %d: %s", res.code, tt.want, res.stderr)
+			}
+			if tt.want == exitPreimage && !strings.Contains(res.stderr, "not the signed hash") {
+				t.Errorf("preimage mismatch not reported as such: %s", res.stderr)
+			}
+		})
+	}
+}
+
+func TestSigLayoutRoundTrip(t *testing.T) {
+	rs := signJSON(t, "hello", "-deterministic")
+	sr := signJSON(t, "hello", "-deterministic", "-sig-layout", "s-r")
+
+	if want := "0x" + sr.Signature[66:] + sr.Signature[2:66]; rs.Signature != want {
+		t.Fatalf("s-r signature %s is not r-s %s swapped", sr.Signature, rs.Signature)
+	}
+
+	verify := func(sig string, extra ...string) int {
+		args := append([]string{"verify", "-message", "hello", "-pubkey", testPubKey, "-signature", sig}, extra...)
+		return cliRun{}.run(t, args...).code
+	}
+	if code := verify(rs.Signature); code != 0 {
+		t.Errorf("r-s signature: exit %d", code)
+	}
+	if code := verify(sr.Signature, "-sig-layout", "s-r"); code != 0 {
+		t.Errorf("s-r signature with -sig-layout s-r: exit %d", code)
+	}
+	if code := verify(sr.Signature); code == 0 {
+		t.Error("s-r signature verifies as r-s")
+	}
+}
+
+func TestVerifyValidators(t *testing.T) {
+	hello := signJSON(t, "hello")
+
+	tests := []struct {
+		name string
+		set  string
+		want int
+		out  string
+	}{
+		{"registered", `[{"publicKey": "` + testPubKey + `", "name": "validator-1", "power": 2000000}]`, 0, "Validator: validator-1\nPower: 2000000\n"},
+		{"unregistered", `[]`, exitVerify, "not a registered validator"},
+		{"zero power", `[{"publicKey": "` + testPubKey + `", "name": "retired", "power": 0}]`, exitVerify, `zero voting power: "retired"`},
+		{"malformed set", `[{"publicKey": "` + testPubKey + `"}]`, exitInput, "invalid validator set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "validators.json")
+			if err := os.WriteFile(path, []byte(tt.set), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			res := cliRun{}.run(t, "verify", "-message", "hello", "-pubkey", testPubKey, "-signature", hello.Signature, "-validators", path)
+			if res.code != tt.want {
+				t.Fatalf("exit %d, want %d: %s", res.code, tt.want, res.stderr)
+			}
+			if tt.want == 0 && !strings.HasSuffix(res.stdout, tt.out) {
+				t.Errorf("output %q, want it to end with %q", res.stdout, tt.out)
+			}
+			if tt.want != 0 && !strings.Contains(res.stderr, tt.out) {
+				t.Errorf("error %q does not say %q", res.stderr, tt.out)
+			}
+		})
+	}
+}
+
+func TestTimestampAttestation(t *testing.T) {
+	first := signJSON(t, "hello", "-deterministic", "-timestamp", "1700000000")
+	second := signJSON(t, "hello", "-deterministic", "-timestamp", "1700000001")
+	if first.Timestamp != "1700000000" {
+		t.Fatalf("timestamp is %q, want 1700000000", first.Timestamp)
+	}
+	if first.Signature == second.Signature {
+		t.Fatal("two timestamps gave the same signature")
+	}
+
+	// The sign output is an attestation carrying the timestamp.
+	data, err := json.Marshal(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bundle attestation.Attestation
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Timestamp == nil || bundle.Timestamp.Unix() != 1700000000 || !bundle.Verify() {
+		t.Errorf("attestation from sign output: %+v", bundle)
+	}
+
+	verify := func(extra ...string) int {
+		args := append([]string{"verify", "-message", "hello", "-pubkey", testPubKey, "-signature", first.Signature}, extra...)
+		return cliRun{}.run(t, args...).code
+	}
+	if code := verify("-timestamp", "1700000000"); code != 0 {
+		t.Errorf("right timestamp: exit %d", code)
+	}
+	if code := verify("-timestamp", "2023-11-14T22:13:20Z"); code != 0 {
+		t.Errorf("right timestamp as RFC 3339: exit %d", code)
+	}
+	if code := verify("-timestamp", "1700000001"); code != exitVerify {
+		t.Errorf("wrong timestamp: exit %d, want %d", code, exitVerify)
+	}
+	if code := verify(); code != exitVerify {
+		t.Errorf("no timestamp: exit %d, want %d", code, exitVerify)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/TimeleapLabs/go-schnorr/attestation"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+// verifyBatch checks every attestation bundle in a file, one per line,
+// either as JSON or as the binary layout in 0x-prefixed hex, as a
+// coordinator does with what a round produced. Bundles are numbered
+// from 0 in file order, blank lines skipped. A bundle that cannot be
+// decoded counts as invalid. The command exits with exitVerify if any
+// bundle is invalid, after printing the index of every one.
+func verifyBatch(args []string) {
+	flags := flag.NewFlagSet("verify-batch", flag.ExitOnError)
+	in := flags.String("in", "", "file of attestation bundles, one JSON or 0x-prefixed hex bundle per line")
+	workers := flags.Int("workers", 1, "verify bundles on this many goroutines")
+	parseFlags(flags, args)
+
+	if *in == "" {
+		fatal(usageError("-in is required"))
+	}
+	if *workers < 1 {
+		fatal(usageError("-workers must be at least 1"))
+	}
+
+	lines, err := readBatchLines(*in)
+	if err != nil {
+		fatal(inputError("Error reading bundles: %w", err))
+	}
+	if len(lines) == 0 {
+		fatal(inputError("No bundles found in %s", *in))
+	}
+
+	failures := verifyBundles(lines, *workers)
+	for _, failure := range failures {
+		errorf("Bundle %d (line %d): %v", failure.index, failure.line, failure.err)
+	}
+	reportBundles(os.Stdout, len(lines), failures)
+
+	if len(failures) > 0 {
+		fatal(verifyError("%d of %d bundles are invalid", len(failures), len(lines)))
+	}
+}
+
+// bundleFailure is a bundle that did not verify: its index among the
+// bundles, its line in the file and why.
+type bundleFailure struct {
+	index, line int
+	err         error
+}
+
+// verifyBundles verifies every bundle line on workers goroutines and
+// returns the failures in index order.
+func verifyBundles(lines []batchLine, workers int) []bundleFailure {
+	errs := make([]error, len(lines))
+	runWorkers(len(lines), workers, func(_, i int) {
+		errs[i] = verifyBundleLine(lines[i].data)
+	})
+
+	var failures []bundleFailure
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, bundleFailure{index: i, line: lines[i].number, err: err})
+		}
+	}
+	return failures
+}
+
+// reportBundles writes the valid and invalid counts out of total and
+// the index of every failed bundle.
+func reportBundles(w io.Writer, total int, failures []bundleFailure) {
+	fmt.Fprintf(w, "Valid: %d\n", total-len(failures))
+	fmt.Fprintf(w, "Invalid: %d\n", len(failures))
+	for _, failure := range failures {
+		fmt.Fprintf(w, "Failed: %d\n", failure.index)
+	}
+}
+
+func verifyBundleLine(line []byte) error {
+	var bundle attestation.Attestation
+	if bytes.HasPrefix(line, []byte("{")) {
+		if err := json.Unmarshal(line, &bundle); err != nil {
+			return err
+		}
+	} else {
+		data, err := signer.DecodeHex(string(line))
+		if err != nil {
+			return err
+		}
+		if err := bundle.UnmarshalBinary(data); err != nil {
+			return err
+		}
+	}
+
+	if !bundle.Verify() {
+		return fmt.Errorf("signature does not verify under 0x%x", bundle.PublicKey)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TimeleapLabs/go-schnorr/attestation"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+// bundleFile writes bundles over "a", "b" and "c" signed with the test
+// key to a file, with these failures among them:
+//
+//	line 1  bundle 0  valid JSON
+//	line 2            blank
+//	line 3  bundle 1  valid hex
+//	line 4  bundle 2  JSON with a tampered signature
+//	line 5  bundle 3  not hex
+//	line 6  bundle 4  valid hex
+//	line 7  bundle 5  JSON with a short message hash
+func bundleFile(t *testing.T) string {
+	t.Helper()
+
+	priv, err := signer.PrivateKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := func(message string) *attestation.Attestation {
+		hash := signer.HashMessage([]byte(message))
+		sig, err := signer.SignDeterministic(priv, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := attestation.New(hash, priv.PubKey(), sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	asJSON := func(a *attestation.Attestation) string {
+		data, err := json.Marshal(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	asHex := func(a *attestation.Attestation) string {
+		data, err := a.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fmt.Sprintf("0x%x", data)
+	}
+
+	tampered := bundle("b")
+	tampered.Signature[63] ^= 1
+
+	lines := []string{
+		asJSON(bundle("a")),
+		"",
+		asHex(bundle("b")),
+		asJSON(tampered),
+		"0xzz",
+		asHex(bundle("c")),
+		`{"messageHash": "0x12"}`,
+	}
+	path := filepath.Join(t.TempDir(), "bundles.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// wantReport is the summary of bundleFile.
+const wantReport = "Valid: 3\nInvalid: 3\nFailed: 2\nFailed: 3\nFailed: 5\n"
+
+func TestVerifyBundles(t *testing.T) {
+	lines, err := readBatchLines(bundleFile(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprint(workers, " workers"), func(t *testing.T) {
+			failures := verifyBundles(lines, workers)
+
+			wantIndices, wantLines := []int{2, 3, 5}, []int{4, 5, 7}
+			if len(failures) != len(wantIndices) {
+				t.Fatalf("got %d failures, want %d: %v", len(failures), len(wantIndices), failures)
+			}
+			for i, failure := range failures {
+				if failure.index != wantIndices[i] || failure.line != wantLines[i] || failure.err == nil {
+					t.Errorf("failure %d is bundle %d on line %d (%v), want bundle %d on line %d",
+						i, failure.index, failure.line, failure.err, wantIndices[i], wantLines[i])
+				}
+			}
+			if !strings.Contains(failures[0].err.Error(), "does not verify") {
+				t.Errorf("tampered bundle: %v", failures[0].err)
+			}
+
+			var report strings.Builder
+			reportBundles(&report, len(lines), failures)
+			if report.String() != wantReport {
+				t.Errorf("report is\n%s\nwant\n%s", report.String(), wantReport)
+			}
+		})
+	}
+
+	var report strings.Builder
+	reportBundles(&report, 2, nil)
+	if report.String() != "Valid: 2\nInvalid: 0\n" {
+		t.Errorf("report with no failures is %q", report.String())
+	}
+}
+
+func TestVerifyBatchCommand(t *testing.T) {
+	res := cliRun{}.run(t, "verify-batch", "-in", bundleFile(t), "-workers", "2")
+	if res.code != exitVerify {
+		t.Errorf("exit %d, want %d: %s", res.code, exitVerify, res.stderr)
+	}
+	if res.stdout != wantReport {
+		t.Errorf("stdout is\n%s\nwant\n%s", res.stdout, wantReport)
+	}
+	if !strings.Contains(res.stderr, "Bundle 3 (line 5)") {
+		t.Errorf("failure not reported with its line: %s", res.stderr)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/TimeleapLabs/go-schnorr/merkle"
+	"github.com/TimeleapLabs/go-schnorr/signer"
+)
+
+// verifyProof checks a leaf against a signed merkle root: the signature
+// must be valid for the root and the proof must place the leaf at
+// -index under that root. An invalid signature exits with exitVerify
+// and an invalid proof with exitBadProof, so callers can tell which
+// check failed.
+func verifyProof(args []string) {
+	flags := flag.NewFlagSet("verify-proof", flag.ExitOnError)
+	publicKeyHex := flags.String("pubkey", "", "0x-prefixed public key X coordinate")
+	rootHex := flags.String("root", "", "0x-prefixed 32-byte merkle root")
+	signatureHex := flags.String("signature", "", "0x-prefixed 64-byte signature over the root")
+	leafData := flags.String("leaf", "", "leaf data, hex (0x-prefixed) or raw")
+	leafFile := flags.String("leaf-file", "", "read the leaf data from a file")
+	proofHex := flags.String("proof", "", "comma separated 0x-prefixed sibling hashes, bottom first")
+	index := flags.Int("index", 0, "position of the leaf in the tree")
+	parseFlags(flags, args)
+
+	publicKey, err := signer.ParsePublicKey(*publicKeyHex)
+	if err != nil {
+		fatal(inputError("Error parsing public key: %w", err))
+	}
+
+	root, err := signer.DecodeHash(*rootHex)
+	if err != nil {
+		fatal(inputError("Error parsing root: %w", err))
+	}
+
+	signature, err := signer.ParseSignature(*signatureHex)
+	if err != nil {
+		fatal(inputError("Error parsing signature: %w", err))
+	}
+
+	var leaf []byte
+	switch {
+	case *leafData != "" && *leafFile != "":
+		fatal(usageError("only one of -leaf or -leaf-file may be used"))
+	case *leafFile != "":
+		leaf, err = os.ReadFile(*leafFile)
+	case strings.HasPrefix(*leafData, "0x"):
+		leaf, err = signer.DecodeHex(*leafData)
+	default:
+		leaf = []byte(*leafData)
+	}
+	if err != nil {
+		fatal(inputError("Error reading leaf: %w", err))
+	}
+
+	var proof [][]byte
+	if *proofHex != "" {
+		for i, siblingHex := range strings.Split(*proofHex, ",") {
+			sibling, err := signer.DecodeHash(siblingHex)
+			if err != nil {
+				fatal(inputError("Error parsing proof element %d: %w", i, err))
+			}
+			proof = append(proof, sibling)
+		}
+	}
+
+	if !signer.VerifyMessage(publicKey, root, signature) {
+		fatal(verifyError("Signature over the root is invalid"))
+	}
+
+	if !merkle.VerifyProof(root, leaf, proof, *index) {
+		fatal(codedError(exitBadProof, "Merkle proof is invalid"))
+	}
+
+	infof("Signature and proof are valid")
+}
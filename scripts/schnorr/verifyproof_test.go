@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/TimeleapLabs/go-schnorr/merkle"
+)
+
+func TestVerifyProofExitCodes(t *testing.T) {
+	leaves := [][]byte{[]byte("alpha"), []byte("bravo"), []byte("charlie")}
+	tree, err := merkle.New(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := fmt.Sprintf("0x%x", tree.Root())
+	signed := signJSON(t, root, "-hash-only")
+	other := signJSON(t, "other")
+
+	proof, err := tree.Proof(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrongSibling := append([][]byte{merkle.HashLeaf([]byte("delta"))}, proof[1:]...)
+	otherProof, err := tree.Proof(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flip the last hex digit of r, which keeps the signature well
+	// formed but no longer valid.
+	tampered := []byte(signed.Signature)
+	if tampered[65] == '0' {
+		tampered[65] = '1'
+	} else {
+		tampered[65] = '0'
+	}
+
+	tests := []struct {
+		name      string
+		signature string
+		leaf      string
+		proof     [][]byte
+		index     int
+		want      int
+	}{
+		{"valid", signed.Signature, "bravo", proof, 1, 0},
+		{"valid hex leaf", signed.Signature, "0x627261766f", proof, 1, 0},
+		{"tampered signature", string(tampered), "bravo", proof, 1, exitVerify},
+		{"signature over another message", other.Signature, "bravo", proof, 1, exitVerify},
+		{"wrong sibling", signed.Signature, "bravo", wrongSibling, 1, exitBadProof},
+		{"wrong index", signed.Signature, "bravo", proof, 0, exitBadProof},
+		{"index past the tree", signed.Signature, "bravo", proof, 5, exitBadProof},
+		{"wrong leaf", signed.Signature, "alpha", proof, 1, exitBadProof},
+		{"another leaf's proof", signed.Signature, "bravo", otherProof, 1, exitBadProof},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := cliRun{}.run(t, "verify-proof", "-pubkey", testPubKey, "-root", root,
+				"-signature", tt.signature, "-leaf", tt.leaf, "-proof", joinProof(tt.proof), "-index", strconv.Itoa(tt.index))
+			if res.code != tt.want {
+				t.Errorf("exit %d, want %d: %s", res.code, tt.want, res.stderr)
+			}
+		})
+	}
+}
+
+// joinProof formats proof as the -proof flag takes it.
+func joinProof(proof [][]byte) string {
+	siblings := make([]string, len(proof))
+	for i, sibling := range proof {
+		siblings[i] = fmt.Sprintf("0x%x", sibling)
+	}
+	return strings.Join(siblings, ",")
+}
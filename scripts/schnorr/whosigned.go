@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/TimeleapLabs/go-schnorr/signer"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// whoSigned finds which of a set of known public keys produced a
+// signature, for signatures that arrive without an identity attached.
+func whoSigned(args []string) {
+	flags := flag.NewFlagSet("whosigned", flag.ExitOnError)
+	pubkeys := flags.String("pubkeys", "", "comma separated 0x-prefixed public key X coordinates")
+	pubkeysFile := flags.String("pubkeys-file", "", "read the public keys from a file, one per line")
+	input := addInputFlags(flags)
+	input.now = time.Now
+	signatureHex := flags.String("signature", "", "0x-prefixed 64-byte signature")
+	parseFlags(flags, args)
+
+	keys, err := readKeySet(*pubkeys, *pubkeysFile, signer.ParsePublicKey)
+	if err != nil {
+		fatal(inputError("Error reading public keys: %w", err))
+	}
+
+	signature, err := signer.ParseSignature(*signatureHex)
+	if err != nil {
+		fatal(inputError("Error parsing signature: %w", err))
+	}
+
+	hash, err := input.digest()
+	if err != nil {
+		fatal(fmt.Errorf("Error reading message: %w", err))
+	}
+
+	debugf("Trying %d public keys", len(keys))
+	index, err := signer.FindSigner(keys, hash, signature)
+	if errors.Is(err, signer.ErrNoMatch) {
+		fatal(verifyError("No public key in the set matches the signature"))
+	}
+	if err != nil {
+		fatal(fmt.Errorf("Error finding signer: %w", err))
+	}
+
+	fmt.Printf("Signer: %d\n", index)
+	fmt.Printf("Public key: 0x%x\n", signer.XOnlyPubKey(keys[index]))
+}
+
+// readKeySet parses the keys given with -pubkeys or -pubkeys-file using
+// parse. Blank lines and lines starting with # in the file are skipped.
+// Parsing stops once the set is larger than signer.MaxKeySet.
+func readKeySet(list, path string, parse func(string) (*btcec.PublicKey, error)) ([]*btcec.PublicKey, error) {
+	var entries []string
+	switch {
+	case list != "" && path != "":
+		return nil, usageError("only one of -pubkeys or -pubkeys-file may be used")
+	case path != "":
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			entries = append(entries, line)
+			if len(entries) > signer.MaxKeySet {
+				break
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	case list != "":
+		entries = strings.Split(list, ",")
+	}
+
+	if len(entries) == 0 {
+		return nil, signer.ErrNoKeys
+	}
+	if len(entries) > signer.MaxKeySet {
+		return nil, fmt.Errorf("%w: at most %d are allowed", signer.ErrTooManyKeys, signer.MaxKeySet)
+	}
+
+	keys := make([]*btcec.PublicKey, len(entries))
+	for i, entry := range entries {
+		key, err := parse(strings.TrimSpace(entry))
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		keys[i] = key
+	}
+
+	return keys, nil
+}